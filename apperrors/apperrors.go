@@ -0,0 +1,154 @@
+package apperrors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Kind là loại lỗi nghiệp vụ, quyết định HTTP status và gRPC code trả về cho client
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindBadRequest
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindUnavailable
+)
+
+// FieldError mô tả lỗi của một field trong request. Message được dịch từ Code khi trả về client.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Params là giá trị thay vào message, ví dụ {"param": "8"} với code min_length
+	Params map[string]string `json:"-"`
+}
+
+// Error là lỗi trả về cho client. Code là mã ổn định để client xử lý và là key của message
+// trong catalogue, cause chỉ dùng để ghi log và không bao giờ được trả về trong response.
+type Error struct {
+	Kind   Kind
+	Code   string
+	Params map[string]string
+	Fields []FieldError
+	// Data là thông tin thêm trả về trong field data của response
+	Data  interface{}
+	cause error
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Code + ": " + e.cause.Error()
+	}
+	return e.Code
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Status trả về HTTP status tương ứng với Kind
+func (e *Error) Status() int {
+	switch e.Kind {
+	case KindBadRequest:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindValidation:
+		return http.StatusUnprocessableEntity
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// WithData trả về bản sao của lỗi kèm data, lỗi gốc thường là biến dùng chung nên không sửa trực tiếp
+func (e *Error) WithData(data interface{}) *Error {
+	copied := *e
+	copied.Data = data
+	return &copied
+}
+
+// WithParams trả về bản sao của lỗi kèm giá trị thay vào message
+func (e *Error) WithParams(params map[string]string) *Error {
+	copied := *e
+	copied.Params = params
+	return &copied
+}
+
+// Wrap trả về bản sao của lỗi giữ cause để ghi log
+func (e *Error) Wrap(cause error) *Error {
+	copied := *e
+	copied.cause = cause
+	return &copied
+}
+
+func newError(kind Kind, code string) *Error {
+	return &Error{Kind: kind, Code: code}
+}
+
+func BadRequest(code string) *Error {
+	return newError(KindBadRequest, code)
+}
+
+func Unauthorized(code string) *Error {
+	return newError(KindUnauthorized, code)
+}
+
+func Forbidden(code string) *Error {
+	return newError(KindForbidden, code)
+}
+
+func NotFound(code string) *Error {
+	return newError(KindNotFound, code)
+}
+
+func Conflict(code string) *Error {
+	return newError(KindConflict, code)
+}
+
+// Validation tạo lỗi 422 với chi tiết từng field
+func Validation(fields ...FieldError) *Error {
+	e := newError(KindValidation, "validation_failed")
+	e.Fields = fields
+	return e
+}
+
+// InvalidField tạo lỗi validation cho một field, params có thể nil
+func InvalidField(field, code string, params map[string]string) *Error {
+	return Validation(FieldError{Field: field, Code: code, Params: params})
+}
+
+func Unavailable(code string, cause error) *Error {
+	return newError(KindUnavailable, code).Wrap(cause)
+}
+
+func Internal(cause error) *Error {
+	return newError(KindInternal, "internal_error").Wrap(cause)
+}
+
+// From chuyển một lỗi bất kỳ thành *Error. Lỗi timeout hoặc mất kết nối tới storage là 503,
+// các lỗi không xác định khác là 500 để không lộ chi tiết nội bộ.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, context.DeadlineExceeded) || mongo.IsTimeout(err) || mongo.IsNetworkError(err) {
+		return Unavailable("storage_unavailable", err)
+	}
+	return Internal(err)
+}
@@ -0,0 +1,74 @@
+package apperrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want int
+	}{
+		{BadRequest("invalid_id"), http.StatusBadRequest},
+		{Unauthorized("unauthorized"), http.StatusUnauthorized},
+		{Forbidden("missing_scope"), http.StatusForbidden},
+		{NotFound("connection_not_found"), http.StatusNotFound},
+		{Conflict("webview_server_name_taken"), http.StatusConflict},
+		{InvalidField("name", "required", nil), http.StatusUnprocessableEntity},
+		{Unavailable("storage_unavailable", errors.New("timeout")), http.StatusServiceUnavailable},
+		{Internal(errors.New("boom")), http.StatusInternalServerError},
+		{&Error{Kind: Kind(99), Code: "unknown_kind"}, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Code, func(t *testing.T) {
+			if got := tt.err.Status(); got != tt.want {
+				t.Fatalf("Status() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrom(t *testing.T) {
+	notFound := NotFound("connection_not_found")
+
+	tests := []struct {
+		name     string
+		err      error
+		wantKind Kind
+		wantCode string
+	}{
+		{"app error", notFound, KindNotFound, "connection_not_found"},
+		{"wrapped app error", fmt.Errorf("find: %w", notFound), KindNotFound, "connection_not_found"},
+		{"deadline exceeded", fmt.Errorf("query: %w", context.DeadlineExceeded), KindUnavailable, "storage_unavailable"},
+		{"unknown error", errors.New("connection refused to 10.0.0.5 with password hunter2"), KindInternal, "internal_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := From(tt.err)
+			if got.Kind != tt.wantKind || got.Code != tt.wantCode {
+				t.Fatalf("From = %v/%s, want %v/%s", got.Kind, got.Code, tt.wantKind, tt.wantCode)
+			}
+			// Lỗi gốc vẫn giữ làm cause để ghi log
+			if !errors.Is(got, tt.err) && !errors.Is(tt.err, got) {
+				t.Fatalf("From lost the cause of %v", tt.err)
+			}
+		})
+	}
+}
+
+func TestWithDataDoesNotModifyShared(t *testing.T) {
+	shared := Conflict("webview_server_name_taken")
+	copied := shared.WithData("id").WithParams(map[string]string{"name": "a"}).Wrap(errors.New("duplicate"))
+	if shared.Data != nil || shared.Params != nil || shared.Unwrap() != nil {
+		t.Fatalf("shared error was modified: %+v", shared)
+	}
+	if copied.Data != "id" || copied.Params["name"] != "a" || copied.Unwrap() == nil {
+		t.Fatalf("copied = %+v", copied)
+	}
+}
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"draft-notification/models"
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// Field không đưa vào diff vì thay đổi ở mọi lần cập nhật
+var ignoredFields = map[string]bool{"updatedAt": true}
+
+// Diff so sánh hai entity theo dạng JSON của chúng, nên các field json:"-"
+// (hash API key, webhook secret, password hash...) không bao giờ xuất hiện trong audit.
+// before hoặc after nil nghĩa là entity được tạo mới hoặc bị xoá.
+func Diff(before, after interface{}) []models.AuditChange {
+	beforeFields := flatten(before)
+	afterFields := flatten(after)
+
+	fields := make([]string, 0, len(beforeFields)+len(afterFields))
+	for field := range beforeFields {
+		fields = append(fields, field)
+	}
+	for field := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := []models.AuditChange{}
+	for _, field := range fields {
+		if ignoredFields[field] {
+			continue
+		}
+		beforeValue, afterValue := beforeFields[field], afterFields[field]
+		if reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+		changes = append(changes, models.AuditChange{Field: field, Before: beforeValue, After: afterValue})
+	}
+	return changes
+}
+
+// flatten chuyển entity thành map field → giá trị, object lồng nhau dùng key dạng "a.b"
+func flatten(entity interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if entity == nil || (reflect.ValueOf(entity).Kind() == reflect.Pointer && reflect.ValueOf(entity).IsNil()) {
+		return fields
+	}
+
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return fields
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return fields
+	}
+
+	flattenInto(fields, "", doc)
+	return fields
+}
+
+func flattenInto(fields map[string]interface{}, prefix string, doc map[string]interface{}) {
+	for key, value := range doc {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenInto(fields, key, nested)
+			continue
+		}
+		fields[key] = value
+	}
+}
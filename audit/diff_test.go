@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"draft-notification/models"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDiff(t *testing.T) {
+	createdAt := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	connection := models.Connection{
+		Id:                           primitive.NewObjectID(),
+		Status:                       "inactive",
+		WebviewServerApiKeyPrefix:    "abcd1234",
+		WebviewServerApiKeyHash:      "hash-1",
+		UserDeliveryServerWebHookUrl: "https://hooks.example.com/a",
+		WebhookSecret:                "secret-1",
+		CreatedAt:                    createdAt,
+		UpdatedAt:                    createdAt,
+	}
+	// rotated đổi key, secret và thêm rotation lồng nhau
+	rotated := connection
+	rotated.WebviewServerApiKeyPrefix = "efgh5678"
+	rotated.WebviewServerApiKeyHash = "hash-2"
+	rotated.WebhookSecret = "secret-2"
+	rotated.WebviewServerApiKeyRotation = &models.ApiKeyRotation{PreviousKeyPrefix: "abcd1234", PreviousKeyHash: "hash-1"}
+	rotated.UpdatedAt = createdAt.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+		fields []string
+		// subset chỉ yêu cầu fields nằm trong diff, dùng khi entity được tạo hoặc xoá
+		subset bool
+	}{
+		{"unchanged", connection, connection, nil, false},
+		{"only updatedAt", connection, func() models.Connection { c := connection; c.UpdatedAt = time.Now(); return c }(), nil, false},
+		{"status", connection, func() models.Connection { c := connection; c.Status = "active"; return c }(), []string{"status"}, false},
+		{"secrets and nested rotation", connection, rotated, []string{
+			"webviewServerApiKeyPrefix",
+			"webviewServerApiKeyRotation.previousKeyExpiresAt",
+			"webviewServerApiKeyRotation.previousKeyPrefix",
+			"webviewServerApiKeyRotation.rotatedAt",
+			"webviewServerApiKeyRotation.rotatedBy",
+		}, false},
+		{"nested removed", rotated, func() models.Connection { c := rotated; c.WebviewServerApiKeyRotation = nil; return c }(), []string{
+			"webviewServerApiKeyRotation.previousKeyExpiresAt",
+			"webviewServerApiKeyRotation.previousKeyPrefix",
+			"webviewServerApiKeyRotation.rotatedAt",
+			"webviewServerApiKeyRotation.rotatedBy",
+		}, false},
+		{"created", nil, &connection, []string{"id", "status", "webviewServerApiKeyPrefix", "createdAt"}, true},
+		{"deleted", &connection, (*models.Connection)(nil), []string{"id", "status", "webviewServerApiKeyPrefix", "createdAt"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := Diff(tt.before, tt.after)
+			fields := []string{}
+			for _, change := range changes {
+				fields = append(fields, change.Field)
+				// Field json:"-" không được xuất hiện dưới bất kỳ dạng nào
+				for _, value := range []interface{}{change.Before, change.After} {
+					if s, ok := value.(string); ok && (strings.HasPrefix(s, "hash-") || strings.HasPrefix(s, "secret-")) {
+						t.Fatalf("secret value leaked in change %+v", change)
+					}
+				}
+				if strings.Contains(strings.ToLower(change.Field), "hash") || strings.Contains(strings.ToLower(change.Field), "secret") {
+					t.Fatalf("secret field in changes: %s", change.Field)
+				}
+			}
+
+			if contains(fields, "updatedAt") {
+				t.Fatalf("fields = %v, updatedAt should be ignored", fields)
+			}
+			if tt.subset {
+				for _, want := range tt.fields {
+					if !contains(fields, want) {
+						t.Fatalf("fields = %v, want %s", fields, want)
+					}
+				}
+				return
+			}
+			if tt.fields == nil {
+				tt.fields = []string{}
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Fatalf("fields = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"context"
+	"draft-notification/logging"
+	"draft-notification/models"
+	"draft-notification/repositories"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Recorder ghi audit event cho các thay đổi dữ liệu
+type Recorder struct {
+	events repositories.AuditEventRepo
+}
+
+func NewRecorder(events repositories.AuditEventRepo) *Recorder {
+	return &Recorder{events: events}
+}
+
+// Record ghi event với diff giữa before và after. Thay đổi đã được lưu trước đó nên
+// lỗi ghi audit chỉ được log lại, không làm request thất bại.
+func (r *Recorder) Record(ctx context.Context, event models.AuditEvent, before, after interface{}) models.AuditEvent {
+	event.Id = primitive.NewObjectID()
+	event.Changes = Diff(before, after)
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	if err := r.events.Create(ctx, event); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "Ghi audit event lỗi",
+			"entity_type", event.EntityType, "entity_id", event.EntityId.Hex(), "action", event.Action, "error", err)
+	}
+	return event
+}
+
+// RecordCascade ghi event cho thay đổi dây chuyền do parent gây ra, dùng chung actor và request
+func (r *Recorder) RecordCascade(ctx context.Context, parent models.AuditEvent, action, entityType string, entityId primitive.ObjectID, before, after interface{}) models.AuditEvent {
+	event := parent
+	event.Action = action
+	event.EntityType = entityType
+	event.EntityId = entityId
+	event.ParentId = parent.Id
+	return r.Record(ctx, event, before, after)
+}
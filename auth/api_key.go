@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"draft-notification/helpers"
+	"draft-notification/models"
+	"draft-notification/repositories"
+	"errors"
+	"net"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidApiKey = errors.New("invalid api key")
+	ErrIpNotAllowed  = errors.New("client ip is not allowed for this api key")
+)
+
+// ApiKeyIdentity là chủ sở hữu API key của request cùng các scope được phép
+type ApiKeyIdentity struct {
+	// KeyId rỗng khi request dùng key chính của connection
+	KeyId primitive.ObjectID
+	// Connection nil khi key thuộc về webview server, lúc đó request phải chỉ định connection
+	Connection      *models.Connection
+	WebviewServerId primitive.ObjectID
+	Side            string
+	Scopes          []string
+}
+
+func (i ApiKeyIdentity) HasScope(scope string) bool {
+	for _, s := range i.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ApiKeyAuthenticator xác thực key chính của connection và các scoped API key
+type ApiKeyAuthenticator struct {
+	connections    repositories.ConnectionRepo
+	webviewServers repositories.WebviewServerRepo
+	apiKeys        repositories.ApiKeyRepo
+}
+
+func NewApiKeyAuthenticator(repos repositories.Repositories) *ApiKeyAuthenticator {
+	return &ApiKeyAuthenticator{
+		connections:    repos.Connections,
+		webviewServers: repos.WebviewServers,
+		apiKeys:        repos.ApiKeys,
+	}
+}
+
+// Authenticate tra cứu key theo prefix rồi so sánh hash trong thời gian hằng số.
+// Key chính của connection (kể cả key cũ còn trong thời gian overlap) có tất cả scope,
+// scoped key còn phải chưa hết hạn, chưa bị thu hồi và khớp IP allowlist.
+func (a *ApiKeyAuthenticator) Authenticate(ctx context.Context, apiKey string, clientIP string) (ApiKeyIdentity, error) {
+	if len(apiKey) <= helpers.APIKeyPrefixLength {
+		return ApiKeyIdentity{}, ErrInvalidApiKey
+	}
+
+	now := time.Now().UTC()
+	prefix := helpers.APIKeyPrefix(apiKey)
+
+	connections, err := a.connections.FindByApiKeyPrefix(ctx, prefix, now)
+	if err != nil {
+		return ApiKeyIdentity{}, err
+	}
+	for _, connection := range connections {
+		side := apiKeySide(connection, apiKey, now)
+		if side == "" {
+			continue
+		}
+		if connection.Status != "active" {
+			return ApiKeyIdentity{}, ErrInvalidApiKey
+		}
+		return ApiKeyIdentity{
+			Connection:      &connection,
+			WebviewServerId: connection.WebviewServerId,
+			Side:            side,
+			Scopes:          AllScopes,
+		}, nil
+	}
+
+	keys, err := a.apiKeys.FindByPrefix(ctx, prefix)
+	if err != nil {
+		return ApiKeyIdentity{}, err
+	}
+	for _, key := range keys {
+		if !helpers.APIKeyMatches(apiKey, key.Hash) {
+			continue
+		}
+		if !key.Active(now) {
+			return ApiKeyIdentity{}, ErrInvalidApiKey
+		}
+		if !IPAllowed(key.AllowedIps, clientIP) {
+			return ApiKeyIdentity{}, ErrIpNotAllowed
+		}
+		return a.scopedIdentity(ctx, key)
+	}
+
+	return ApiKeyIdentity{}, ErrInvalidApiKey
+}
+
+// scopedIdentity kiểm tra chủ sở hữu của scoped key vẫn đang active
+func (a *ApiKeyAuthenticator) scopedIdentity(ctx context.Context, key models.ApiKey) (ApiKeyIdentity, error) {
+	identity := ApiKeyIdentity{KeyId: key.Id, Side: key.Side, Scopes: key.Scopes}
+
+	switch key.OwnerType {
+	case models.ApiKeyOwnerConnection:
+		connection, err := a.connections.FindById(ctx, key.OwnerId)
+		if errors.Is(err, repositories.ErrNotFound) || (err == nil && connection.Status != "active") {
+			return ApiKeyIdentity{}, ErrInvalidApiKey
+		}
+		if err != nil {
+			return ApiKeyIdentity{}, err
+		}
+		identity.Connection = &connection
+		identity.WebviewServerId = connection.WebviewServerId
+	case models.ApiKeyOwnerWebviewServer:
+		server, err := a.webviewServers.FindById(ctx, key.OwnerId)
+		if errors.Is(err, repositories.ErrNotFound) || (err == nil && server.Status != "active") {
+			return ApiKeyIdentity{}, ErrInvalidApiKey
+		}
+		if err != nil {
+			return ApiKeyIdentity{}, err
+		}
+		identity.WebviewServerId = server.Id
+	default:
+		return ApiKeyIdentity{}, ErrInvalidApiKey
+	}
+
+	return identity, nil
+}
+
+// apiKeySide trả về phía sở hữu apiKey, rỗng nếu key không khớp hoặc đã hết hạn tại now
+func apiKeySide(connection models.Connection, apiKey string, now time.Time) string {
+	matches := func(hash string, rotation *models.ApiKeyRotation) bool {
+		return helpers.APIKeyMatches(apiKey, hash) ||
+			(rotation.PreviousKeyActive(now) && helpers.APIKeyMatches(apiKey, rotation.PreviousKeyHash))
+	}
+
+	switch {
+	case matches(connection.WebviewServerApiKeyHash, connection.WebviewServerApiKeyRotation):
+		return models.ApiKeySideWebviewServer
+	case matches(connection.UserDeliveryServerApiKeyHash, connection.UserDeliveryServerApiKeyRotation):
+		return models.ApiKeySideUserDeliveryServer
+	}
+	return ""
+}
+
+// IPAllowed cho biết clientIP có nằm trong allowlist (IP hoặc CIDR) không, allowlist rỗng thì cho phép tất cả
+func IPAllowed(allowlist []string, clientIP string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+
+	for _, entry := range allowlist {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidIPAllowlistEntry chấp nhận một địa chỉ IP hoặc một dải CIDR
+func ValidIPAllowlistEntry(entry string) bool {
+	if _, _, err := net.ParseCIDR(entry); err == nil {
+		return true
+	}
+	return net.ParseIP(entry) != nil
+}
+
+var (
+	ErrConnectionRequired   = errors.New("connection id is required for webview server keys")
+	ErrConnectionNotAllowed = errors.New("api key cannot send through this connection")
+)
+
+// SendingConnection trả về connection mà identity gửi notification qua.
+// Chỉ phía webview server được gửi; key của webview server phải chỉ định connectionId.
+func (a *ApiKeyAuthenticator) SendingConnection(ctx context.Context, identity ApiKeyIdentity, connectionId primitive.ObjectID) (models.Connection, error) {
+	if identity.Side != models.ApiKeySideWebviewServer {
+		return models.Connection{}, ErrConnectionNotAllowed
+	}
+
+	if identity.Connection != nil {
+		if !connectionId.IsZero() && connectionId != identity.Connection.Id {
+			return models.Connection{}, ErrConnectionNotAllowed
+		}
+		return *identity.Connection, nil
+	}
+
+	if connectionId.IsZero() {
+		return models.Connection{}, ErrConnectionRequired
+	}
+
+	connection, err := a.connections.FindById(ctx, connectionId)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return models.Connection{}, ErrConnectionNotAllowed
+	}
+	if err != nil {
+		return models.Connection{}, err
+	}
+	if connection.WebviewServerId != identity.WebviewServerId || connection.Status != "active" {
+		return models.Connection{}, ErrConnectionNotAllowed
+	}
+	return connection, nil
+}
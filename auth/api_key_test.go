@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"draft-notification/helpers"
+	"draft-notification/models"
+	"draft-notification/repositories"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newKey(t *testing.T) (key, prefix, hash string) {
+	t.Helper()
+	key, prefix, hash, err := helpers.NewAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, prefix, hash
+}
+
+func TestApiKeySideRotationOverlap(t *testing.T) {
+	rotatedAt := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC)
+	expiresAt := rotatedAt.Add(time.Hour)
+
+	webviewKey, webviewPrefix, webviewHash := newKey(t)
+	previousWebviewKey, previousWebviewPrefix, previousWebviewHash := newKey(t)
+	userDeliveryKey, userDeliveryPrefix, userDeliveryHash := newKey(t)
+	connection := models.Connection{
+		Id:                             primitive.NewObjectID(),
+		WebviewServerApiKeyPrefix:      webviewPrefix,
+		WebviewServerApiKeyHash:        webviewHash,
+		UserDeliveryServerApiKeyPrefix: userDeliveryPrefix,
+		UserDeliveryServerApiKeyHash:   userDeliveryHash,
+		WebviewServerApiKeyRotation: &models.ApiKeyRotation{
+			PreviousKeyPrefix:    previousWebviewPrefix,
+			PreviousKeyHash:      previousWebviewHash,
+			PreviousKeyExpiresAt: expiresAt,
+			RotatedAt:            rotatedAt,
+		},
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		now  time.Time
+		side string
+	}{
+		{"new key right after rotation", webviewKey, rotatedAt, models.ApiKeySideWebviewServer},
+		{"new key after overlap", webviewKey, expiresAt.Add(time.Hour), models.ApiKeySideWebviewServer},
+		{"previous key right after rotation", previousWebviewKey, rotatedAt, models.ApiKeySideWebviewServer},
+		{"previous key just before expiry", previousWebviewKey, expiresAt.Add(-time.Millisecond), models.ApiKeySideWebviewServer},
+		{"previous key at expiry", previousWebviewKey, expiresAt, ""},
+		{"previous key after expiry", previousWebviewKey, expiresAt.Add(time.Minute), ""},
+		{"other side not rotated", userDeliveryKey, rotatedAt, models.ApiKeySideUserDeliveryServer},
+		{"unknown key", "0123456789abcdef0123", rotatedAt, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if side := apiKeySide(connection, tt.key, tt.now); side != tt.side {
+				t.Fatalf("apiKeySide = %q, want %q", side, tt.side)
+			}
+		})
+	}
+}
+
+func TestApiKeyAuthenticator(t *testing.T) {
+	ctx := context.Background()
+	repos := repositories.NewMemory()
+	now := time.Now().UTC()
+
+	activeServer := models.WebviewServer{Id: primitive.NewObjectID(), Name: "active", Status: "active", CreatedAt: now}
+	inactiveServer := models.WebviewServer{Id: primitive.NewObjectID(), Name: "inactive", Status: "inactive", CreatedAt: now}
+	for _, server := range []models.WebviewServer{activeServer, inactiveServer} {
+		if err := repos.WebviewServers.Create(ctx, server); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Connection vừa rotate cả hai key: key cũ phía webview server còn trong overlap,
+	// key cũ phía user delivery server đã hết overlap
+	webviewKey, webviewPrefix, webviewHash := newKey(t)
+	previousWebviewKey, previousWebviewPrefix, previousWebviewHash := newKey(t)
+	userDeliveryKey, userDeliveryPrefix, userDeliveryHash := newKey(t)
+	expiredUserDeliveryKey, expiredUserDeliveryPrefix, expiredUserDeliveryHash := newKey(t)
+	connection := models.Connection{
+		Id:                             primitive.NewObjectID(),
+		Status:                         "active",
+		WebviewServerId:                activeServer.Id,
+		UserDeliveryServerId:           primitive.NewObjectID(),
+		WebviewServerApiKeyPrefix:      webviewPrefix,
+		WebviewServerApiKeyHash:        webviewHash,
+		UserDeliveryServerApiKeyPrefix: userDeliveryPrefix,
+		UserDeliveryServerApiKeyHash:   userDeliveryHash,
+		WebviewServerApiKeyRotation: &models.ApiKeyRotation{
+			PreviousKeyPrefix:    previousWebviewPrefix,
+			PreviousKeyHash:      previousWebviewHash,
+			PreviousKeyExpiresAt: now.Add(time.Hour),
+		},
+		UserDeliveryServerApiKeyRotation: &models.ApiKeyRotation{
+			PreviousKeyPrefix:    expiredUserDeliveryPrefix,
+			PreviousKeyHash:      expiredUserDeliveryHash,
+			PreviousKeyExpiresAt: now.Add(-time.Minute),
+		},
+		CreatedAt: now,
+	}
+	inactiveKey, inactivePrefix, inactiveHash := newKey(t)
+	inactiveConnection := models.Connection{
+		Id:                        primitive.NewObjectID(),
+		Status:                    "inactive",
+		WebviewServerId:           inactiveServer.Id,
+		UserDeliveryServerId:      primitive.NewObjectID(),
+		WebviewServerApiKeyPrefix: inactivePrefix,
+		WebviewServerApiKeyHash:   inactiveHash,
+		CreatedAt:                 now,
+	}
+	for _, c := range []models.Connection{connection, inactiveConnection} {
+		if err := repos.Connections.Create(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scopedKey := func(ownerType string, ownerId primitive.ObjectID, modify func(key *models.ApiKey)) string {
+		key, prefix, hash := newKey(t)
+		apiKey := models.ApiKey{
+			Id:        primitive.NewObjectID(),
+			OwnerType: ownerType,
+			OwnerId:   ownerId,
+			Side:      models.ApiKeySideWebviewServer,
+			Scopes:    []string{ScopeNotificationsSend},
+			Prefix:    prefix,
+			Hash:      hash,
+			CreatedAt: now,
+		}
+		if modify != nil {
+			modify(&apiKey)
+		}
+		if err := repos.ApiKeys.Create(ctx, apiKey); err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	serverKey := scopedKey(models.ApiKeyOwnerWebviewServer, activeServer.Id, nil)
+	connectionKey := scopedKey(models.ApiKeyOwnerConnection, connection.Id, nil)
+	revokedKey := scopedKey(models.ApiKeyOwnerWebviewServer, activeServer.Id, func(key *models.ApiKey) { key.RevokedAt = now })
+	expiredKey := scopedKey(models.ApiKeyOwnerWebviewServer, activeServer.Id, func(key *models.ApiKey) { key.ExpiresAt = now.Add(-time.Second) })
+	ipKey := scopedKey(models.ApiKeyOwnerWebviewServer, activeServer.Id, func(key *models.ApiKey) { key.AllowedIps = []string{"10.0.0.0/8"} })
+	inactiveOwnerKey := scopedKey(models.ApiKeyOwnerWebviewServer, inactiveServer.Id, nil)
+	inactiveConnectionOwnerKey := scopedKey(models.ApiKeyOwnerConnection, inactiveConnection.Id, nil)
+	missingOwnerKey := scopedKey(models.ApiKeyOwnerConnection, primitive.NewObjectID(), nil)
+
+	tests := []struct {
+		name         string
+		key          string
+		ip           string
+		err          error
+		side         string
+		scoped       bool
+		connectionId primitive.ObjectID
+	}{
+		{name: "webview server key", key: webviewKey, side: models.ApiKeySideWebviewServer, connectionId: connection.Id},
+		{name: "user delivery server key", key: userDeliveryKey, side: models.ApiKeySideUserDeliveryServer, connectionId: connection.Id},
+		{name: "previous key within overlap", key: previousWebviewKey, side: models.ApiKeySideWebviewServer, connectionId: connection.Id},
+		{name: "previous key after overlap", key: expiredUserDeliveryKey, err: ErrInvalidApiKey},
+		{name: "inactive connection", key: inactiveKey, err: ErrInvalidApiKey},
+		{name: "unknown key", key: "0123456789abcdef", err: ErrInvalidApiKey},
+		{name: "key with matching prefix", key: webviewPrefix + "0000", err: ErrInvalidApiKey},
+		{name: "too short", key: "abc", err: ErrInvalidApiKey},
+		{name: "empty", key: "", err: ErrInvalidApiKey},
+		{name: "scoped webview server key", key: serverKey, side: models.ApiKeySideWebviewServer, scoped: true},
+		{name: "scoped connection key", key: connectionKey, side: models.ApiKeySideWebviewServer, scoped: true, connectionId: connection.Id},
+		{name: "revoked", key: revokedKey, err: ErrInvalidApiKey},
+		{name: "expired", key: expiredKey, err: ErrInvalidApiKey},
+		{name: "ip allowed", key: ipKey, ip: "10.1.2.3", side: models.ApiKeySideWebviewServer, scoped: true},
+		{name: "ip not allowed", key: ipKey, ip: "192.168.1.1", err: ErrIpNotAllowed},
+		{name: "inactive webview server owner", key: inactiveOwnerKey, err: ErrInvalidApiKey},
+		{name: "inactive connection owner", key: inactiveConnectionOwnerKey, err: ErrInvalidApiKey},
+		{name: "deleted owner", key: missingOwnerKey, err: ErrInvalidApiKey},
+	}
+
+	authenticator := NewApiKeyAuthenticator(repos)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := authenticator.Authenticate(ctx, tt.key, tt.ip)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Authenticate = %+v, %v, want %v", identity, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if identity.Side != tt.side || identity.KeyId.IsZero() != !tt.scoped {
+				t.Fatalf("identity = %+v, want side %s scoped %v", identity, tt.side, tt.scoped)
+			}
+			if identity.WebviewServerId != activeServer.Id {
+				t.Fatalf("webview server = %s, want %s", identity.WebviewServerId.Hex(), activeServer.Id.Hex())
+			}
+			var connectionId primitive.ObjectID
+			if identity.Connection != nil {
+				connectionId = identity.Connection.Id
+			}
+			if connectionId != tt.connectionId {
+				t.Fatalf("connection = %s, want %s", connectionId.Hex(), tt.connectionId.Hex())
+			}
+			if tt.scoped && (identity.HasScope(ScopeStatsRead) || !identity.HasScope(ScopeNotificationsSend)) {
+				t.Fatalf("scopes = %v", identity.Scopes)
+			}
+			if !tt.scoped && len(identity.Scopes) != len(AllScopes) {
+				t.Fatalf("connection key scopes = %v, want all", identity.Scopes)
+			}
+		})
+	}
+}
+
+func TestIPAllowed(t *testing.T) {
+	tests := []struct {
+		allowlist []string
+		ip        string
+		allowed   bool
+	}{
+		{nil, "203.0.113.1", true},
+		{nil, "", true},
+		{[]string{"203.0.113.1"}, "203.0.113.1", true},
+		{[]string{"203.0.113.1"}, "203.0.113.2", false},
+		{[]string{"203.0.113.0/24"}, "203.0.113.200", true},
+		{[]string{"203.0.113.0/24"}, "203.0.114.1", false},
+		{[]string{"2001:db8::/32"}, "2001:db8::1", true},
+		{[]string{"203.0.113.1"}, "::ffff:203.0.113.1", true},
+		{[]string{"203.0.113.0/24"}, "", false},
+		{[]string{"203.0.113.0/24"}, "not-an-ip", false},
+	}
+
+	for _, tt := range tests {
+		if got := IPAllowed(tt.allowlist, tt.ip); got != tt.allowed {
+			t.Errorf("IPAllowed(%v, %q) = %v, want %v", tt.allowlist, tt.ip, got, tt.allowed)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package auth
+
+const (
+	RoleOwner    = "owner"
+	RoleOperator = "operator"
+	RoleViewer   = "viewer"
+)
+
+const (
+	PermServersRead       = "servers:read"
+	PermServersWrite      = "servers:write"
+	PermServersStatus     = "servers:status"
+	PermConnectionsRead   = "connections:read"
+	PermConnectionsWrite  = "connections:write"
+	PermConnectionsStatus = "connections:status"
+	PermKeysRotate        = "keys:rotate"
+	PermKeysManage        = "keys:manage"
+	PermAdminsManage      = "admins:manage"
+	PermConsentsManage    = "consents:manage"
+	PermAuditRead         = "audit:read"
+)
+
+// Viewer chỉ đọc, operator được tạo/sửa và đổi status, owner có toàn quyền
+var rolePermissions = map[string]map[string]bool{
+	RoleViewer: {
+		PermServersRead:     true,
+		PermConnectionsRead: true,
+	},
+	RoleOperator: {
+		PermServersRead:       true,
+		PermServersWrite:      true,
+		PermServersStatus:     true,
+		PermConnectionsRead:   true,
+		PermConnectionsWrite:  true,
+		PermConnectionsStatus: true,
+	},
+	RoleOwner: {
+		PermServersRead:       true,
+		PermServersWrite:      true,
+		PermServersStatus:     true,
+		PermConnectionsRead:   true,
+		PermConnectionsWrite:  true,
+		PermConnectionsStatus: true,
+		PermKeysRotate:        true,
+		PermKeysManage:        true,
+		PermAdminsManage:      true,
+		PermConsentsManage:    true,
+		PermAuditRead:         true,
+	},
+}
+
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func HasPermission(role, permission string) bool {
+	return rolePermissions[role][permission]
+}
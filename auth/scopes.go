@@ -0,0 +1,25 @@
+package auth
+
+// Scope của API key, key chính của connection có tất cả scope
+const (
+	ScopeNotificationsSend = "notifications:send"
+	ScopeNotificationsRead = "notifications:read"
+	ScopeTemplatesWrite    = "templates:write"
+	ScopeStatsRead         = "stats:read"
+)
+
+var AllScopes = []string{
+	ScopeNotificationsSend,
+	ScopeNotificationsRead,
+	ScopeTemplatesWrite,
+	ScopeStatsRead,
+}
+
+func ValidScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
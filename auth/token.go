@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"draft-notification/helpers"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims của access token, SessionId dùng để kiểm tra session chưa bị thu hồi,
+// OrganizationId giới hạn dữ liệu admin được thấy
+type Claims struct {
+	AdminId        primitive.ObjectID `json:"-"`
+	SessionId      primitive.ObjectID `json:"-"`
+	OrganizationId primitive.ObjectID `json:"org"`
+	Username       string             `json:"username"`
+	Role           string             `json:"role"`
+	jwt.RegisteredClaims
+}
+
+type TokenIssuer struct {
+	secret          []byte
+	issuer          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+func NewTokenIssuer(secret, issuer string, accessTokenTTL, refreshTokenTTL time.Duration) *TokenIssuer {
+	return &TokenIssuer{
+		secret:          []byte(secret),
+		issuer:          issuer,
+		AccessTokenTTL:  accessTokenTTL,
+		RefreshTokenTTL: refreshTokenTTL,
+	}
+}
+
+// IssueAccessToken ký một access token HS256 ngắn hạn cho session
+func (t *TokenIssuer) IssueAccessToken(adminId, organizationId primitive.ObjectID, username, role string, sessionId primitive.ObjectID) (string, error) {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Subject:   adminId.Hex(),
+		ID:        sessionId.Hex(),
+		Issuer:    t.issuer,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(t.AccessTokenTTL)),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{OrganizationId: organizationId, Username: username, Role: role, RegisteredClaims: claims})
+	return token.SignedString(t.secret)
+}
+
+// ParseAccessToken kiểm tra chữ ký, issuer và thời hạn của access token
+func (t *TokenIssuer) ParseAccessToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return t.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(t.issuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.AdminId, err = primitive.ObjectIDFromHex(claims.Subject); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.SessionId, err = primitive.ObjectIDFromHex(claims.ID); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.OrganizationId.IsZero() {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// NewRefreshToken tạo refresh token dạng "<sessionId>.<secret>" cùng hash để lưu vào DB
+func NewRefreshToken(sessionId primitive.ObjectID) (token string, hash string, err error) {
+	secret, err := helpers.GenerateAPIKey(32)
+	if err != nil {
+		return "", "", err
+	}
+	token = sessionId.Hex() + "." + secret
+	return token, HashToken(token), nil
+}
+
+// ParseRefreshToken lấy sessionId từ refresh token
+func ParseRefreshToken(token string) (primitive.ObjectID, error) {
+	sessionHex, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+	sessionId, err := primitive.ObjectIDFromHex(sessionHex)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+	return sessionId, nil
+}
+
+// HashToken trả về SHA-256 (hex) của token, chỉ hash được lưu trong DB
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// TokenMatches so sánh token với hash đã lưu trong thời gian hằng số
+func TokenMatches(token, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(hash)) == 1
+}
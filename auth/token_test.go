@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func TestParseAccessToken(t *testing.T) {
+	issuer := NewTokenIssuer(testSecret, "draft-notification", time.Minute, time.Hour)
+	adminId, organizationId, sessionId := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	valid, err := issuer.IssueAccessToken(adminId, organizationId, "owner1", RoleOwner, sessionId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := issuer.ParseAccessToken(valid)
+	if err != nil {
+		t.Fatalf("ParseAccessToken: %v", err)
+	}
+	if claims.AdminId != adminId || claims.SessionId != sessionId || claims.OrganizationId != organizationId ||
+		claims.Username != "owner1" || claims.Role != RoleOwner {
+		t.Fatalf("claims = %+v", claims)
+	}
+
+	// sign ký claims tuỳ ý để tạo các token sai
+	sign := func(method jwt.SigningMethod, key interface{}, modify func(claims *Claims)) string {
+		now := time.Now()
+		claims := Claims{
+			OrganizationId: organizationId,
+			Role:           RoleOwner,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   adminId.Hex(),
+				ID:        sessionId.Hex(),
+				Issuer:    "draft-notification",
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			},
+		}
+		if modify != nil {
+			modify(&claims)
+		}
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	secret := []byte(testSecret)
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"issued token", valid, true},
+		{"same claims signed again", sign(jwt.SigningMethodHS256, secret, nil), true},
+		{"other secret", sign(jwt.SigningMethodHS256, []byte("another-secret-another-secret-00"), nil), false},
+		{"other algorithm", sign(jwt.SigningMethodHS512, secret, nil), false},
+		{"none algorithm", sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, nil), false},
+		{"other issuer", sign(jwt.SigningMethodHS256, secret, func(c *Claims) { c.Issuer = "other" }), false},
+		{"expired", sign(jwt.SigningMethodHS256, secret, func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Second)) }), false},
+		{"no expiry", sign(jwt.SigningMethodHS256, secret, func(c *Claims) { c.ExpiresAt = nil }), false},
+		{"invalid subject", sign(jwt.SigningMethodHS256, secret, func(c *Claims) { c.Subject = "admin" }), false},
+		{"invalid session", sign(jwt.SigningMethodHS256, secret, func(c *Claims) { c.ID = "" }), false},
+		{"no organization", sign(jwt.SigningMethodHS256, secret, func(c *Claims) { c.OrganizationId = primitive.NilObjectID }), false},
+		{"tampered", valid[:len(valid)-2] + "xx", false},
+		{"garbage", "not.a.token", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := issuer.ParseAccessToken(tt.token)
+			if tt.valid && err != nil {
+				t.Fatalf("ParseAccessToken = %v, want valid", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("ParseAccessToken = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	sessionId := primitive.NewObjectID()
+	token, hash, err := NewRefreshToken(sessionId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, err := ParseRefreshToken(token); err != nil || parsed != sessionId {
+		t.Fatalf("ParseRefreshToken = %s, %v", parsed.Hex(), err)
+	}
+	if !TokenMatches(token, hash) || TokenMatches(token+"x", hash) {
+		t.Fatal("TokenMatches does not match only the issued token")
+	}
+	for _, invalid := range []string{"", "no-dot", "zz.secret"} {
+		if _, err := ParseRefreshToken(invalid); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("ParseRefreshToken(%q) = %v", invalid, err)
+		}
+	}
+}
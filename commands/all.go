@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"context"
+	"draft-notification/configs"
+	"draft-notification/repositories"
+	"fmt"
+	"sync"
+)
+
+// runAll chạy tất cả các role trong cùng một process với chung một storage.
+// Khi một role lỗi hoặc nhận signal, các role còn lại được dừng có kiểm soát.
+func runAll(ctx context.Context, cfg configs.Config, args []string) error {
+	repos, err := openServingRepositories(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeRepositories(repos)
+
+	roles := map[string]func(context.Context, configs.Config, repositories.Repositories) error{
+		"serve-http": serveHTTP,
+		"serve-grpc": serveGRPC,
+		"worker":     runWorkers,
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(roles))
+	for name, run := range roles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := run(ctx, cfg, repos); err != nil {
+				errCh <- fmt.Errorf("%s: %w", name, err)
+			}
+			cancel()
+		}()
+	}
+
+	wg.Wait()
+	close(errCh)
+
+	return <-errCh
+}
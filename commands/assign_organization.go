@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"context"
+	"draft-notification/configs"
+	"errors"
+	"log/slog"
+	"strings"
+)
+
+// runAssignOrganization gán server, connection và admin tạo trước khi có organization
+// cho organization chỉ định (tạo mới nếu chưa có)
+func runAssignOrganization(ctx context.Context, cfg configs.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: assign-organization [flags] <organization>")
+	}
+
+	repos, err := openRepositories(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeRepositories(repos)
+
+	organization, err := findOrCreateOrganization(ctx, repos, strings.TrimSpace(args[0]))
+	if err != nil {
+		return err
+	}
+
+	assigned, err := repos.AssignOrganization(ctx, organization.Id)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Assigned documents to organization", "documents", assigned, "organization", organization.Name)
+	return nil
+}
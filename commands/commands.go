@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"context"
+	"draft-notification/configs"
+	"draft-notification/helpers"
+	"draft-notification/i18n"
+	"draft-notification/logging"
+	"draft-notification/tracing"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, cfg configs.Config, args []string) error
+}
+
+var commands = map[string]command{
+	"serve-http":          {usage: "run the HTTP admin API", run: runServeHTTP},
+	"serve-grpc":          {usage: "run the gRPC server", run: runServeGRPC},
+	"worker":              {usage: "run the queue workers", run: runWorker},
+	"all":                 {usage: "run HTTP API, gRPC server and workers in one process", run: runAll},
+	"migrate":             {usage: "apply pending database migrations (collections, indexes)", run: runMigrate},
+	"migrate down":        {usage: "revert migrations newer than a version: migrate down <version>", run: runMigrateDown},
+	"migrate status":      {usage: "list database migrations and when they were applied", run: runMigrateStatus},
+	"create-admin":        {usage: "create an admin account: create-admin <organization> <username> [role] (password from $" + adminPasswordEnv + " or stdin)", run: runCreateAdmin},
+	"assign-organization": {usage: "assign data created before organizations existed: assign-organization <organization>", run: runAssignOrganization},
+	"config print":        {usage: "print the effective config with secrets redacted", run: runConfigPrint},
+	"keyring generate":    {usage: "add a new primary encryption key: keyring generate [file] (default encryption.keyringFile)", run: runKeyringGenerate},
+	"reencrypt":           {usage: "re-encrypt stored secrets and notification content with the primary key", run: runReEncrypt},
+}
+
+// Execute parse subcommand từ args (không bao gồm tên binary) và chạy nó
+func Execute(args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(os.Stdout)
+		return nil
+	}
+
+	name, cfg, rest, err := parseArgs(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if errors.Is(err, errUnknownCommand) {
+		printUsage(os.Stderr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := logging.Setup(os.Stderr, cfg.Log.Level, cfg.Log.Format); err != nil {
+		return err
+	}
+	helpers.RequestTimeout = cfg.RequestTimeout.Duration
+	helpers.MaxPageSize = cfg.MaxPageSize
+	i18n.SetDefault(cfg.I18n.DefaultLanguage)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:     cfg.Tracing.Exporter,
+		ServiceName:  cfg.Tracing.ServiceName,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+		OTLPInsecure: cfg.Tracing.OTLPInsecure,
+		SampleRatio:  cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+	// Gửi nốt các span còn trong buffer trước khi thoát
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Flush trace lỗi", "error", err)
+		}
+	}()
+
+	// SIGINT/SIGTERM huỷ ctx, các role ngừng nhận việc mới và drain trong shutdownTimeout
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return commands[name].run(ctx, cfg, rest)
+}
+
+var errUnknownCommand = errors.New("unknown command")
+
+// parseArgs tách subcommand (gồm cả dạng hai từ như "migrate down") khỏi args, parse flag và
+// nạp config đã áp dụng flag. rest là các tham số còn lại của subcommand.
+func parseArgs(args []string) (name string, cfg configs.Config, rest []string, err error) {
+	name, rest = args[0], args[1:]
+	if len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		if _, ok := commands[name+" "+rest[0]]; ok {
+			name, rest = name+" "+rest[0], rest[1:]
+		}
+	}
+
+	if _, ok := commands[name]; !ok {
+		return name, cfg, nil, fmt.Errorf("%w %q", errUnknownCommand, name)
+	}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to the YAML config file (default $"+configs.ConfigFileEnv+" or "+configs.DefaultConfigFile+")")
+	httpAddr := fs.String("http-addr", "", "listen address of the HTTP API (overrides config)")
+	grpcAddr := fs.String("grpc-addr", "", "listen address of the gRPC server (overrides config)")
+	if err := fs.Parse(rest); err != nil {
+		return name, cfg, nil, err
+	}
+
+	cfg, err = configs.Load(*configPath)
+	if err != nil {
+		return name, cfg, nil, err
+	}
+
+	// Flag có độ ưu tiên cao nhất, chỉ áp dụng khi được truyền vào
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "http-addr":
+			cfg.HTTP.Addr = *httpAddr
+		case "grpc-addr":
+			cfg.GRPC.Addr = *grpcAddr
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return name, cfg, nil, fmt.Errorf("invalid config:\n%w", err)
+	}
+	return name, cfg, fs.Args(), nil
+}
+
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: draft-notification <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-20s %s\n", name, commands[name].usage)
+	}
+}
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"context"
+	"draft-notification/configs"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// isolateConfig trỏ config về một file rỗng để test không đọc config.yaml hay biến môi trường của máy
+func isolateConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(configs.ConfigFileEnv, path)
+	for _, name := range []string{"HTTP_ADDR", "GRPC_ADDR", "STORAGE_DRIVER"} {
+		t.Setenv(configs.EnvPrefix+name, "")
+		os.Unsetenv(configs.EnvPrefix + name)
+	}
+	return path
+}
+
+func TestParseArgsDispatch(t *testing.T) {
+	isolateConfig(t, "")
+	tests := []struct {
+		args     []string
+		wantName string
+		wantRun  func(ctx context.Context, cfg configs.Config, args []string) error
+		wantRest []string
+	}{
+		{[]string{"serve-http"}, "serve-http", runServeHTTP, []string{}},
+		{[]string{"serve-grpc"}, "serve-grpc", runServeGRPC, []string{}},
+		{[]string{"worker"}, "worker", runWorker, []string{}},
+		{[]string{"all"}, "all", runAll, []string{}},
+		{[]string{"migrate"}, "migrate", runMigrate, []string{}},
+		{[]string{"migrate", "down", "3"}, "migrate down", runMigrateDown, []string{"3"}},
+		{[]string{"migrate", "status"}, "migrate status", runMigrateStatus, []string{}},
+		{[]string{"create-admin", "acme", "alice", "operator"}, "create-admin", runCreateAdmin, []string{"acme", "alice", "operator"}},
+		{[]string{"config", "print"}, "config print", runConfigPrint, []string{}},
+		{[]string{"keyring", "generate", "keys.json"}, "keyring generate", runKeyringGenerate, []string{"keys.json"}},
+		{[]string{"reencrypt"}, "reencrypt", runReEncrypt, []string{}},
+		// Flag đứng trước tham số của subcommand
+		{[]string{"migrate", "down", "--grpc-addr", ":9000", "2"}, "migrate down", runMigrateDown, []string{"2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			name, _, rest, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs: %v", err)
+			}
+			if name != tt.wantName || !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Fatalf("parseArgs = %q %q, want %q %q", name, rest, tt.wantName, tt.wantRest)
+			}
+			if reflect.ValueOf(commands[name].run).Pointer() != reflect.ValueOf(tt.wantRun).Pointer() {
+				t.Fatalf("%q runs the wrong command", name)
+			}
+		})
+	}
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	isolateConfig(t, "")
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"unknown command", []string{"serve"}, `unknown command "serve"`},
+		{"missing subcommand", []string{"keyring"}, `unknown command "keyring"`},
+		{"unknown subcommand", []string{"config", "show"}, `unknown command "config"`},
+		{"unknown flag", []string{"worker", "--bogus"}, "flag provided but not defined: -bogus"},
+		{"invalid flag value", []string{"serve-grpc", "--grpc-addr", "bogus"}, "invalid config"},
+		{"missing config file", []string{"worker", "--config", "missing.yaml"}, "read config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := parseArgs(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("parseArgs = %v, want error containing %q", err, tt.wantErr)
+			}
+			if strings.HasPrefix(tt.wantErr, "unknown command") != errors.Is(err, errUnknownCommand) {
+				t.Fatalf("errors.Is(%v, errUnknownCommand) mismatch", err)
+			}
+		})
+	}
+}
+
+// Subcommand thiếu tham số bắt buộc báo cách dùng trước khi mở storage
+func TestMissingArguments(t *testing.T) {
+	isolateConfig(t, "")
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"migrate", "down"}, "usage: migrate down <version>"},
+		{[]string{"migrate", "down", "latest"}, `invalid version "latest"`},
+		{[]string{"create-admin", "acme"}, "usage: create-admin"},
+		{[]string{"keyring", "generate"}, "usage: keyring generate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			name, cfg, rest, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs: %v", err)
+			}
+			err = commands[name].run(context.Background(), cfg, rest)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("%s = %v, want error containing %q", name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseArgsFlagOverrides(t *testing.T) {
+	path := isolateConfig(t, "http:\n  addr: 127.0.0.1:1000\ngrpc:\n  addr: 127.0.0.1:2000\n")
+	t.Setenv(configs.EnvPrefix+"GRPC_ADDR", "127.0.0.1:3000")
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantHTTP string
+		wantGRPC string
+	}{
+		{"file and env", []string{"all"}, "127.0.0.1:1000", "127.0.0.1:3000"},
+		{"http flag", []string{"all", "--http-addr", "127.0.0.1:4000"}, "127.0.0.1:4000", "127.0.0.1:3000"},
+		{"grpc flag over env", []string{"all", "--grpc-addr=127.0.0.1:5000"}, "127.0.0.1:1000", "127.0.0.1:5000"},
+		{"config flag", []string{"all", "--config", path, "--http-addr", ":80"}, ":80", "127.0.0.1:3000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cfg, _, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs: %v", err)
+			}
+			if cfg.HTTP.Addr != tt.wantHTTP || cfg.GRPC.Addr != tt.wantGRPC {
+				t.Fatalf("addrs = %s %s, want %s %s", cfg.HTTP.Addr, cfg.GRPC.Addr, tt.wantHTTP, tt.wantGRPC)
+			}
+		})
+	}
+}
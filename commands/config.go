@@ -0,0 +1,17 @@
+package commands
+
+import (
+	"context"
+	"draft-notification/configs"
+	"fmt"
+)
+
+func runConfigPrint(ctx context.Context, cfg configs.Config, args []string) error {
+	out, err := cfg.Redacted().YAML()
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(out)
+	return nil
+}
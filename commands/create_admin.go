@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"draft-notification/auth"
+	"draft-notification/configs"
+	"draft-notification/models"
+	"draft-notification/repositories"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Biến môi trường chứa mật khẩu, nếu không có thì đọc dòng đầu tiên từ stdin
+const adminPasswordEnv = configs.EnvPrefix + "ADMIN_PASSWORD"
+
+// runCreateAdmin tạo tài khoản admin: create-admin <organization> <username> [role], role mặc định là owner.
+// Organization chưa tồn tại sẽ được tạo mới.
+func runCreateAdmin(ctx context.Context, cfg configs.Config, args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return errors.New("usage: create-admin [flags] <organization> <username> [owner|operator|viewer]")
+	}
+	organizationName := strings.TrimSpace(args[0])
+	username := strings.TrimSpace(args[1])
+
+	role := auth.RoleOwner
+	if len(args) == 3 {
+		role = args[2]
+	}
+	if !auth.ValidRole(role) {
+		return fmt.Errorf("invalid role %q", role)
+	}
+
+	password, ok := os.LookupEnv(adminPasswordEnv)
+	if !ok {
+		fmt.Fprint(os.Stderr, "Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("read password: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	passwordHash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	repos, err := openRepositories(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeRepositories(repos)
+
+	organization, err := findOrCreateOrganization(ctx, repos, organizationName)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	admin := models.Admin{
+		Id:             primitive.NewObjectID(),
+		OrganizationId: organization.Id,
+		Username:       username,
+		Role:           role,
+		PasswordHash:   passwordHash,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	err = repos.Admins.Create(ctx, admin)
+	if errors.Is(err, repositories.ErrDuplicate) {
+		return fmt.Errorf("admin %q already exists", username)
+	}
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Created admin", "role", role, "username", username, "admin_id", admin.Id.Hex(), "organization", organization.Name)
+	return nil
+}
+
+func findOrCreateOrganization(ctx context.Context, repos repositories.Repositories, name string) (models.Organization, error) {
+	if name == "" {
+		return models.Organization{}, errors.New("organization name is required")
+	}
+
+	organization, err := repos.Organizations.FindByName(ctx, name)
+	if err == nil || !errors.Is(err, repositories.ErrNotFound) {
+		return organization, err
+	}
+
+	now := time.Now().UTC()
+	organization = models.Organization{
+		Id:        primitive.NewObjectID(),
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := repos.Organizations.Create(ctx, organization); err != nil {
+		return models.Organization{}, err
+	}
+
+	slog.Info("Created organization", "organization", name, "organization_id", organization.Id.Hex())
+	return organization, nil
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"context"
+	"draft-notification/configs"
+	"draft-notification/encryption"
+	"errors"
+	"log/slog"
+	"os"
+)
+
+// loadKeyring bật mã hoá at rest nếu config có keyring. Thiếu keyring là lỗi khi encryption.required,
+// trừ storage memory vì dữ liệu không được ghi ra đâu cả.
+func loadKeyring(cfg configs.Config) error {
+	if cfg.Encryption.KeyringFile == "" {
+		if cfg.Encryption.Required && cfg.Storage.Driver != configs.StorageMemory {
+			return errors.New("encryption.keyringFile is required (create one with `keyring generate <file>`), set encryption.required to false to store data unencrypted")
+		}
+		slog.Warn("Chưa cấu hình keyring, webhook secret và nội dung notification mới sẽ được lưu plaintext")
+		encryption.Use(nil)
+		return nil
+	}
+
+	keyring, err := encryption.LoadKeyring(cfg.Encryption.KeyringFile)
+	if err != nil {
+		return err
+	}
+	encryption.Use(keyring)
+	return nil
+}
+
+// runKeyringGenerate thêm khoá mới làm primary vào keyring (tạo file nếu chưa có).
+// Dữ liệu cũ vẫn đọc được bằng khoá trước đó cho tới khi chạy reencrypt.
+func runKeyringGenerate(ctx context.Context, cfg configs.Config, args []string) error {
+	path := cfg.Encryption.KeyringFile
+	if len(args) == 1 {
+		path = args[0]
+	}
+	if path == "" || len(args) > 1 {
+		return errors.New("usage: keyring generate [flags] [file] (default encryption.keyringFile)")
+	}
+
+	keyring := &encryption.Keyring{}
+	if _, err := os.Stat(path); err == nil {
+		if keyring, err = encryption.LoadKeyring(path); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	id, err := keyring.GenerateKey()
+	if err != nil {
+		return err
+	}
+	if err := keyring.Save(path); err != nil {
+		return err
+	}
+
+	slog.Info("Added primary key to keyring", "key_id", id, "file", path)
+	return nil
+}
+
+// runReEncrypt mã hoá lại dữ liệu plaintext hoặc dùng khoá cũ bằng khoá primary
+func runReEncrypt(ctx context.Context, cfg configs.Config, args []string) error {
+	repos, err := openRepositories(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeRepositories(repos)
+
+	updated, err := repos.ReEncrypt(ctx)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Re-encrypted documents", "documents", updated, "key_id", encryption.PrimaryKeyId())
+	return nil
+}
@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"context"
+	"draft-notification/configs"
+	"draft-notification/health"
+	"draft-notification/repositories"
+	"fmt"
+	"time"
+)
+
+// registerReadinessChecks đăng ký các dependency dùng chung của mọi role cho /readyz
+func registerReadinessChecks(repos repositories.Repositories) {
+	health.Register("storage", repos.Ping)
+	health.Register("migrations", repos.CheckMigrated)
+}
+
+// registerQueueLagCheck chỉ dùng cho role worker: hàng đợi chậm là lỗi của worker, nếu HTTP và gRPC
+// cũng báo not-ready thì load balancer bỏ luôn API nhận notification. Độ trễ hàng đợi vẫn được
+// theo dõi qua metric notification_queue_oldest_pending_age_seconds của worker.
+func registerQueueLagCheck(cfg configs.Config, repos repositories.Repositories) {
+	health.Register("queue", func(ctx context.Context) error {
+		stats, err := repos.Jobs.Stats(ctx)
+		if err != nil {
+			return err
+		}
+		if stats.OldestRunAt.IsZero() {
+			return nil
+		}
+		if lag := time.Since(stats.OldestRunAt); lag > cfg.Health.MaxQueueLag.Duration {
+			return fmt.Errorf("oldest due job has waited %s, more than %s", lag.Round(time.Second), cfg.Health.MaxQueueLag.Duration)
+		}
+		return nil
+	})
+}
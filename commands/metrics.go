@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"context"
+	"draft-notification/configs"
+	"draft-notification/health"
+	"draft-notification/metrics"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// withMetricsServer chạy run cùng một listener /metrics, /healthz, /readyz riêng (metrics.addr)
+// cho các role không có HTTP API.
+// Listener dừng khi run kết thúc, lỗi listen làm huỷ run.
+func withMetricsServer(ctx context.Context, cfg configs.Config, run func(ctx context.Context) error) error {
+	if cfg.Metrics.Addr == "" {
+		return run(ctx)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/healthz", health.LiveHandler())
+	mux.Handle("/readyz", health.ReadyHandler(cfg.Health.CheckTimeout.Duration))
+	server := &http.Server{Addr: cfg.Metrics.Addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("Metrics đang chạy", "addr", cfg.Metrics.Addr)
+		err := server.ListenAndServe()
+		if !errors.Is(err, http.ErrServerClosed) {
+			cancel()
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	runErr := run(ctx)
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	server.Shutdown(shutdownCtx)
+
+	if err := <-serveErr; err != nil {
+		return errors.Join(runErr, err)
+	}
+	return runErr
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"context"
+	"draft-notification/configs"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// runMigrate chạy các migration chưa được áp dụng (collection, index...)
+func runMigrate(ctx context.Context, cfg configs.Config, args []string) error {
+	repos, err := openRepositories(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeRepositories(repos)
+
+	return repos.Migrate(ctx)
+}
+
+// runMigrateDown hoàn tác các migration có version lớn hơn version truyền vào
+func runMigrateDown(ctx context.Context, cfg configs.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: migrate down <version>")
+	}
+	to, err := strconv.Atoi(args[0])
+	if err != nil || to < 0 {
+		return fmt.Errorf("invalid version %q", args[0])
+	}
+
+	repos, err := openRepositories(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeRepositories(repos)
+
+	return repos.MigrateDown(ctx, to)
+}
+
+// runMigrateStatus in các migration và thời điểm chúng được áp dụng
+func runMigrateStatus(ctx context.Context, cfg configs.Config, args []string) error {
+	repos, err := openRepositories(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeRepositories(repos)
+
+	statuses, err := repos.Migrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if !status.AppliedAt.IsZero() {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"context"
+	"draft-notification/configs"
+	"draft-notification/repositories"
+	"log/slog"
+	"time"
+)
+
+// openRepositories mở storage theo config, các field nhạy cảm được mã hoá bằng keyring (nếu có)
+func openRepositories(cfg configs.Config) (repositories.Repositories, error) {
+	if err := loadKeyring(cfg); err != nil {
+		return repositories.Repositories{}, err
+	}
+	return repositories.Open(cfg)
+}
+
+// openServingRepositories mở storage cho các role phục vụ request và từ chối khởi động khi còn
+// migration chưa áp dụng, vì ràng buộc unique (tên server, cặp connection...) nằm ở các migration
+func openServingRepositories(ctx context.Context, cfg configs.Config) (repositories.Repositories, error) {
+	repos, err := openRepositories(cfg)
+	if err != nil {
+		return repos, err
+	}
+	if err := repos.CheckMigrated(ctx); err != nil {
+		closeRepositories(repos)
+		return repositories.Repositories{}, err
+	}
+	return repos, nil
+}
+
+func closeRepositories(repos repositories.Repositories) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := repos.Close(ctx); err != nil {
+		slog.Error("Đóng storage lỗi", "error", err)
+		return
+	}
+	slog.Info("Đã đóng storage")
+}
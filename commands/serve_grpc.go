@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"context"
+	"draft-notification/configs"
+	"draft-notification/grpc"
+	"draft-notification/repositories"
+)
+
+func runServeGRPC(ctx context.Context, cfg configs.Config, args []string) error {
+	repos, err := openServingRepositories(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeRepositories(repos)
+
+	return withMetricsServer(ctx, cfg, func(ctx context.Context) error {
+		return serveGRPC(ctx, cfg, repos)
+	})
+}
+
+func serveGRPC(ctx context.Context, cfg configs.Config, repos repositories.Repositories) error {
+	registerReadinessChecks(repos)
+	return grpc.Serve(ctx, cfg.GRPC.Addr, repos, cfg.ShutdownTimeout.Duration, cfg.Health.CheckTimeout.Duration)
+}
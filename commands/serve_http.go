@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"context"
+	"draft-notification/auth"
+	"draft-notification/configs"
+	"draft-notification/controllers"
+	"draft-notification/helpers"
+	"draft-notification/middlewares"
+	"draft-notification/repositories"
+	"draft-notification/routes"
+	"draft-notification/webhook"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+)
+
+func newHTTPServer(cfg configs.Config, repos repositories.Repositories) *echo.Echo {
+	tokens := newTokenIssuer(cfg)
+
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.HTTPErrorHandler = helpers.HTTPErrorHandler
+
+	// Chỉ tin X-Forwarded-For khi chạy sau reverse proxy, IP client dùng cho IP allowlist của API key
+	e.IPExtractor = echo.ExtractIPDirect()
+	if cfg.HTTP.TrustProxyHeaders {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	}
+
+	// Request ID lấy từ header X-Request-Id hoặc được sinh mới, trả lại trong response và ghi vào audit event
+	e.Use(middleware.RequestID())
+	e.Use(middlewares.Metrics())
+	e.Use(otelecho.Middleware(cfg.Tracing.ServiceName, otelecho.WithSkipper(func(c echo.Context) bool {
+		return c.Path() == "/metrics"
+	})))
+	e.Use(middlewares.RequestLog())
+	e.Use(middlewares.ValidateToken(tokens, repos.AdminSessions))
+
+	routes.MetricsRoute(e)
+	routes.HealthRoute(e, cfg.Health.CheckTimeout.Duration)
+	routes.AuthRoute(e, controllers.NewAuthController(repos, tokens))
+	routes.AdminRoute(e, controllers.NewAdminController(repos))
+	routes.OrganizationRoute(e, controllers.NewOrganizationController(repos))
+	routes.WebviewServerRoute(e, controllers.NewWebviewServerController(repos))
+	routes.ConnectionConsentRoute(e, controllers.NewConnectionConsentController(repos))
+	routes.UserDeliveryServerRoute(e, controllers.NewUserDeliveryServerController(repos))
+	routes.ConnectionRoute(e, controllers.NewConnectionController(repos, cfg.ApiKeys.RotationOverlap.Duration, webhook.NewVerifier(webhookPolicy(cfg), cfg.Webhook.Timeout.Duration)))
+	routes.ApiKeyRoute(e, controllers.NewApiKeyController(repos))
+	routes.AuditEventRoute(e, controllers.NewAuditEventController(repos))
+
+	authenticator := auth.NewApiKeyAuthenticator(repos)
+	routes.NotificationRoute(e, controllers.NewNotificationController(repos, authenticator), authenticator)
+
+	return e
+}
+
+func runServeHTTP(ctx context.Context, cfg configs.Config, args []string) error {
+	repos, err := openServingRepositories(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeRepositories(repos)
+
+	return serveHTTP(ctx, cfg, repos)
+}
+
+func newTokenIssuer(cfg configs.Config) *auth.TokenIssuer {
+	return auth.NewTokenIssuer(cfg.Auth.JWTSecret, cfg.Auth.Issuer, cfg.Auth.AccessTokenTTL.Duration, cfg.Auth.RefreshTokenTTL.Duration)
+}
+
+// serveHTTP chạy HTTP API cho tới khi ctx bị huỷ, sau đó chờ các request đang xử lý
+func serveHTTP(ctx context.Context, cfg configs.Config, repos repositories.Repositories) error {
+	if err := cfg.ValidateHTTP(); err != nil {
+		return err
+	}
+
+	registerReadinessChecks(repos)
+	return runHTTPServer(ctx, newHTTPServer(cfg, repos), cfg.HTTP.Addr, cfg.ShutdownTimeout.Duration)
+}
+
+// runHTTPServer chạy e trên addr (hoặc e.Listener nếu đã có) và cho các request đang xử lý
+// tối đa shutdownTimeout để xong khi ctx bị huỷ
+func runHTTPServer(ctx context.Context, e *echo.Echo, addr string, shutdownTimeout time.Duration) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("HTTP server đang chạy", "addr", addr)
+		if err := e.Start(addr); !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("HTTP server đang dừng")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		e.Close()
+		return err
+	}
+	return <-serveErr
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestRunHTTPServerShutdownTimeout(t *testing.T) {
+	const shutdownTimeout = 300 * time.Millisecond
+	tests := []struct {
+		name string
+		// slowRequest giữ một request đang xử lý lâu hơn shutdownTimeout
+		slowRequest bool
+		wantErr     error
+		min, max    time.Duration
+	}{
+		{"idle server stops without waiting", false, nil, 0, shutdownTimeout},
+		{"slow request is cut off after the timeout", true, context.DeadlineExceeded, shutdownTimeout, shutdownTimeout + time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+			release := make(chan struct{})
+			defer close(release)
+
+			e := echo.New()
+			e.HideBanner = true
+			e.HidePort = true
+			e.GET("/slow", func(c echo.Context) error {
+				close(started)
+				<-release
+				return c.NoContent(http.StatusOK)
+			})
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			e.Listener = lis
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan error, 1)
+			go func() { done <- runHTTPServer(ctx, e, lis.Addr().String(), shutdownTimeout) }()
+
+			if tt.slowRequest {
+				go http.Get("http://" + lis.Addr().String() + "/slow")
+				<-started
+			}
+
+			start := time.Now()
+			cancel()
+			select {
+			case err := <-done:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("runHTTPServer = %v, want %v", err, tt.wantErr)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("runHTTPServer did not return after shutdown")
+			}
+			if elapsed := time.Since(start); elapsed < tt.min || elapsed > tt.max {
+				t.Fatalf("shutdown took %v, want between %v and %v", elapsed, tt.min, tt.max)
+			}
+		})
+	}
+}
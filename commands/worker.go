@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"context"
+	"draft-notification/configs"
+	"draft-notification/health"
+	"draft-notification/metrics"
+	"draft-notification/queue"
+	"draft-notification/repositories"
+	"draft-notification/webhook"
+	"errors"
+	"log/slog"
+)
+
+func runWorker(ctx context.Context, cfg configs.Config, args []string) error {
+	repos, err := openServingRepositories(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeRepositories(repos)
+
+	return withMetricsServer(ctx, cfg, func(ctx context.Context) error {
+		return runWorkers(ctx, cfg, repos)
+	})
+}
+
+func runWorkers(ctx context.Context, cfg configs.Config, repos repositories.Repositories) error {
+	metrics.SetMaxDeliveryLabelValues(cfg.Metrics.MaxDeliveryLabelValues)
+	queue.RegisterMetrics(repos.Jobs)
+
+	dispatcher := webhook.NewDispatcher(repos.Connections, webhookPolicy(cfg), cfg.Webhook.Timeout.Duration)
+
+	worker := &queue.Worker{
+		Jobs:          repos.Jobs,
+		Handler:       dispatcher.Deliver,
+		Concurrency:   cfg.Worker.Concurrency,
+		LeaseDuration: cfg.Worker.LeaseDuration.Duration,
+		PollInterval:  cfg.Worker.PollInterval.Duration,
+		MaxAttempts:   cfg.Worker.MaxAttempts,
+	}
+
+	registerReadinessChecks(repos)
+	registerQueueLagCheck(cfg, repos)
+	health.Register("workers", func(ctx context.Context) error {
+		if !worker.Running() {
+			return errors.New("workers are not running")
+		}
+		return nil
+	})
+
+	slog.Info("Worker đang chạy", "concurrency", cfg.Worker.Concurrency)
+	return worker.Run(ctx, cfg.ShutdownTimeout.Duration)
+}
+
+func webhookPolicy(cfg configs.Config) webhook.Policy {
+	return webhook.Policy{
+		HTTPSOnly:            cfg.Webhook.HTTPSOnly,
+		AllowedHosts:         cfg.Webhook.AllowedHosts,
+		DeniedHosts:          cfg.Webhook.DeniedHosts,
+		AllowedPorts:         cfg.Webhook.AllowedPorts,
+		AllowPrivateNetworks: cfg.Webhook.AllowPrivateNetworks,
+	}
+}
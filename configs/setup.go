@@ -2,40 +2,44 @@ package configs
 
 import (
 	"context"
-	"log"
-	"time"
+	"log/slog"
 
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func ConnectDB() *mongo.Client {
-	clientOptions := options.Client().ApplyURI("mongodb://localhost:27017")
+// ConnectDB kết nối MongoDB, poolMonitor nhận event của connection pool (có thể nil) để export metric
+func ConnectDB(cfg MongoConfig, poolMonitor *event.PoolMonitor) (*mongo.Client, error) {
+	clientOptions := options.Client().ApplyURI(cfg.URI).SetPoolMonitor(poolMonitor)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout.Duration)
 	defer cancel()
 
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	// Ping the database to verify connection
 	err = client.Ping(ctx, nil)
 	if err != nil {
-		log.Fatal(err)
+		client.Disconnect(context.Background())
+		return nil, err
 	}
 
-	log.Println("✅ Kết nối MongoDB thành công!")
+	slog.Info("Kết nối MongoDB thành công", "database", cfg.Database)
 
-	return client
+	return client, nil
 }
 
-// Client instance
-var DB *mongo.Client = ConnectDB()
+// getting database
+func GetDatabase(client *mongo.Client, cfg MongoConfig) *mongo.Database {
+	return client.Database(cfg.Database)
+}
 
 // getting database collections
-func GetCollection(client *mongo.Client, collectionName string) *mongo.Collection {
-	collection := client.Database("draft-notification").Collection(collectionName)
+func GetCollection(db *mongo.Database, collectionName string) *mongo.Collection {
+	collection := db.Collection(collectionName)
 	return collection
 }
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"draft-notification/apperrors"
+	"draft-notification/audit"
+	"draft-notification/auth"
+	"draft-notification/dtos"
+	"draft-notification/helpers"
+	"draft-notification/middlewares"
+	"draft-notification/models"
+	"draft-notification/repositories"
+	"draft-notification/responses"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AdminController struct {
+	admins repositories.AdminRepo
+	audit  *audit.Recorder
+}
+
+func NewAdminController(repos repositories.Repositories) *AdminController {
+	return &AdminController{admins: repos.Admins, audit: audit.NewRecorder(repos.AuditEvents)}
+}
+
+func (ctl *AdminController) CreateAdmin(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	var request dtos.CreateAdminRequest
+	if err := c.Bind(&request); err != nil {
+		return helpers.HandleError(c, helpers.ErrInvalidJSON.Wrap(err))
+	}
+	if err := helpers.ValidateStruct(request); err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	if !auth.ValidRole(request.Role) {
+		return helpers.HandleError(c, errInvalidRole)
+	}
+
+	passwordHash, err := auth.HashPassword(request.Password)
+	if errors.Is(err, auth.ErrPasswordTooShort) {
+		return helpers.HandleError(c, apperrors.InvalidField("password", "min_length", map[string]string{"param": strconv.Itoa(auth.MinPasswordLength)}))
+	}
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	newAdmin := models.Admin{
+		Id:             primitive.NewObjectID(),
+		OrganizationId: currentOrganization(c),
+		Username:       request.Username,
+		Role:           request.Role,
+		PasswordHash:   passwordHash,
+		CreatedAt:      time.Now().UTC(),
+		UpdatedAt:      time.Now().UTC(),
+	}
+
+	if err := ctl.admins.Create(ctx, newAdmin); err != nil {
+		return helpers.HandleError(c, duplicate(err, errUsernameTaken))
+	}
+
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionCreate, models.AuditEntityAdmin, newAdmin.Id), nil, newAdmin)
+
+	return helpers.HandleSuccess(c, responses.CreatedResponse{InsertedID: newAdmin.Id})
+}
+
+func (ctl *AdminController) GetAllAdmins(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	limit, page := parsePagination(c)
+	filter := repositories.AdminFilter{
+		OrganizationId: currentOrganization(c),
+		Keyword:        c.QueryParam("keyword"),
+		Limit:          limit,
+		Page:           page,
+	}
+	if role := c.QueryParam("role"); auth.ValidRole(role) {
+		filter.Role = role
+	}
+
+	admins, totalCount, err := ctl.admins.List(ctx, filter)
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	data := responses.GetAllAdminResponse{
+		List: admins,
+		Pagination: responses.Pagination{
+			Total: int(totalCount),
+			Limit: limit,
+			Page:  page,
+		}}
+	return helpers.HandleSuccess(c, data)
+}
+
+func (ctl *AdminController) ChangeRoleAdmin(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	objId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return helpers.HandleError(c, errInvalidId)
+	}
+
+	var request dtos.ChangeRoleAdminRequest
+	if err := c.Bind(&request); err != nil {
+		return helpers.HandleError(c, helpers.ErrInvalidJSON.Wrap(err))
+	}
+
+	if !auth.ValidRole(request.Role) {
+		return helpers.HandleError(c, errInvalidRole)
+	}
+
+	// Không cho owner tự hạ quyền để tránh mất owner cuối cùng
+	if claims := middlewares.CurrentClaims(c); claims.AdminId == objId && request.Role != auth.RoleOwner {
+		return helpers.HandleError(c, apperrors.Forbidden("self_demotion"))
+	}
+
+	admin, err := ctl.admins.FindById(ctx, objId)
+	if err == nil && admin.OrganizationId != currentOrganization(c) {
+		err = repositories.ErrNotFound
+	}
+	if err != nil {
+		return helpers.HandleError(c, notFound(err, errAdminNotFound))
+	}
+
+	updatedAdmin, err := ctl.admins.UpdateRole(ctx, objId, request.Role)
+	if err != nil {
+		return helpers.HandleError(c, notFound(err, errAdminNotFound))
+	}
+
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionChangeRole, models.AuditEntityAdmin, objId), admin, updatedAdmin)
+
+	return helpers.HandleSuccess(c, updatedAdmin)
+}
@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"context"
+	"draft-notification/apperrors"
+	"draft-notification/audit"
+	"draft-notification/auth"
+	"draft-notification/dtos"
+	"draft-notification/helpers"
+	"draft-notification/middlewares"
+	"draft-notification/models"
+	"draft-notification/repositories"
+	"draft-notification/responses"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ApiKeyController quản lý scoped API key của webview server và connection
+type ApiKeyController struct {
+	webviewServers repositories.WebviewServerRepo
+	connections    repositories.ConnectionRepo
+	apiKeys        repositories.ApiKeyRepo
+	audit          *audit.Recorder
+}
+
+func NewApiKeyController(repos repositories.Repositories) *ApiKeyController {
+	return &ApiKeyController{
+		webviewServers: repos.WebviewServers,
+		connections:    repos.Connections,
+		apiKeys:        repos.ApiKeys,
+		audit:          audit.NewRecorder(repos.AuditEvents),
+	}
+}
+
+func (ctl *ApiKeyController) CreateWebviewServerApiKey(c echo.Context) error {
+	return ctl.createApiKey(c, models.ApiKeyOwnerWebviewServer)
+}
+
+func (ctl *ApiKeyController) CreateConnectionApiKey(c echo.Context) error {
+	return ctl.createApiKey(c, models.ApiKeyOwnerConnection)
+}
+
+func (ctl *ApiKeyController) GetAllWebviewServerApiKeys(c echo.Context) error {
+	return ctl.listApiKeys(c, models.ApiKeyOwnerWebviewServer)
+}
+
+func (ctl *ApiKeyController) GetAllConnectionApiKeys(c echo.Context) error {
+	return ctl.listApiKeys(c, models.ApiKeyOwnerConnection)
+}
+
+func (ctl *ApiKeyController) RevokeApiKey(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	objId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return helpers.HandleError(c, errInvalidId)
+	}
+
+	apiKey, err := ctl.apiKeys.FindById(ctx, objId)
+	if err == nil && apiKey.OrganizationId != currentOrganization(c) {
+		err = repositories.ErrNotFound
+	}
+	if err != nil {
+		return helpers.HandleError(c, notFound(err, errApiKeyNotFound))
+	}
+
+	if !apiKey.RevokedAt.IsZero() {
+		return helpers.HandleDone(c)
+	}
+
+	revokedApiKey, err := ctl.apiKeys.Revoke(ctx, objId)
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionRevoke, models.AuditEntityApiKey, objId), apiKey, revokedApiKey)
+
+	return helpers.HandleSuccess(c, revokedApiKey)
+}
+
+func (ctl *ApiKeyController) createApiKey(c echo.Context, ownerType string) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	ownerId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return helpers.HandleError(c, errInvalidId)
+	}
+
+	var request dtos.CreateApiKeyRequest
+	if err := c.Bind(&request); err != nil {
+		return helpers.HandleError(c, helpers.ErrInvalidJSON.Wrap(err))
+	}
+	if err := helpers.ValidateStruct(request); err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	for _, scope := range request.Scopes {
+		if !auth.ValidScope(scope) {
+			return helpers.HandleError(c, apperrors.InvalidField("scopes", "scope", map[string]string{"value": scope}))
+		}
+	}
+	for _, entry := range request.AllowedIps {
+		if !auth.ValidIPAllowlistEntry(entry) {
+			return helpers.HandleError(c, apperrors.InvalidField("allowedIps", "ip", map[string]string{"value": entry}))
+		}
+	}
+
+	now := time.Now().UTC()
+	var expiresAt time.Time
+	if request.ExpiresAt != nil {
+		if !request.ExpiresAt.After(now) {
+			return helpers.HandleError(c, apperrors.InvalidField("expiresAt", "future", nil))
+		}
+		expiresAt = request.ExpiresAt.UTC()
+	}
+
+	side := models.ApiKeySideWebviewServer
+	if ownerType == models.ApiKeyOwnerConnection && request.Side != "" {
+		if request.Side != models.ApiKeySideWebviewServer && request.Side != models.ApiKeySideUserDeliveryServer {
+			return helpers.HandleError(c, apperrors.InvalidField("side", "oneof", map[string]string{
+				"values": models.ApiKeySideWebviewServer + ", " + models.ApiKeySideUserDeliveryServer,
+			}))
+		}
+		side = request.Side
+	}
+
+	if err := ctl.checkOwner(ctx, c, ownerType, ownerId); err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	key, prefix, hash, err := helpers.NewAPIKey()
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	newApiKey := models.ApiKey{
+		Id:             primitive.NewObjectID(),
+		OrganizationId: currentOrganization(c),
+		Name:           request.Name,
+		OwnerType:      ownerType,
+		OwnerId:        ownerId,
+		Side:           side,
+		Scopes:         request.Scopes,
+		AllowedIps:     request.AllowedIps,
+		Prefix:         prefix,
+		Hash:           hash,
+		ExpiresAt:      expiresAt,
+		CreatedBy:      middlewares.CurrentClaims(c).AdminId,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if err := ctl.apiKeys.Create(ctx, newApiKey); err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionCreate, models.AuditEntityApiKey, newApiKey.Id), nil, newApiKey)
+
+	return helpers.HandleSuccess(c, responses.CreatedApiKeyResponse{InsertedID: newApiKey.Id, ApiKey: key})
+}
+
+func (ctl *ApiKeyController) listApiKeys(c echo.Context, ownerType string) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	ownerId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return helpers.HandleError(c, errInvalidId)
+	}
+
+	if err := ctl.checkOwner(ctx, c, ownerType, ownerId); err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	apiKeys, err := ctl.apiKeys.ListByOwner(ctx, ownerType, ownerId)
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	return helpers.HandleSuccess(c, apiKeys)
+}
+
+// checkOwner kiểm tra webview server / connection tồn tại và thuộc organization của admin hiện tại,
+// trả về lỗi NotFound của owner nếu không
+func (ctl *ApiKeyController) checkOwner(ctx context.Context, c echo.Context, ownerType string, ownerId primitive.ObjectID) error {
+	var organizationId primitive.ObjectID
+	if ownerType == models.ApiKeyOwnerWebviewServer {
+		server, err := ctl.webviewServers.FindById(ctx, ownerId)
+		if err != nil {
+			return notFound(err, errWebviewServerNotFound)
+		}
+		organizationId = server.OrganizationId
+	} else {
+		connection, err := ctl.connections.FindById(ctx, ownerId)
+		if err != nil {
+			return notFound(err, errConnectionNotFound)
+		}
+		organizationId = connection.OrganizationId
+	}
+
+	if organizationId != currentOrganization(c) {
+		if ownerType == models.ApiKeyOwnerWebviewServer {
+			return errWebviewServerNotFound
+		}
+		return errConnectionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"context"
+	"draft-notification/audit"
+	"draft-notification/middlewares"
+	"draft-notification/models"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// auditEvent tạo audit event với actor, request ID và IP của request hiện tại
+func auditEvent(c echo.Context, action, entityType string, entityId primitive.ObjectID) models.AuditEvent {
+	claims := middlewares.CurrentClaims(c)
+	return models.AuditEvent{
+		OrganizationId: claims.OrganizationId,
+		ActorId:        claims.AdminId,
+		ActorUsername:  claims.Username,
+		Action:         action,
+		EntityType:     entityType,
+		EntityId:       entityId,
+		RequestId:      c.Response().Header().Get(echo.HeaderXRequestID),
+		SourceIp:       c.RealIP(),
+	}
+}
+
+// recordDeactivatedConnections ghi audit cho các connection bị deactivate theo server
+func recordDeactivatedConnections(ctx context.Context, recorder *audit.Recorder, parent models.AuditEvent, connections []models.Connection) {
+	for _, before := range connections {
+		after := before
+		after.Status = "inactive"
+		recorder.RecordCascade(ctx, parent, models.AuditActionChangeStatus, models.AuditEntityConnection, before.Id, before, after)
+	}
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"draft-notification/apperrors"
+	"draft-notification/helpers"
+	"draft-notification/models"
+	"draft-notification/repositories"
+	"draft-notification/responses"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditEventController struct {
+	auditEvents repositories.AuditEventRepo
+}
+
+func NewAuditEventController(repos repositories.Repositories) *AuditEventController {
+	return &AuditEventController{auditEvents: repos.AuditEvents}
+}
+
+func (ctl *AuditEventController) GetAllAuditEvents(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	filter, err := parseAuditEventFilter(c)
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+	filter.Limit, filter.Page = parsePagination(c)
+
+	events, totalCount, err := ctl.auditEvents.List(ctx, filter)
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	data := responses.GetAllAuditEventResponse{
+		List: events,
+		Pagination: responses.Pagination{
+			Total: int(totalCount),
+			Limit: filter.Limit,
+			Page:  filter.Page,
+		}}
+	return helpers.HandleSuccess(c, data)
+}
+
+// ExportAuditEvents trả về mọi event khớp filter dạng JSONL (mỗi dòng một event), cũ nhất trước
+func (ctl *AuditEventController) ExportAuditEvents(c echo.Context) error {
+	filter, err := parseAuditEventFilter(c)
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="audit-events.jsonl"`)
+	res.WriteHeader(http.StatusOK)
+
+	// Không dùng timeout của request thường vì export có thể dài, dừng khi client ngắt kết nối
+	encoder := json.NewEncoder(res)
+	err = ctl.auditEvents.Export(c.Request().Context(), filter, func(event models.AuditEvent) error {
+		return encoder.Encode(event)
+	})
+	if err != nil {
+		// Header đã được gửi nên chỉ có thể log lỗi, client nhận được file bị cắt ngang
+		slog.ErrorContext(c.Request().Context(), "Export audit event lỗi", "error", err)
+	}
+	res.Flush()
+	return nil
+}
+
+// parseAuditEventFilter đọc filter từ query: actorId, entityType, entityId, action, requestId,
+// from và to (RFC 3339)
+func parseAuditEventFilter(c echo.Context) (repositories.AuditEventFilter, error) {
+	filter := repositories.AuditEventFilter{
+		OrganizationId: currentOrganization(c),
+		EntityType:     c.QueryParam("entityType"),
+		Action:         c.QueryParam("action"),
+		RequestId:      c.QueryParam("requestId"),
+	}
+
+	var err error
+	if value := c.QueryParam("actorId"); value != "" {
+		if filter.ActorId, err = primitive.ObjectIDFromHex(value); err != nil {
+			return filter, apperrors.InvalidField("actorId", "object_id", nil)
+		}
+	}
+	if value := c.QueryParam("entityId"); value != "" {
+		if filter.EntityId, err = primitive.ObjectIDFromHex(value); err != nil {
+			return filter, apperrors.InvalidField("entityId", "object_id", nil)
+		}
+	}
+	if value := c.QueryParam("from"); value != "" {
+		if filter.From, err = time.Parse(time.RFC3339, value); err != nil {
+			return filter, apperrors.InvalidField("from", "rfc3339", nil)
+		}
+	}
+	if value := c.QueryParam("to"); value != "" {
+		if filter.To, err = time.Parse(time.RFC3339, value); err != nil {
+			return filter, apperrors.InvalidField("to", "rfc3339", nil)
+		}
+	}
+	return filter, nil
+}
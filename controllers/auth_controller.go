@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"draft-notification/apperrors"
+	"draft-notification/auth"
+	"draft-notification/dtos"
+	"draft-notification/helpers"
+	"draft-notification/middlewares"
+	"draft-notification/models"
+	"draft-notification/repositories"
+	"draft-notification/responses"
+	"errors"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Hash giả để thời gian phản hồi không tiết lộ username có tồn tại hay không
+var dummyPasswordHash, _ = auth.HashPassword("dummy-password")
+
+type AuthController struct {
+	admins   repositories.AdminRepo
+	sessions repositories.AdminSessionRepo
+	tokens   *auth.TokenIssuer
+}
+
+func NewAuthController(repos repositories.Repositories, tokens *auth.TokenIssuer) *AuthController {
+	return &AuthController{
+		admins:   repos.Admins,
+		sessions: repos.AdminSessions,
+		tokens:   tokens,
+	}
+}
+
+func (ctl *AuthController) Login(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	var request dtos.LoginRequest
+	if err := c.Bind(&request); err != nil {
+		return helpers.HandleError(c, helpers.ErrInvalidJSON.Wrap(err))
+	}
+	if err := helpers.ValidateStruct(request); err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	admin, err := ctl.admins.FindByUsername(ctx, request.Username)
+	if errors.Is(err, repositories.ErrNotFound) {
+		auth.CheckPassword(dummyPasswordHash, request.Password)
+		return helpers.HandleError(c, errInvalidCredentials)
+	}
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+	if !auth.CheckPassword(admin.PasswordHash, request.Password) {
+		return helpers.HandleError(c, errInvalidCredentials)
+	}
+
+	// Admin tạo trước khi có organization phải được gán bằng lệnh assign-organization
+	if admin.OrganizationId.IsZero() {
+		return helpers.HandleError(c, apperrors.Forbidden("no_organization"))
+	}
+
+	sessionId := primitive.NewObjectID()
+	refreshToken, refreshTokenHash, err := auth.NewRefreshToken(sessionId)
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	now := time.Now().UTC()
+	session := models.AdminSession{
+		Id:               sessionId,
+		AdminId:          admin.Id,
+		RefreshTokenHash: refreshTokenHash,
+		ExpiresAt:        now.Add(ctl.tokens.RefreshTokenTTL),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := ctl.sessions.Create(ctx, session); err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	return ctl.respondTokens(c, admin, sessionId, refreshToken)
+}
+
+// Refresh đổi refresh token lấy cặp token mới, refresh token cũ hết hiệu lực.
+// Nếu refresh token cũ bị dùng lại thì session bị thu hồi vì token có thể đã bị lộ.
+func (ctl *AuthController) Refresh(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	var request dtos.RefreshTokenRequest
+	if err := c.Bind(&request); err != nil {
+		return helpers.HandleError(c, helpers.ErrInvalidJSON.Wrap(err))
+	}
+	if err := helpers.ValidateStruct(request); err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	sessionId, err := auth.ParseRefreshToken(request.RefreshToken)
+	if err != nil {
+		return helpers.HandleError(c, errInvalidRefreshToken)
+	}
+
+	session, err := ctl.sessions.FindById(ctx, sessionId)
+	if err != nil || !session.Active(time.Now()) {
+		return helpers.HandleError(c, errInvalidRefreshToken)
+	}
+
+	if !auth.TokenMatches(request.RefreshToken, session.RefreshTokenHash) {
+		if err := ctl.sessions.Revoke(ctx, sessionId); err != nil {
+			return helpers.HandleError(c, err)
+		}
+		return helpers.HandleError(c, errInvalidRefreshToken)
+	}
+
+	admin, err := ctl.admins.FindById(ctx, session.AdminId)
+	if err != nil {
+		return helpers.HandleError(c, errInvalidRefreshToken)
+	}
+
+	refreshToken, refreshTokenHash, err := auth.NewRefreshToken(sessionId)
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+	expiresAt := time.Now().UTC().Add(ctl.tokens.RefreshTokenTTL)
+	// Một lần refresh khác với cùng token đã đổi hash trước thì lần này không được cấp token mới
+	err = ctl.sessions.UpdateRefreshToken(ctx, sessionId, session.RefreshTokenHash, refreshTokenHash, expiresAt)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return helpers.HandleError(c, errInvalidRefreshToken)
+	}
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	return ctl.respondTokens(c, admin, sessionId, refreshToken)
+}
+
+// Logout thu hồi session hiện tại, cả access token và refresh token đều hết hiệu lực
+func (ctl *AuthController) Logout(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	claims := middlewares.CurrentClaims(c)
+	if err := ctl.sessions.Revoke(ctx, claims.SessionId); err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	return helpers.HandleDone(c)
+}
+
+func (ctl *AuthController) Me(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	admin, err := ctl.admins.FindById(ctx, middlewares.CurrentClaims(c).AdminId)
+	if err != nil {
+		return helpers.HandleError(c, notFound(err, errAdminNotFound))
+	}
+
+	return helpers.HandleSuccess(c, admin)
+}
+
+func (ctl *AuthController) respondTokens(c echo.Context, admin models.Admin, sessionId primitive.ObjectID, refreshToken string) error {
+	accessToken, err := ctl.tokens.IssueAccessToken(admin.Id, admin.OrganizationId, admin.Username, admin.Role, sessionId)
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	return helpers.HandleSuccess(c, responses.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(ctl.tokens.AccessTokenTTL.Seconds()),
+	})
+}
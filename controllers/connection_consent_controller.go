@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"context"
+	"draft-notification/apperrors"
+	"draft-notification/audit"
+	"draft-notification/dtos"
+	"draft-notification/helpers"
+	"draft-notification/middlewares"
+	"draft-notification/models"
+	"draft-notification/repositories"
+	"draft-notification/responses"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ConnectionConsentController quản lý các organization được phép tạo connection tới webview server
+// của organization hiện tại
+type ConnectionConsentController struct {
+	webviewServers repositories.WebviewServerRepo
+	organizations  repositories.OrganizationRepo
+	consents       repositories.ConnectionConsentRepo
+	audit          *audit.Recorder
+}
+
+func NewConnectionConsentController(repos repositories.Repositories) *ConnectionConsentController {
+	return &ConnectionConsentController{
+		webviewServers: repos.WebviewServers,
+		organizations:  repos.Organizations,
+		consents:       repos.ConnectionConsents,
+		audit:          audit.NewRecorder(repos.AuditEvents),
+	}
+}
+
+func (ctl *ConnectionConsentController) CreateConnectionConsent(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	webviewServerObjId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return helpers.HandleError(c, errInvalidId)
+	}
+
+	var request dtos.CreateConnectionConsentRequest
+	if err := c.Bind(&request); err != nil {
+		return helpers.HandleError(c, helpers.ErrInvalidJSON.Wrap(err))
+	}
+	if err := helpers.ValidateStruct(request); err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	webviewServer, err := ctl.findWebviewServer(ctx, c, webviewServerObjId)
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	if request.OrganizationId == webviewServer.OrganizationId {
+		return helpers.HandleError(c, apperrors.InvalidField("organizationId", "self_consent", nil))
+	}
+
+	if _, err := ctl.organizations.FindById(ctx, request.OrganizationId); err != nil {
+		return helpers.HandleError(c, notFound(err, errOrganizationNotFound))
+	}
+
+	newConsent := models.ConnectionConsent{
+		Id:                    primitive.NewObjectID(),
+		OrganizationId:        webviewServer.OrganizationId,
+		WebviewServerId:       webviewServer.Id,
+		GranteeOrganizationId: request.OrganizationId,
+		CreatedBy:             middlewares.CurrentClaims(c).AdminId,
+		CreatedAt:             time.Now().UTC(),
+	}
+
+	if err := ctl.consents.Create(ctx, newConsent); err != nil {
+		return helpers.HandleError(c, duplicate(err, errConsentExists))
+	}
+
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionCreate, models.AuditEntityConnectionConsent, newConsent.Id), nil, newConsent)
+
+	return helpers.HandleSuccess(c, responses.CreatedResponse{InsertedID: newConsent.Id})
+}
+
+func (ctl *ConnectionConsentController) GetAllConnectionConsents(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	webviewServerObjId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return helpers.HandleError(c, errInvalidId)
+	}
+
+	webviewServer, err := ctl.findWebviewServer(ctx, c, webviewServerObjId)
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	consents, err := ctl.consents.ListByWebviewServer(ctx, webviewServer.Id)
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	return helpers.HandleSuccess(c, consents)
+}
+
+// DeleteConnectionConsent thu hồi consent. Connection đã tạo vẫn được giữ lại
+// nhưng không thể chuyển sang active cho tới khi được cấp consent lại.
+func (ctl *ConnectionConsentController) DeleteConnectionConsent(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	webviewServerObjId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return helpers.HandleError(c, errInvalidId)
+	}
+	consentObjId, err := primitive.ObjectIDFromHex(c.Param("consentId"))
+	if err != nil {
+		return helpers.HandleError(c, errInvalidId)
+	}
+
+	consent, err := ctl.consents.FindById(ctx, consentObjId)
+	if err == nil && (consent.WebviewServerId != webviewServerObjId || consent.OrganizationId != currentOrganization(c)) {
+		err = repositories.ErrNotFound
+	}
+	if err != nil {
+		return helpers.HandleError(c, notFound(err, errConsentNotFound))
+	}
+
+	if err := ctl.consents.Delete(ctx, consent.Id); err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionDelete, models.AuditEntityConnectionConsent, consent.Id), consent, nil)
+
+	return helpers.HandleDone(c)
+}
+
+// findWebviewServer chỉ trả về webview server thuộc organization của admin hiện tại
+func (ctl *ConnectionConsentController) findWebviewServer(ctx context.Context, c echo.Context, id primitive.ObjectID) (models.WebviewServer, error) {
+	server, err := ctl.webviewServers.FindById(ctx, id)
+	if err == nil && server.OrganizationId != currentOrganization(c) {
+		err = repositories.ErrNotFound
+	}
+	return server, notFound(err, errWebviewServerNotFound)
+}
@@ -1,42 +1,47 @@
 package controllers
 
 import (
-	"draft-notification/configs"
+	"context"
+	"draft-notification/apperrors"
+	"draft-notification/audit"
 	"draft-notification/dtos"
+	"draft-notification/encryption"
 	"draft-notification/helpers"
+	"draft-notification/middlewares"
 	"draft-notification/models"
+	"draft-notification/repositories"
 	"draft-notification/responses"
-	"net/http"
-	"net/url"
-	"strconv"
+	"draft-notification/webhook"
 	"time"
 
 	"github.com/labstack/echo/v4"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var connectionCollection *mongo.Collection = configs.GetCollection(configs.DB, "connection")
-
-// Helper function for binding and validating request body
-func bindAndValidateConnection(c echo.Context, connection *models.Connection) error {
-	// Bind request body to connection struct
-	if err := c.Bind(connection); err != nil {
-		return err
-	}
+type ConnectionController struct {
+	webviewServers      repositories.WebviewServerRepo
+	userDeliveryServers repositories.UserDeliveryServerRepo
+	connections         repositories.ConnectionRepo
+	consents            repositories.ConnectionConsentRepo
+	keyRotationOverlap  time.Duration
+	webhookVerifier     *webhook.Verifier
+	audit               *audit.Recorder
+}
 
-	// Validate the connection struct
-	if validationErr := helpers.Validate.Struct(connection); validationErr != nil {
-		return validationErr
+func NewConnectionController(repos repositories.Repositories, keyRotationOverlap time.Duration, webhookVerifier *webhook.Verifier) *ConnectionController {
+	return &ConnectionController{
+		webviewServers:      repos.WebviewServers,
+		userDeliveryServers: repos.UserDeliveryServers,
+		connections:         repos.Connections,
+		consents:            repos.ConnectionConsents,
+		keyRotationOverlap:  keyRotationOverlap,
+		webhookVerifier:     webhookVerifier,
+		audit:               audit.NewRecorder(repos.AuditEvents),
 	}
-
-	return nil
 }
 
-func CreateConnection(c echo.Context) error {
-	ctx, cancel := helpers.CreateContext()
+func (ctl *ConnectionController) CreateConnection(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
 	defer cancel()
 
 	userDeliveryServerId := c.Param("userDeliveryServerId")
@@ -44,298 +49,467 @@ func CreateConnection(c echo.Context) error {
 	var connection models.Connection
 
 	// Bind and validate the request body
-	if err := bindAndValidateConnection(c, &connection); err != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, err.Error())
+	if err := helpers.BindAndValidate(c, &connection); err != nil {
+		return helpers.HandleError(c, err)
 	}
 
 	userDeliveryServerObjId, err := primitive.ObjectIDFromHex(userDeliveryServerId)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, "Invalid UserDeliveryServerId")
+		return helpers.HandleError(c, errInvalidId)
 	}
 
-	if count, _ := userDeliveryServerCollection.CountDocuments(ctx, bson.M{"_id": userDeliveryServerObjId}); count == 0 {
-		return helpers.HandleError(c, http.StatusInternalServerError, "ID User delivery server không tồn tại trong DB")
+	userDeliveryServer, err := ctl.findUserDeliveryServer(ctx, c, userDeliveryServerObjId)
+	if err != nil {
+		return helpers.HandleError(c, notFound(err, errUserDeliveryServerNotFound))
+	}
+
+	webviewServer, err := ctl.webviewServers.FindById(ctx, connection.WebviewServerId)
+	if err != nil {
+		return helpers.HandleError(c, notFound(err, errWebviewServerNotFound))
 	}
 
-	if count, _ := webviewServerCollection.CountDocuments(ctx, bson.M{"_id": connection.WebviewServerId}); count == 0 {
-		return helpers.HandleError(c, http.StatusInternalServerError, "ID webview server không tồn tại trong DB")
+	if consented, err := ctl.hasConsent(ctx, webviewServer, userDeliveryServer.OrganizationId); err != nil {
+		return helpers.HandleError(c, err)
+	} else if !consented {
+		return ctl.forbidWithoutConsent(c, webviewServer)
 	}
 
 	if connection.UserDeliveryServerWebHookUrl != "" {
-		if _, err := url.ParseRequestURI(connection.UserDeliveryServerWebHookUrl); err != nil {
-			return helpers.HandleError(c, http.StatusBadRequest, "Invalid UserDeliveryServerWebHookUrl")
+		if err := ctl.webhookVerifier.CheckURL(ctx, connection.UserDeliveryServerWebHookUrl); err != nil {
+			return helpers.HandleError(c, invalidWebhookUrl(err))
 		}
 	}
 
-	count, _ := connectionCollection.CountDocuments(ctx, bson.M{"userdeliveryserverid": userDeliveryServerObjId,
-		"webviewserverid": connection.WebviewServerId})
-
-	if count > 0 {
-		return helpers.HandleError(c, http.StatusInternalServerError, "Connection giữa webview server và user delivery server đã tồn tại")
+	// Webhook chưa biết secret này nên không xác minh ở đây: client lưu secret từ response
+	// rồi gọi POST /connections/:id/verify-webhook trước khi active connection
+	webhookSecret, err := helpers.GenerateAPIKey(32)
+	if err != nil {
+		return helpers.HandleError(c, err)
 	}
 
-	WebviewServerApiKey, err := helpers.GenerateAPIKey(32)
+	// Key đầy đủ chỉ được trả về một lần trong response này, DB chỉ lưu prefix và hash
+	webviewServerApiKey, webviewServerApiKeyPrefix, webviewServerApiKeyHash, err := helpers.NewAPIKey()
 	if err != nil {
-		panic(err)
+		return helpers.HandleError(c, err)
 	}
 
-	UserDeliveryServerApiKey, err := helpers.GenerateAPIKey(32)
+	userDeliveryServerApiKey, userDeliveryServerApiKeyPrefix, userDeliveryServerApiKeyHash, err := helpers.NewAPIKey()
 	if err != nil {
-		panic(err)
+		return helpers.HandleError(c, err)
 	}
 
 	// Create new connection
 	newConnection := models.Connection{
-		Id:                           primitive.NewObjectID(),
-		CreatedAt:                    time.Now().UTC(),
-		UpdatedAt:                    time.Now().UTC(),
-		Status:                       "inactive",
-		WebviewServerApiKey:          WebviewServerApiKey,
-		UserDeliveryServerApiKey:     UserDeliveryServerApiKey,
-		WebviewServerId:              connection.WebviewServerId,
-		UserDeliveryServerId:         userDeliveryServerObjId,
-		UserDeliveryServerWebHookUrl: connection.UserDeliveryServerWebHookUrl,
-	}
-
-	result, err := connectionCollection.InsertOne(ctx, newConnection)
-	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
-	}
-
-	return helpers.HandleSuccess(c, result)
+		Id:                             primitive.NewObjectID(),
+		OrganizationId:                 userDeliveryServer.OrganizationId,
+		CreatedAt:                      time.Now().UTC(),
+		UpdatedAt:                      time.Now().UTC(),
+		Status:                         "inactive",
+		WebviewServerApiKeyPrefix:      webviewServerApiKeyPrefix,
+		WebviewServerApiKeyHash:        webviewServerApiKeyHash,
+		UserDeliveryServerApiKeyPrefix: userDeliveryServerApiKeyPrefix,
+		UserDeliveryServerApiKeyHash:   userDeliveryServerApiKeyHash,
+		WebviewServerId:                connection.WebviewServerId,
+		UserDeliveryServerId:           userDeliveryServerObjId,
+		UserDeliveryServerWebHookUrl:   connection.UserDeliveryServerWebHookUrl,
+		WebhookSecret:                  encryption.EncryptedString(webhookSecret),
+	}
+
+	if err := ctl.connections.Create(ctx, newConnection); err != nil {
+		return helpers.HandleError(c, duplicate(err, errConnectionExists))
+	}
+
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionCreate, models.AuditEntityConnection, newConnection.Id), nil, newConnection)
+
+	return helpers.HandleSuccess(c, responses.CreatedConnectionResponse{
+		InsertedID:               newConnection.Id,
+		WebviewServerApiKey:      webviewServerApiKey,
+		UserDeliveryServerApiKey: userDeliveryServerApiKey,
+		WebhookSecret:            webhookSecret,
+	})
 }
 
-func GetAllConnections(c echo.Context) error {
-	ctx, cancel := helpers.CreateContext()
+func (ctl *ConnectionController) GetAllConnections(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
 	defer cancel()
 
 	userDeliveryServerId := c.Param("userDeliveryServerId")
 
 	userDeliveryServerObjId, err := primitive.ObjectIDFromHex(userDeliveryServerId)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, "Invalid UserDeliveryServerId")
+		return helpers.HandleError(c, errInvalidId)
 	}
 
-	if count, _ := userDeliveryServerCollection.CountDocuments(ctx, bson.M{"_id": userDeliveryServerObjId}); count == 0 {
-		return helpers.HandleError(c, http.StatusInternalServerError, "ID User delivery server không tồn tại trong DB")
+	if _, err := ctl.findUserDeliveryServer(ctx, c, userDeliveryServerObjId); err != nil {
+		return helpers.HandleError(c, notFound(err, errUserDeliveryServerNotFound))
 	}
 
-	webviewServerId := c.QueryParam("webviewServerId")
-	status := c.QueryParam("status")
-	limitStr := c.QueryParam("limit")
-	pageStr := c.QueryParam("page")
+	query, err := parseListQuery(c, connectionSortFields)
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+	filter := repositories.ConnectionFilter{
+		UserDeliveryServerId: userDeliveryServerObjId,
+		Status:               parseStatus(c.QueryParam("status")),
+		CreatedFrom:          query.CreatedFrom,
+		CreatedTo:            query.CreatedTo,
+		Sort:                 query.Sort,
+		After:                query.After,
+		Limit:                query.fetchLimit(),
+		Page:                 query.Page,
+	}
 
-	limit := 10
-	page := 0
+	if webviewServerObjId, err := primitive.ObjectIDFromHex(c.QueryParam("webviewServerId")); err == nil {
+		filter.WebviewServerId = webviewServerObjId
+	}
 
-	if limitStr != "" {
-		limitParsed, err := strconv.Atoi(limitStr)
-		if err == nil && limitParsed > 0 {
-			limit = limitParsed
-		}
+	connections, totalCount, err := ctl.connections.ListWithServers(ctx, filter)
+	if err != nil {
+		return helpers.HandleError(c, err)
 	}
 
-	if pageStr != "" {
-		parsedPage, err := strconv.Atoi(pageStr)
-		if err == nil && parsedPage > 0 {
-			page = parsedPage
-		}
+	// Prepare the response data
+	data := responses.GetAllConnectionResponse{}
+	connections, data.Pagination, data.Cursor = paginate(c, query, connections, totalCount, func(conn models.ConnectionWithServers) repositories.SortFields {
+		return repositories.ConnectionSortFields(conn.Connection)
+	})
+
+	// Tạo danh sách chứa dữ liệu phản hồi, tên server đã được lấy cùng connection
+	connectionResponses := []models.ConnectionResponse{}
+	for _, conn := range connections {
+		connectionResponses = append(connectionResponses, models.ConnectionResponse{
+			Id:                               conn.Id,
+			Status:                           conn.Status,
+			CreatedAt:                        conn.CreatedAt,
+			UpdatedAt:                        conn.UpdatedAt,
+			WebviewServerApiKey:              helpers.MaskAPIKey(conn.WebviewServerApiKeyPrefix),
+			UserDeliveryServerApiKey:         helpers.MaskAPIKey(conn.UserDeliveryServerApiKeyPrefix),
+			WebviewServer:                    conn.WebviewServer,
+			UserDeliveryServer:               conn.UserDeliveryServer,
+			UserDeliveryServerWebHookUrl:     conn.UserDeliveryServerWebHookUrl,
+			WebviewServerApiKeyRotation:      conn.WebviewServerApiKeyRotation,
+			UserDeliveryServerApiKeyRotation: conn.UserDeliveryServerApiKeyRotation,
+			WebhookVerified:                  conn.WebhookVerified(),
+		})
+	}
+
+	data.List = connectionResponses
+	return helpers.HandleSuccess(c, data)
+}
+
+func (ctl *ConnectionController) UpdateConnectionWebhookUrl(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	id := c.Param("id")
+	objId, objIdErr := primitive.ObjectIDFromHex(id)
+
+	if objIdErr != nil {
+		return helpers.HandleError(c, errInvalidId)
 	}
 
-	filter := bson.M{
-		"userdeliveryserverid": userDeliveryServerObjId,
+	connection, err := ctl.findConnection(ctx, c, objId)
+	if err != nil {
+		return helpers.HandleError(c, notFound(err, errConnectionNotFound))
 	}
 
-	if webviewServerObjId, err := primitive.ObjectIDFromHex(webviewServerId); err == nil {
-		filter["webviewserverid"] = webviewServerObjId
+	var request dtos.UpdateConnectionWebhookUrlRequest
+	if err := c.Bind(&request); err != nil {
+		return helpers.HandleError(c, helpers.ErrInvalidJSON.Wrap(err))
 	}
 
-	if status == "active" || status == "inactive" {
-		filter["status"] = status
+	if err := ctl.webhookVerifier.CheckURL(ctx, request.UserDeliveryServerWebHookUrl); err != nil {
+		return helpers.HandleError(c, invalidWebhookUrl(err))
 	}
 
-	results, err := connectionCollection.Find(ctx, filter, options.Find().SetLimit(int64(limit)).SetSkip(int64(page*limit)))
-	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+	if request.UserDeliveryServerWebHookUrl == connection.UserDeliveryServerWebHookUrl && connection.WebhookVerified() {
+		return helpers.HandleDone(c)
 	}
-	defer results.Close(ctx)
 
-	// Tạo danh sách chứa dữ liệu phản hồi
-	var connectionResponses []models.ConnectionResponse
+	var verifiedAt time.Time
+	var verificationError string
+	if err := ctl.webhookVerifier.Verify(ctx, request.UserDeliveryServerWebHookUrl, string(connection.WebhookSecret)); err != nil {
+		// Connection đang active thì giữ URL cũ đã xác minh, không chuyển sang URL chưa xác minh
+		if connection.Status == "active" {
+			return helpers.HandleError(c, webhookVerificationFailed(err))
+		}
+		verificationError = err.Error()
+	} else {
+		verifiedAt = time.Now().UTC()
+	}
 
-	for results.Next(ctx) {
-		var conn models.Connection
+	updatedConnection, err := ctl.connections.UpdateWebhookUrl(ctx, objId, request.UserDeliveryServerWebHookUrl, verifiedAt)
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
 
-		if err := results.Decode(&conn); err != nil {
-			return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
-		}
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionUpdateWebhookUrl, models.AuditEntityConnection, objId), connection, updatedConnection)
 
-		// Khai báo struct chứa server info
-		var webviewServer models.ServerInfo
-		var userDeliveryServer models.ServerInfo
+	return helpers.HandleSuccess(c, responses.UpdatedWebhookUrlResponse{
+		Connection:               updatedConnection,
+		WebhookVerified:          updatedConnection.WebhookVerified(),
+		WebhookVerificationError: verificationError,
+	})
+}
 
-		// Lấy thông tin từ collection webviewServer
-		if err := webviewServerCollection.FindOne(ctx, bson.M{"_id": conn.WebviewServerId}).Decode(&webviewServer); err != nil {
-			// Nếu không tìm thấy, giữ nguyên giá trị rỗng
-			webviewServer = models.ServerInfo{}
-		}
+// VerifyConnectionWebhook gửi lại challenge tới webhook URL hiện tại của connection
+func (ctl *ConnectionController) VerifyConnectionWebhook(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
 
-		// Lấy thông tin từ collection userDeliveryServer
-		if err := userDeliveryServerCollection.FindOne(ctx, bson.M{"_id": conn.UserDeliveryServerId}).Decode(&userDeliveryServer); err != nil {
-			userDeliveryServer = models.ServerInfo{}
-		}
+	objId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return helpers.HandleError(c, errInvalidId)
+	}
 
-		// Gộp dữ liệu vào struct response
-		connectionResponse := models.ConnectionResponse{
-			Id:                           conn.Id,
-			Status:                       conn.Status,
-			CreatedAt:                    conn.CreatedAt,
-			UpdatedAt:                    conn.UpdatedAt,
-			WebviewServerApiKey:          conn.WebviewServerApiKey,
-			UserDeliveryServerApiKey:     conn.UserDeliveryServerApiKey,
-			WebviewServer:                webviewServer,
-			UserDeliveryServer:           userDeliveryServer,
-			UserDeliveryServerWebHookUrl: conn.UserDeliveryServerWebHookUrl,
-		}
+	connection, err := ctl.findConnection(ctx, c, objId)
+	if err != nil {
+		return helpers.HandleError(c, notFound(err, errConnectionNotFound))
+	}
 
-		connectionResponses = append(connectionResponses, connectionResponse)
+	if connection.UserDeliveryServerWebHookUrl == "" {
+		return helpers.HandleError(c, apperrors.InvalidField("userDeliveryServerWebHookUrl", "required", nil))
 	}
 
-	// Kiểm tra nếu không có dữ liệu thì trả về mảng rỗng
-	if len(connectionResponses) == 0 {
-		connectionResponses = []models.ConnectionResponse{}
+	if err := ctl.webhookVerifier.Verify(ctx, connection.UserDeliveryServerWebHookUrl, string(connection.WebhookSecret)); err != nil {
+		return helpers.HandleError(c, webhookVerificationFailed(err))
 	}
 
-	// Count total documents matching the filter
-	totalCount, err := connectionCollection.CountDocuments(ctx, filter)
+	updatedConnection, err := ctl.connections.UpdateWebhookUrl(ctx, objId, connection.UserDeliveryServerWebHookUrl, time.Now().UTC())
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+		return helpers.HandleError(c, err)
 	}
 
-	// Prepare the response data
-	data := responses.GetAllConnectionResponse{
-		List: connectionResponses,
-		Pagination: responses.Pagination{
-			Total: int(totalCount),
-			Limit: limit,
-			Page:  page,
-		}}
-	return helpers.HandleSuccess(c, data)
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionVerifyWebhook, models.AuditEntityConnection, objId), connection, updatedConnection)
+
+	return helpers.HandleSuccess(c, updatedConnection)
 }
 
-func UpdateConnectionWebhookUrl(c echo.Context) error {
-	ctx, cancel := helpers.CreateContext()
+// RotateWebhookSecret cấp secret mới để ký challenge và payload webhook, secret chỉ được trả về một lần
+func (ctl *ConnectionController) RotateWebhookSecret(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
 	defer cancel()
 
-	id := c.Param("id")
-	objId, objIdErr := primitive.ObjectIDFromHex(id)
-
-	if objIdErr != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, "Invalid ID")
-	}
-
-	var connection models.Connection
-	err := connectionCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&connection)
+	objId, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, "Id ko tồn tại trong DB")
-	}
-
-	var request dtos.UpdateConnectionWebhookUrlRequest
-	if err := c.Bind(&request); err != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, "Invalid JSON format")
+		return helpers.HandleError(c, errInvalidId)
 	}
 
-	if _, err := url.ParseRequestURI(request.UserDeliveryServerWebHookUrl); err != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, "Invalid UserDeliveryServerWebHookUrl")
+	connection, err := ctl.findConnection(ctx, c, objId)
+	if err != nil {
+		return helpers.HandleError(c, notFound(err, errConnectionNotFound))
 	}
 
-	if request.UserDeliveryServerWebHookUrl == connection.UserDeliveryServerWebHookUrl {
-		return helpers.HandleSuccess(c, "thành công")
+	secret, err := helpers.GenerateAPIKey(32)
+	if err != nil {
+		return helpers.HandleError(c, err)
 	}
 
-	update := bson.M{"userdeliveryserverwebhookurl": request.UserDeliveryServerWebHookUrl}
-	result, err := connectionCollection.UpdateOne(ctx, bson.M{"_id": objId}, bson.M{"$set": update})
+	updatedConnection, err := ctl.connections.UpdateWebhookSecret(ctx, objId, secret)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+		return helpers.HandleError(c, err)
 	}
 
-	// Retrieve updated connection details
-	var updatedConnection models.Connection
-	if result.MatchedCount == 1 {
-		err := connectionCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&updatedConnection)
-		if err != nil {
-			return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
-		}
-	}
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionRotateWebhookSecret, models.AuditEntityConnection, objId), connection, updatedConnection)
 
-	return helpers.HandleSuccess(c, updatedConnection)
+	return helpers.HandleSuccess(c, responses.RotateWebhookSecretResponse{ConnectionId: objId, WebhookSecret: secret})
 }
 
-func ChangeStatusConnection(c echo.Context) error {
-	ctx, cancel := helpers.CreateContext()
+func (ctl *ConnectionController) ChangeStatusConnection(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
 	defer cancel()
 
 	id := c.Param("id")
 	objId, objIdErr := primitive.ObjectIDFromHex(id)
 
 	if objIdErr != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, "Invalid ID")
+		return helpers.HandleError(c, errInvalidId)
 	}
 
-	var connection models.Connection
-	err := connectionCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&connection)
+	connection, err := ctl.findConnection(ctx, c, objId)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, "Id ko tồn tại trong DB")
+		return helpers.HandleError(c, notFound(err, errConnectionNotFound))
 	}
 
 	var request dtos.ChangeStatusConnectionRequest
 	if err := c.Bind(&request); err != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, "Invalid JSON format")
+		return helpers.HandleError(c, helpers.ErrInvalidJSON.Wrap(err))
 	}
 
 	if request.Status != "active" && request.Status != "inactive" {
-		return helpers.HandleError(c, http.StatusBadRequest, "Invalid status value")
+		return helpers.HandleError(c, errInvalidStatus)
 	}
 
 	if request.Status == "active" {
-		var webviewServer models.WebviewServer
-		var userDeliveryServer models.UserDeliveryServer
-
-		if err := webviewServerCollection.FindOne(ctx, bson.M{"_id": connection.WebviewServerId}).Decode(&webviewServer); err != nil {
-			return helpers.HandleError(c, http.StatusInternalServerError, "Không tìm thấy thông tin webview server")
+		webviewServer, err := ctl.webviewServers.FindById(ctx, connection.WebviewServerId)
+		if err != nil {
+			return helpers.HandleError(c, notFound(err, errWebviewServerNotFound))
 		}
 
-		if err := userDeliveryServerCollection.FindOne(ctx, bson.M{"_id": connection.UserDeliveryServerId}).Decode(&userDeliveryServer); err != nil {
-			return helpers.HandleError(c, http.StatusInternalServerError, "Không tìm thấy thông tin user delivery server")
+		userDeliveryServer, err := ctl.userDeliveryServers.FindById(ctx, connection.UserDeliveryServerId)
+		if err != nil {
+			return helpers.HandleError(c, notFound(err, errUserDeliveryServerNotFound))
 		}
 
 		if userDeliveryServer.Status != "active" {
-			return helpers.HandleError(c, http.StatusInternalServerError, "User delivery server chưa active")
+			return helpers.HandleError(c, apperrors.Conflict("user_delivery_server_inactive"))
 		}
 
 		if webviewServer.Status != "active" {
-			return helpers.HandleError(c, http.StatusInternalServerError, "Webview server chưa active")
+			return helpers.HandleError(c, apperrors.Conflict("webview_server_inactive"))
+		}
+
+		if !connection.WebhookVerified() {
+			return helpers.HandleError(c, apperrors.Forbidden("webhook_not_verified").WithData(&echo.Map{
+				"connectionId":                 connection.Id,
+				"userDeliveryServerWebHookUrl": connection.UserDeliveryServerWebHookUrl,
+			}))
+		}
+
+		// Consent có thể đã bị thu hồi sau khi connection được tạo
+		if consented, err := ctl.hasConsent(ctx, webviewServer, connection.OrganizationId); err != nil {
+			return helpers.HandleError(c, err)
+		} else if !consented {
+			return ctl.forbidWithoutConsent(c, webviewServer)
 		}
 	}
 
 	if request.Status == connection.Status {
-		return helpers.HandleSuccess(c, "thành công")
+		return helpers.HandleDone(c)
 	}
 
-	update := bson.M{"status": request.Status}
-	result, err := connectionCollection.UpdateOne(ctx, bson.M{"_id": objId}, bson.M{"$set": update})
+	updatedConnection, err := ctl.connections.UpdateStatus(ctx, objId, request.Status)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+		return helpers.HandleError(c, err)
 	}
 
-	// Retrieve updated connection details
-	var updatedConnection models.Connection
-	if result.MatchedCount == 1 {
-		err := connectionCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&updatedConnection)
-		if err != nil {
-			return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionChangeStatus, models.AuditEntityConnection, objId), connection, updatedConnection)
+
+	return helpers.HandleSuccess(c, updatedConnection)
+}
+
+func (ctl *ConnectionController) RotateWebviewServerApiKey(c echo.Context) error {
+	return ctl.rotateApiKey(c, models.ApiKeySideWebviewServer)
+}
+
+func (ctl *ConnectionController) RotateUserDeliveryServerApiKey(c echo.Context) error {
+	return ctl.rotateApiKey(c, models.ApiKeySideUserDeliveryServer)
+}
+
+// rotateApiKey cấp API key mới cho một phía, key mới chỉ được trả về một lần.
+// Key cũ vẫn dùng được trong thời gian overlap.
+func (ctl *ConnectionController) rotateApiKey(c echo.Context, side string) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	objId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return helpers.HandleError(c, errInvalidId)
+	}
+
+	var request dtos.RotateApiKeyRequest
+	if err := c.Bind(&request); err != nil {
+		return helpers.HandleError(c, helpers.ErrInvalidJSON.Wrap(err))
+	}
+
+	overlap := ctl.keyRotationOverlap
+	if request.Overlap != "" {
+		parsed, err := time.ParseDuration(request.Overlap)
+		if err != nil || parsed < 0 || parsed > ctl.keyRotationOverlap {
+			return helpers.HandleError(c, apperrors.InvalidField("overlap", "duration", nil))
 		}
+		overlap = parsed
 	}
 
-	return helpers.HandleSuccess(c, updatedConnection)
+	connection, err := ctl.findConnection(ctx, c, objId)
+	if err != nil {
+		return helpers.HandleError(c, notFound(err, errConnectionNotFound))
+	}
+
+	apiKey, prefix, hash, err := helpers.NewAPIKey()
+	if err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	previousKeyPrefix, previousKeyHash := connection.WebviewServerApiKeyPrefix, connection.WebviewServerApiKeyHash
+	previousRotation := connection.WebviewServerApiKeyRotation
+	if side == models.ApiKeySideUserDeliveryServer {
+		previousKeyPrefix, previousKeyHash = connection.UserDeliveryServerApiKeyPrefix, connection.UserDeliveryServerApiKeyHash
+		previousRotation = connection.UserDeliveryServerApiKeyRotation
+	}
+
+	// Mỗi phía chỉ giữ được một key cũ: rotate lần nữa trong overlap sẽ thu hồi ngay key cũ hơn mà
+	// client có thể vẫn đang dùng, nên chỉ cho phép với overlap 0 (thu hồi cả hai khi key bị lộ)
+	now := time.Now().UTC()
+	if previousRotation.PreviousKeyActive(now) && overlap > 0 {
+		return helpers.HandleError(c, errApiKeyRotationInProgress.WithData(map[string]time.Time{
+			"previousKeyExpiresAt": previousRotation.PreviousKeyExpiresAt,
+		}))
+	}
+	rotation := models.ApiKeyRotation{
+		PreviousKeyPrefix:    previousKeyPrefix,
+		PreviousKeyHash:      previousKeyHash,
+		PreviousKeyExpiresAt: now.Add(overlap),
+		RotatedBy:            middlewares.CurrentClaims(c).AdminId,
+		RotatedAt:            now,
+	}
+
+	updatedConnection, err := ctl.connections.RotateApiKey(ctx, objId, side, prefix, hash, rotation)
+	if err != nil {
+		// Key đã bị một request khác rotate sau khi đọc connection
+		return helpers.HandleError(c, replaceError(err, repositories.ErrNotFound, errApiKeyRotationInProgress))
+	}
+
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionRotateApiKey, models.AuditEntityConnection, objId), connection, updatedConnection)
+
+	return helpers.HandleSuccess(c, responses.RotateApiKeyResponse{
+		ConnectionId:         objId,
+		Side:                 side,
+		ApiKey:               apiKey,
+		PreviousKeyExpiresAt: rotation.PreviousKeyExpiresAt,
+	})
+}
+
+// findUserDeliveryServer chỉ trả về user delivery server thuộc organization của admin hiện tại
+func (ctl *ConnectionController) findUserDeliveryServer(ctx context.Context, c echo.Context, id primitive.ObjectID) (models.UserDeliveryServer, error) {
+	server, err := ctl.userDeliveryServers.FindById(ctx, id)
+	if err == nil && server.OrganizationId != currentOrganization(c) {
+		return models.UserDeliveryServer{}, repositories.ErrNotFound
+	}
+	return server, err
+}
+
+// findConnection chỉ trả về connection thuộc organization của admin hiện tại
+func (ctl *ConnectionController) findConnection(ctx context.Context, c echo.Context, id primitive.ObjectID) (models.Connection, error) {
+	connection, err := ctl.connections.FindById(ctx, id)
+	if err == nil && connection.OrganizationId != currentOrganization(c) {
+		return models.Connection{}, repositories.ErrNotFound
+	}
+	return connection, err
+}
+
+// hasConsent kiểm tra organizationId có được kết nối tới webview server không.
+// Connection trong cùng organization không cần consent.
+func (ctl *ConnectionController) hasConsent(ctx context.Context, webviewServer models.WebviewServer, organizationId primitive.ObjectID) (bool, error) {
+	if webviewServer.OrganizationId == organizationId {
+		return true, nil
+	}
+	return ctl.consents.Exists(ctx, webviewServer.Id, organizationId)
+}
+
+func (ctl *ConnectionController) forbidWithoutConsent(c echo.Context, webviewServer models.WebviewServer) error {
+	return helpers.HandleError(c, apperrors.Forbidden("consent_required").WithData(&echo.Map{
+		"webviewServerId": webviewServer.Id,
+	}))
+}
+
+// invalidWebhookUrl là lỗi khi webhook URL không đúng định dạng hoặc bị policy chặn
+func invalidWebhookUrl(err error) error {
+	return apperrors.InvalidField("userDeliveryServerWebHookUrl", "webhook_url", map[string]string{"reason": err.Error()})
+}
+
+// webhookVerificationFailed là lỗi khi webhook không trả lời đúng challenge, lý do được trả về
+// để người quản trị sửa endpoint
+func webhookVerificationFailed(err error) error {
+	return apperrors.InvalidField("userDeliveryServerWebHookUrl", "webhook_verification_failed", map[string]string{"reason": err.Error()})
 }
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"draft-notification/helpers"
+	"draft-notification/middlewares"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// currentOrganization trả về organization của admin đang đăng nhập
+func currentOrganization(c echo.Context) primitive.ObjectID {
+	return middlewares.CurrentClaims(c).OrganizationId
+}
+
+// parsePagination đọc limit (mặc định 10, tối đa helpers.MaxPageSize) và page (bắt đầu từ 0) từ query
+func parsePagination(c echo.Context) (limit int, page int) {
+	limitStr := c.QueryParam("limit")
+	pageStr := c.QueryParam("page")
+
+	limit = 10
+	page = 0
+
+	if limitStr != "" {
+		limitParsed, err := strconv.Atoi(limitStr)
+		if err == nil && limitParsed > 0 {
+			limit = min(limitParsed, helpers.MaxPageSize)
+		}
+	}
+
+	if pageStr != "" {
+		parsedPage, err := strconv.Atoi(pageStr)
+		if err == nil && parsedPage > 0 {
+			page = parsedPage
+		}
+	}
+
+	return limit, page
+}
+
+// parseStatus chỉ giữ lại các giá trị status hợp lệ để lọc
+func parseStatus(status string) string {
+	if status == "active" || status == "inactive" {
+		return status
+	}
+	return ""
+}
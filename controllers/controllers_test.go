@@ -0,0 +1,579 @@
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"draft-notification/auth"
+	"draft-notification/controllers"
+	"draft-notification/helpers"
+	"draft-notification/middlewares"
+	"draft-notification/models"
+	"draft-notification/queue"
+	"draft-notification/repositories"
+	"draft-notification/responses"
+	"draft-notification/routes"
+	"draft-notification/tracing"
+	"draft-notification/webhook"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+)
+
+// testServer là HTTP API trên storage memory với các route quản trị server và connection
+type testServer struct {
+	e      *echo.Echo
+	repos  repositories.Repositories
+	tokens *auth.TokenIssuer
+}
+
+// testResponse là responses.Response với data để decode sau
+type testResponse struct {
+	Code   int             `json:"code"`
+	Reason string          `json:"reason"`
+	Data   json.RawMessage `json:"data"`
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	repos := repositories.NewMemory()
+	tokens := auth.NewTokenIssuer("0123456789abcdef0123456789abcdef", "test", time.Minute, time.Hour)
+
+	e := echo.New()
+	e.HTTPErrorHandler = helpers.HTTPErrorHandler
+	e.Use(middlewares.ValidateToken(tokens, repos.AdminSessions))
+	routes.WebviewServerRoute(e, controllers.NewWebviewServerController(repos))
+	routes.UserDeliveryServerRoute(e, controllers.NewUserDeliveryServerController(repos))
+	// Cho phép địa chỉ loopback để webhook có thể là httptest server
+	verifier := webhook.NewVerifier(webhook.Policy{AllowPrivateNetworks: true}, time.Second)
+	routes.ConnectionRoute(e, controllers.NewConnectionController(repos, time.Hour, verifier))
+
+	return &testServer{e: e, repos: repos, tokens: tokens}
+}
+
+// login tạo session cho một admin của organizationId và trả về access token
+func (s *testServer) login(t *testing.T, organizationId primitive.ObjectID, role string) string {
+	t.Helper()
+	now := time.Now().UTC()
+	session := models.AdminSession{Id: primitive.NewObjectID(), AdminId: primitive.NewObjectID(), ExpiresAt: now.Add(time.Hour), CreatedAt: now, UpdatedAt: now}
+	if err := s.repos.AdminSessions.Create(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	token, err := s.tokens.IssueAccessToken(session.AdminId, organizationId, "admin", role, session.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func (s *testServer) do(t *testing.T, token, method, path string, body interface{}) testResponse {
+	t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &reader)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	s.e.ServeHTTP(rec, req)
+
+	var resp testResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s %s: decode %q: %v", method, path, rec.Body.String(), err)
+	}
+	if resp.Code != rec.Code {
+		t.Fatalf("%s %s: body code %d, status %d", method, path, resp.Code, rec.Code)
+	}
+	return resp
+}
+
+// expect kiểm tra status và reason của response rồi decode data vào out (nếu khác nil)
+func expect(t *testing.T, resp testResponse, code int, reason string, out interface{}) {
+	t.Helper()
+	if resp.Code != code || resp.Reason != reason {
+		t.Fatalf("response = %d %s, want %d %s (data %s)", resp.Code, resp.Reason, code, reason, resp.Data)
+	}
+	if out != nil {
+		if err := json.Unmarshal(resp.Data, out); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type created struct {
+	InsertedID primitive.ObjectID `json:"InsertedID"`
+}
+
+type list[T any] struct {
+	List []T `json:"list"`
+}
+
+func TestWebviewServerCRUD(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login(t, primitive.NewObjectID(), auth.RoleOwner)
+
+	var id created
+	expect(t, s.do(t, token, http.MethodPost, "/webview-server", map[string]string{"name": "web"}), http.StatusOK, "success", &id)
+	path := "/webview-server/" + id.InsertedID.Hex()
+
+	var server models.WebviewServer
+	expect(t, s.do(t, token, http.MethodGet, path, nil), http.StatusOK, "success", &server)
+	if server.Name != "web" || server.Status != "inactive" {
+		t.Fatalf("server = %+v", server)
+	}
+
+	expect(t, s.do(t, token, http.MethodPut, path, map[string]string{"name": "web-2"}), http.StatusOK, "success", &server)
+	if server.Name != "web-2" {
+		t.Fatalf("updated name = %q", server.Name)
+	}
+
+	expect(t, s.do(t, token, http.MethodPatch, path+"/change-status", map[string]string{"status": "active"}), http.StatusOK, "success", &server)
+	if server.Status != "active" {
+		t.Fatalf("updated status = %q", server.Status)
+	}
+	expect(t, s.do(t, token, http.MethodPatch, path+"/change-status", map[string]string{"status": "deleted"}), http.StatusUnprocessableEntity, "validation_failed", nil)
+
+	var servers list[models.WebviewServer]
+	expect(t, s.do(t, token, http.MethodGet, "/webview-server", nil), http.StatusOK, "success", &servers)
+	if len(servers.List) != 1 || servers.List[0].Id != id.InsertedID {
+		t.Fatalf("list = %+v", servers.List)
+	}
+}
+
+func TestUserDeliveryServerCRUD(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login(t, primitive.NewObjectID(), auth.RoleOwner)
+
+	var id created
+	expect(t, s.do(t, token, http.MethodPost, "/user-delivery-server", map[string]string{"name": "delivery"}), http.StatusOK, "success", &id)
+	path := "/user-delivery-server/" + id.InsertedID.Hex()
+
+	var server models.UserDeliveryServer
+	expect(t, s.do(t, token, http.MethodPut, path, map[string]string{"name": "delivery-2"}), http.StatusOK, "success", &server)
+	expect(t, s.do(t, token, http.MethodGet, path, nil), http.StatusOK, "success", &server)
+	if server.Name != "delivery-2" {
+		t.Fatalf("server = %+v", server)
+	}
+
+	expect(t, s.do(t, token, http.MethodPatch, path+"/change-status", map[string]string{"status": "active"}), http.StatusOK, "success", &server)
+	if server.Status != "active" {
+		t.Fatalf("updated status = %q", server.Status)
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	s := newTestServer(t)
+	organizationId := primitive.NewObjectID()
+	viewer := s.login(t, organizationId, auth.RoleViewer)
+	operator := s.login(t, organizationId, auth.RoleOperator)
+
+	expect(t, s.do(t, viewer, http.MethodPost, "/webview-server", map[string]string{"name": "web"}), http.StatusForbidden, "missing_permission", nil)
+	expect(t, s.do(t, viewer, http.MethodGet, "/webview-server", nil), http.StatusOK, "success", nil)
+	expect(t, s.do(t, "invalid", http.MethodGet, "/webview-server", nil), http.StatusUnauthorized, "invalid_token", nil)
+	expect(t, s.do(t, operator, http.MethodPost, "/webview-server", map[string]string{"name": "web"}), http.StatusOK, "success", nil)
+}
+
+// Tạo connection trả về API key và webhook secret dạng plaintext nên chỉ role có keys:rotate được gọi
+func TestCreateConnectionRequiresKeysRotate(t *testing.T) {
+	s := newTestServer(t)
+	organizationId := primitive.NewObjectID()
+	owner := s.login(t, organizationId, auth.RoleOwner)
+	operator := s.login(t, organizationId, auth.RoleOperator)
+
+	var webview, delivery created
+	expect(t, s.do(t, operator, http.MethodPost, "/webview-server", map[string]string{"name": "web"}), http.StatusOK, "success", &webview)
+	expect(t, s.do(t, operator, http.MethodPost, "/user-delivery-server", map[string]string{"name": "delivery"}), http.StatusOK, "success", &delivery)
+
+	path := "/user-delivery-server/" + delivery.InsertedID.Hex() + "/connection"
+	body := map[string]string{"webviewServerId": webview.InsertedID.Hex()}
+	expect(t, s.do(t, operator, http.MethodPost, path, body), http.StatusForbidden, "missing_permission", nil)
+	expect(t, s.do(t, owner, http.MethodPost, path, body), http.StatusOK, "success", nil)
+}
+
+func TestOrganizationScoping(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login(t, primitive.NewObjectID(), auth.RoleOwner)
+	otherToken := s.login(t, primitive.NewObjectID(), auth.RoleOwner)
+
+	var webview, delivery created
+	expect(t, s.do(t, token, http.MethodPost, "/webview-server", map[string]string{"name": "web"}), http.StatusOK, "success", &webview)
+	expect(t, s.do(t, token, http.MethodPost, "/user-delivery-server", map[string]string{"name": "delivery"}), http.StatusOK, "success", &delivery)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		reason string
+	}{
+		{"get webview server", http.MethodGet, "/webview-server/" + webview.InsertedID.Hex(), nil, "webview_server_not_found"},
+		{"update webview server", http.MethodPut, "/webview-server/" + webview.InsertedID.Hex(), map[string]string{"name": "x"}, "webview_server_not_found"},
+		{"change webview server status", http.MethodPatch, "/webview-server/" + webview.InsertedID.Hex() + "/change-status", map[string]string{"status": "active"}, "webview_server_not_found"},
+		{"get user delivery server", http.MethodGet, "/user-delivery-server/" + delivery.InsertedID.Hex(), nil, "user_delivery_server_not_found"},
+		{"update user delivery server", http.MethodPut, "/user-delivery-server/" + delivery.InsertedID.Hex(), map[string]string{"name": "x"}, "user_delivery_server_not_found"},
+		{"list connections", http.MethodGet, "/user-delivery-server/" + delivery.InsertedID.Hex() + "/connections", nil, "user_delivery_server_not_found"},
+		{"create connection", http.MethodPost, "/user-delivery-server/" + delivery.InsertedID.Hex() + "/connection", map[string]string{"webviewServerId": webview.InsertedID.Hex()}, "user_delivery_server_not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect(t, s.do(t, otherToken, tt.method, tt.path, tt.body), http.StatusNotFound, tt.reason, nil)
+		})
+	}
+
+	var webviewServers list[models.WebviewServer]
+	expect(t, s.do(t, otherToken, http.MethodGet, "/webview-server", nil), http.StatusOK, "success", &webviewServers)
+	if len(webviewServers.List) != 0 {
+		t.Fatalf("other organization sees %+v", webviewServers.List)
+	}
+	var userDeliveryServers list[models.UserDeliveryServer]
+	expect(t, s.do(t, otherToken, http.MethodGet, "/user-delivery-server", nil), http.StatusOK, "success", &userDeliveryServers)
+	if len(userDeliveryServers.List) != 0 {
+		t.Fatalf("other organization sees %+v", userDeliveryServers.List)
+	}
+
+	// Connection tới webview server của organization khác cần consent
+	var otherDelivery created
+	expect(t, s.do(t, otherToken, http.MethodPost, "/user-delivery-server", map[string]string{"name": "delivery"}), http.StatusOK, "success", &otherDelivery)
+	expect(t, s.do(t, otherToken, http.MethodPost, "/user-delivery-server/"+otherDelivery.InsertedID.Hex()+"/connection",
+		map[string]string{"webviewServerId": webview.InsertedID.Hex()}), http.StatusForbidden, "consent_required", nil)
+}
+
+func TestConnectionKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+	token := s.login(t, primitive.NewObjectID(), auth.RoleOwner)
+
+	var webview, delivery created
+	expect(t, s.do(t, token, http.MethodPost, "/webview-server", map[string]string{"name": "web"}), http.StatusOK, "success", &webview)
+	expect(t, s.do(t, token, http.MethodPost, "/user-delivery-server", map[string]string{"name": "delivery"}), http.StatusOK, "success", &delivery)
+	var connection responses.CreatedConnectionResponse
+	expect(t, s.do(t, token, http.MethodPost, "/user-delivery-server/"+delivery.InsertedID.Hex()+"/connection",
+		map[string]string{"webviewServerId": webview.InsertedID.Hex()}), http.StatusOK, "success", &connection)
+	// Chỉ connection active mới xác thực được, bỏ qua bước xác minh webhook
+	if _, err := s.repos.Connections.UpdateStatus(ctx, connection.InsertedID, "active"); err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator := auth.NewApiKeyAuthenticator(s.repos)
+	authenticates := func(key string) bool {
+		t.Helper()
+		identity, err := authenticator.Authenticate(ctx, key, "")
+		if err != nil && !errors.Is(err, auth.ErrInvalidApiKey) {
+			t.Fatal(err)
+		}
+		return err == nil && identity.Connection != nil && identity.Connection.Id == connection.InsertedID
+	}
+	path := "/connections/" + connection.InsertedID.Hex()
+
+	// Overlap mặc định: key cũ dùng tiếp được tới PreviousKeyExpiresAt, key mới dùng được ngay
+	var rotated responses.RotateApiKeyResponse
+	expect(t, s.do(t, token, http.MethodPost, path+"/rotate-webview-server-key", map[string]string{}), http.StatusOK, "success", &rotated)
+	if !rotated.PreviousKeyExpiresAt.After(time.Now().Add(59 * time.Minute)) {
+		t.Fatalf("previousKeyExpiresAt = %s, want about an hour from now", rotated.PreviousKeyExpiresAt)
+	}
+	if !authenticates(rotated.ApiKey) || !authenticates(connection.WebviewServerApiKey) {
+		t.Fatal("new and previous webview server keys should both work during the overlap")
+	}
+
+	// Rotate lại trong overlap bị từ chối, key cũ giữ nguyên hạn thay vì bị thu hồi
+	firstRotated := rotated
+	var inProgress struct {
+		PreviousKeyExpiresAt time.Time `json:"previousKeyExpiresAt"`
+	}
+	expect(t, s.do(t, token, http.MethodPost, path+"/rotate-webview-server-key", map[string]string{}), http.StatusConflict, "api_key_rotation_in_progress", &inProgress)
+	// Storage lưu thời gian tới millisecond như BSON
+	if !inProgress.PreviousKeyExpiresAt.Equal(firstRotated.PreviousKeyExpiresAt.Truncate(time.Millisecond)) {
+		t.Fatalf("previousKeyExpiresAt = %s, want %s", inProgress.PreviousKeyExpiresAt, firstRotated.PreviousKeyExpiresAt)
+	}
+	if !authenticates(firstRotated.ApiKey) || !authenticates(connection.WebviewServerApiKey) {
+		t.Fatal("a rejected rotation should keep both webview server keys working")
+	}
+
+	// Overlap 0 vẫn được phép để thu hồi ngay cả hai key cũ khi key bị lộ
+	expect(t, s.do(t, token, http.MethodPost, path+"/rotate-webview-server-key", map[string]string{"overlap": "0s"}), http.StatusOK, "success", &rotated)
+	if !authenticates(rotated.ApiKey) || authenticates(firstRotated.ApiKey) || authenticates(connection.WebviewServerApiKey) {
+		t.Fatal("rotating with overlap 0 should leave only the new webview server key working")
+	}
+
+	// Overlap 0 thì key cũ hết hiệu lực ngay
+	expect(t, s.do(t, token, http.MethodPost, path+"/rotate-user-delivery-server-key", map[string]string{"overlap": "0s"}), http.StatusOK, "success", &rotated)
+	if !authenticates(rotated.ApiKey) {
+		t.Fatal("new user delivery server key is rejected")
+	}
+	if authenticates(connection.UserDeliveryServerApiKey) {
+		t.Fatal("previous user delivery server key still works after its overlap")
+	}
+
+	expect(t, s.do(t, token, http.MethodPost, path+"/rotate-webview-server-key", map[string]string{"overlap": "2h"}), http.StatusUnprocessableEntity, "validation_failed", nil)
+}
+
+func TestConnectionWebhookVerification(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login(t, primitive.NewObjectID(), auth.RoleOwner)
+
+	// Webhook chỉ trả lời đúng challenge ở /hook và khi đã biết secret của connection
+	var secret atomic.Value
+	secret.Store("")
+	var calls atomic.Int32
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var challenge struct {
+			Challenge string `json:"challenge"`
+		}
+		if r.URL.Path != "/hook" || json.NewDecoder(r.Body).Decode(&challenge) != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"challenge": challenge.Challenge,
+			"signature": webhook.Sign(secret.Load().(string), []byte(challenge.Challenge)),
+		})
+	}))
+	defer hook.Close()
+
+	var webview, delivery created
+	expect(t, s.do(t, token, http.MethodPost, "/webview-server", map[string]string{"name": "web"}), http.StatusOK, "success", &webview)
+	expect(t, s.do(t, token, http.MethodPost, "/user-delivery-server", map[string]string{"name": "delivery"}), http.StatusOK, "success", &delivery)
+	expect(t, s.do(t, token, http.MethodPatch, "/webview-server/"+webview.InsertedID.Hex()+"/change-status", map[string]string{"status": "active"}), http.StatusOK, "success", nil)
+	expect(t, s.do(t, token, http.MethodPatch, "/user-delivery-server/"+delivery.InsertedID.Hex()+"/change-status", map[string]string{"status": "active"}), http.StatusOK, "success", nil)
+
+	var connection responses.CreatedConnectionResponse
+	expect(t, s.do(t, token, http.MethodPost, "/user-delivery-server/"+delivery.InsertedID.Hex()+"/connection", map[string]string{
+		"webviewServerId":              webview.InsertedID.Hex(),
+		"userDeliveryServerWebHookUrl": hook.URL + "/hook",
+	}), http.StatusOK, "success", &connection)
+	// Tạo connection không gọi webhook vì webhook chưa biết secret vừa sinh
+	if calls.Load() != 0 || connection.WebhookSecret == "" {
+		t.Fatalf("create called the webhook %d times, response %+v", calls.Load(), connection)
+	}
+
+	path := "/connections/" + connection.InsertedID.Hex()
+	activate := map[string]string{"status": "active"}
+	expect(t, s.do(t, token, http.MethodPatch, path+"/change-status", activate), http.StatusForbidden, "webhook_not_verified", nil)
+
+	secret.Store(connection.WebhookSecret)
+	var verified models.Connection
+	expect(t, s.do(t, token, http.MethodPost, path+"/verify-webhook", nil), http.StatusOK, "success", &verified)
+	if verified.WebhookVerifiedUrl != hook.URL+"/hook" || verified.WebhookVerifiedAt.IsZero() {
+		t.Fatalf("connection after verification = %+v", verified)
+	}
+	expect(t, s.do(t, token, http.MethodPatch, path+"/change-status", activate), http.StatusOK, "success", nil)
+
+	// Connection active giữ URL đã xác minh khi URL mới không qua được challenge
+	broken := map[string]string{"userDeliveryServerWebHookUrl": hook.URL + "/broken"}
+	expect(t, s.do(t, token, http.MethodPatch, path+"/update-web-hook-url", broken), http.StatusUnprocessableEntity, "validation_failed", nil)
+
+	// Connection inactive nhận URL mới nhưng mất trạng thái đã xác minh và không active lại được
+	expect(t, s.do(t, token, http.MethodPatch, path+"/change-status", map[string]string{"status": "inactive"}), http.StatusOK, "success", nil)
+	var updated responses.UpdatedWebhookUrlResponse
+	expect(t, s.do(t, token, http.MethodPatch, path+"/update-web-hook-url", broken), http.StatusOK, "success", &updated)
+	if updated.WebhookVerified || updated.WebhookVerifiedUrl != "" || updated.UserDeliveryServerWebHookUrl != hook.URL+"/broken" {
+		t.Fatalf("connection after URL change = %+v", updated)
+	}
+	expect(t, s.do(t, token, http.MethodPatch, path+"/change-status", activate), http.StatusForbidden, "webhook_not_verified", nil)
+}
+
+func TestAuditCascade(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+	organizationId := primitive.NewObjectID()
+	token := s.login(t, organizationId, auth.RoleOwner)
+
+	var webview, delivery created
+	expect(t, s.do(t, token, http.MethodPost, "/webview-server", map[string]string{"name": "web"}), http.StatusOK, "success", &webview)
+	expect(t, s.do(t, token, http.MethodPost, "/user-delivery-server", map[string]string{"name": "delivery"}), http.StatusOK, "success", &delivery)
+	var connection responses.CreatedConnectionResponse
+	expect(t, s.do(t, token, http.MethodPost, "/user-delivery-server/"+delivery.InsertedID.Hex()+"/connection",
+		map[string]string{"webviewServerId": webview.InsertedID.Hex()}), http.StatusOK, "success", &connection)
+	path := "/webview-server/" + webview.InsertedID.Hex() + "/change-status"
+	expect(t, s.do(t, token, http.MethodPatch, path, map[string]string{"status": "active"}), http.StatusOK, "success", nil)
+	if _, err := s.repos.Connections.UpdateStatus(ctx, connection.InsertedID, "active"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deactivate webview server kéo theo connection, event của connection trỏ về event của server
+	expect(t, s.do(t, token, http.MethodPatch, path, map[string]string{"status": "inactive"}), http.StatusOK, "success", nil)
+
+	events, _, err := s.repos.AuditEvents.List(ctx, repositories.AuditEventFilter{OrganizationId: organizationId, Action: models.AuditActionChangeStatus})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parent, child *models.AuditEvent
+	for i, event := range events {
+		switch {
+		case event.EntityId == webview.InsertedID && event.Changes[0].After == "inactive":
+			parent = &events[i]
+		case event.EntityId == connection.InsertedID:
+			child = &events[i]
+		}
+	}
+	if parent == nil || child == nil {
+		t.Fatalf("events = %+v, want the server deactivation and its cascade", events)
+	}
+	if !parent.ParentId.IsZero() || child.ParentId != parent.Id || child.EntityType != models.AuditEntityConnection ||
+		child.ActorId != parent.ActorId || child.RequestId != parent.RequestId {
+		t.Fatalf("cascade event = %+v, parent = %+v", child, parent)
+	}
+	if len(child.Changes) != 1 || child.Changes[0].Field != "status" || child.Changes[0].Before != "active" || child.Changes[0].After != "inactive" {
+		t.Fatalf("cascade changes = %+v", child.Changes)
+	}
+}
+
+func TestInvalidId(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login(t, primitive.NewObjectID(), auth.RoleOwner)
+
+	tests := []struct {
+		method string
+		path   string
+		body   interface{}
+	}{
+		{http.MethodGet, "/webview-server/not-an-id", nil},
+		{http.MethodPut, "/webview-server/not-an-id", map[string]string{"name": "web"}},
+		{http.MethodPatch, "/webview-server/not-an-id/change-status", map[string]string{"status": "active"}},
+		{http.MethodGet, "/user-delivery-server/not-an-id", nil},
+		{http.MethodPut, "/user-delivery-server/not-an-id", map[string]string{"name": "delivery"}},
+		{http.MethodPatch, "/user-delivery-server/not-an-id/change-status", map[string]string{"status": "active"}},
+		{http.MethodGet, "/user-delivery-server/not-an-id/connections", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			expect(t, s.do(t, token, tt.method, tt.path, tt.body), http.StatusBadRequest, "invalid_id", nil)
+		})
+	}
+}
+
+func TestServerNameUniqueness(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login(t, primitive.NewObjectID(), auth.RoleOwner)
+	otherToken := s.login(t, primitive.NewObjectID(), auth.RoleOwner)
+
+	tests := []struct {
+		path   string
+		reason string
+	}{
+		{"/webview-server", "webview_server_name_taken"},
+		{"/user-delivery-server", "user_delivery_server_name_taken"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var first, second created
+			expect(t, s.do(t, token, http.MethodPost, tt.path, map[string]string{"name": "a"}), http.StatusOK, "success", &first)
+			expect(t, s.do(t, token, http.MethodPost, tt.path, map[string]string{"name": "a"}), http.StatusConflict, tt.reason, nil)
+			expect(t, s.do(t, token, http.MethodPost, tt.path, map[string]string{"name": "b"}), http.StatusOK, "success", &second)
+			expect(t, s.do(t, token, http.MethodPut, tt.path+"/"+second.InsertedID.Hex(), map[string]string{"name": "a"}), http.StatusConflict, tt.reason, nil)
+
+			// Tên chỉ cần duy nhất trong organization
+			expect(t, s.do(t, otherToken, http.MethodPost, tt.path, map[string]string{"name": "a"}), http.StatusOK, "success", nil)
+		})
+	}
+}
+
+func TestConnectionPairUniqueness(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login(t, primitive.NewObjectID(), auth.RoleOwner)
+
+	var webview, delivery created
+	expect(t, s.do(t, token, http.MethodPost, "/webview-server", map[string]string{"name": "web"}), http.StatusOK, "success", &webview)
+	expect(t, s.do(t, token, http.MethodPost, "/user-delivery-server", map[string]string{"name": "delivery"}), http.StatusOK, "success", &delivery)
+
+	path := "/user-delivery-server/" + delivery.InsertedID.Hex()
+	body := map[string]string{"webviewServerId": webview.InsertedID.Hex()}
+	var connection created
+	expect(t, s.do(t, token, http.MethodPost, path+"/connection", body), http.StatusOK, "success", &connection)
+	expect(t, s.do(t, token, http.MethodPost, path+"/connection", body), http.StatusConflict, "connection_exists", nil)
+
+	var connections list[models.ConnectionResponse]
+	expect(t, s.do(t, token, http.MethodGet, path+"/connections", nil), http.StatusOK, "success", &connections)
+	if len(connections.List) != 1 || connections.List[0].Id != connection.InsertedID {
+		t.Fatalf("connections = %+v", connections.List)
+	}
+	if got := connections.List[0]; got.WebviewServer.Name != "web" || got.UserDeliveryServer.Name != "delivery" {
+		t.Fatalf("connection servers = %+v, %+v", got.WebviewServer, got.UserDeliveryServer)
+	}
+}
+
+func TestNotificationTraceContext(t *testing.T) {
+	ctx := context.Background()
+	if _, err := tracing.Setup(ctx, tracing.Config{Exporter: tracing.ExporterNone}); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestServer(t)
+	s.e.Use(otelecho.Middleware("test"))
+	authenticator := auth.NewApiKeyAuthenticator(s.repos)
+	routes.NotificationRoute(s.e, controllers.NewNotificationController(s.repos, authenticator), authenticator)
+	token := s.login(t, primitive.NewObjectID(), auth.RoleOwner)
+
+	// Webhook trả lời challenge khi xác minh và ghi lại traceparent của notification
+	var secret atomic.Value
+	secret.Store("")
+	delivered := make(chan string, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(webhook.EventHeader) == webhook.EventNotification {
+			delivered <- r.Header.Get("traceparent")
+			return
+		}
+		var challenge struct {
+			Challenge string `json:"challenge"`
+		}
+		json.NewDecoder(r.Body).Decode(&challenge)
+		json.NewEncoder(w).Encode(map[string]string{
+			"challenge": challenge.Challenge,
+			"signature": webhook.Sign(secret.Load().(string), []byte(challenge.Challenge)),
+		})
+	}))
+	defer hook.Close()
+
+	var webview, delivery created
+	expect(t, s.do(t, token, http.MethodPost, "/webview-server", map[string]string{"name": "web"}), http.StatusOK, "success", &webview)
+	expect(t, s.do(t, token, http.MethodPost, "/user-delivery-server", map[string]string{"name": "delivery"}), http.StatusOK, "success", &delivery)
+	expect(t, s.do(t, token, http.MethodPatch, "/webview-server/"+webview.InsertedID.Hex()+"/change-status", map[string]string{"status": "active"}), http.StatusOK, "success", nil)
+	expect(t, s.do(t, token, http.MethodPatch, "/user-delivery-server/"+delivery.InsertedID.Hex()+"/change-status", map[string]string{"status": "active"}), http.StatusOK, "success", nil)
+	var connection responses.CreatedConnectionResponse
+	expect(t, s.do(t, token, http.MethodPost, "/user-delivery-server/"+delivery.InsertedID.Hex()+"/connection", map[string]string{
+		"webviewServerId":              webview.InsertedID.Hex(),
+		"userDeliveryServerWebHookUrl": hook.URL,
+	}), http.StatusOK, "success", &connection)
+	secret.Store(connection.WebhookSecret)
+	path := "/connections/" + connection.InsertedID.Hex()
+	expect(t, s.do(t, token, http.MethodPost, path+"/verify-webhook", nil), http.StatusOK, "success", nil)
+	expect(t, s.do(t, token, http.MethodPatch, path+"/change-status", map[string]string{"status": "active"}), http.StatusOK, "success", nil)
+
+	const traceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodPost, "/api/notifications", bytes.NewBufferString(`{"content":"hello"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(middlewares.ApiKeyHeader, connection.WebviewServerApiKey)
+	req.Header.Set("traceparent", "00-"+traceId+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	s.e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("send notification = %d %s", rec.Code, rec.Body.String())
+	}
+
+	workerCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	dispatcher := webhook.NewDispatcher(s.repos.Connections, webhook.Policy{AllowPrivateNetworks: true}, time.Second)
+	worker := &queue.Worker{Jobs: s.repos.Jobs, Handler: dispatcher.Deliver, Concurrency: 1, LeaseDuration: time.Minute, PollInterval: 10 * time.Millisecond, MaxAttempts: 1}
+	go worker.Run(workerCtx, time.Second)
+
+	// Dispatcher gửi trace của request đưa notification vào hàng đợi, qua job lưu trong storage
+	select {
+	case traceparent := <-delivered:
+		if !strings.HasPrefix(traceparent, "00-"+traceId+"-") {
+			t.Fatalf("webhook traceparent = %q, want trace %s", traceparent, traceId)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification was not delivered")
+	}
+}
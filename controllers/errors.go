@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"draft-notification/apperrors"
+	"draft-notification/auth"
+	"draft-notification/repositories"
+	"errors"
+)
+
+// Lỗi trả về cho client, code là giá trị ổn định để client xử lý và là key của message trong catalogue i18n
+var (
+	errInvalidId = apperrors.BadRequest("invalid_id")
+
+	errWebviewServerNotFound      = apperrors.NotFound("webview_server_not_found")
+	errUserDeliveryServerNotFound = apperrors.NotFound("user_delivery_server_not_found")
+	errConnectionNotFound         = apperrors.NotFound("connection_not_found")
+	errAdminNotFound              = apperrors.NotFound("admin_not_found")
+	errApiKeyNotFound             = apperrors.NotFound("api_key_not_found")
+	errOrganizationNotFound       = apperrors.NotFound("organization_not_found")
+	errConsentNotFound            = apperrors.NotFound("consent_not_found")
+	errNotificationNotFound       = apperrors.NotFound("notification_not_found")
+
+	errWebviewServerNameTaken      = apperrors.Conflict("webview_server_name_taken")
+	errUserDeliveryServerNameTaken = apperrors.Conflict("user_delivery_server_name_taken")
+	errConnectionExists            = apperrors.Conflict("connection_exists")
+	errConsentExists               = apperrors.Conflict("consent_exists")
+	errUsernameTaken               = apperrors.Conflict("username_taken")
+	errApiKeyRotationInProgress    = apperrors.Conflict("api_key_rotation_in_progress")
+
+	errInvalidStatus = apperrors.InvalidField("status", "oneof", map[string]string{"values": "active, inactive"})
+	errInvalidRole   = apperrors.InvalidField("role", "oneof", map[string]string{"values": auth.RoleOwner + ", " + auth.RoleOperator + ", " + auth.RoleViewer})
+
+	errInvalidCredentials  = apperrors.Unauthorized("invalid_credentials")
+	errInvalidRefreshToken = apperrors.Unauthorized("invalid_refresh_token")
+)
+
+// replaceError đổi lỗi chung của repository (ErrNotFound, ErrDuplicate) thành lỗi riêng của
+// entity, các lỗi khác giữ nguyên để HandleError xử lý
+func replaceError(err error, target error, replacement *apperrors.Error) error {
+	if errors.Is(err, target) {
+		return replacement
+	}
+	return err
+}
+
+func notFound(err error, replacement *apperrors.Error) error {
+	return replaceError(err, repositories.ErrNotFound, replacement)
+}
+
+func duplicate(err error, replacement *apperrors.Error) error {
+	return replaceError(err, repositories.ErrDuplicate, replacement)
+}
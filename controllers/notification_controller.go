@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"draft-notification/apperrors"
+	"draft-notification/auth"
+	"draft-notification/dtos"
+	"draft-notification/helpers"
+	"draft-notification/middlewares"
+	"draft-notification/queue"
+	"draft-notification/repositories"
+	"draft-notification/responses"
+	"errors"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NotificationController nhận notification từ webview server qua HTTP, xác thực bằng API key
+type NotificationController struct {
+	jobs          repositories.JobRepo
+	connections   repositories.ConnectionRepo
+	authenticator *auth.ApiKeyAuthenticator
+}
+
+func NewNotificationController(repos repositories.Repositories, authenticator *auth.ApiKeyAuthenticator) *NotificationController {
+	return &NotificationController{
+		jobs:          repos.Jobs,
+		connections:   repos.Connections,
+		authenticator: authenticator,
+	}
+}
+
+func (ctl *NotificationController) SendNotification(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	var request dtos.SendNotificationRequest
+	if err := c.Bind(&request); err != nil {
+		return helpers.HandleError(c, helpers.ErrInvalidJSON.Wrap(err))
+	}
+	if err := helpers.ValidateStruct(request); err != nil {
+		return helpers.HandleError(c, err)
+	}
+
+	identity, _ := middlewares.CurrentApiKey(c)
+	connection, err := ctl.authenticator.SendingConnection(ctx, identity, request.ConnectionId)
+	switch {
+	case errors.Is(err, auth.ErrConnectionRequired):
+		return helpers.HandleError(c, apperrors.InvalidField("connectionId", "required", nil))
+	case errors.Is(err, auth.ErrConnectionNotAllowed):
+		return helpers.HandleError(c, apperrors.Forbidden("connection_not_allowed"))
+	case err != nil:
+		return helpers.HandleError(c, err)
+	}
+
+	job, err := queue.Enqueue(ctx, ctl.jobs, connection.Id, request.Content)
+	if err != nil {
+		return helpers.HandleError(c, apperrors.Unavailable("queue_unavailable", err))
+	}
+
+	return helpers.HandleSuccess(c, responses.CreatedResponse{InsertedID: job.Id})
+}
+
+// GetNotification trả về trạng thái gửi của một notification thuộc connection của API key
+func (ctl *NotificationController) GetNotification(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	objId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return helpers.HandleError(c, errInvalidId)
+	}
+
+	job, err := ctl.jobs.FindById(ctx, objId)
+	if err != nil {
+		return helpers.HandleError(c, notFound(err, errNotificationNotFound))
+	}
+
+	// Notification của connection khác được coi như không tồn tại
+	identity, _ := middlewares.CurrentApiKey(c)
+	if identity.Connection != nil {
+		if job.ConnectionId != identity.Connection.Id {
+			return helpers.HandleError(c, errNotificationNotFound)
+		}
+	} else if connection, err := ctl.connections.FindById(ctx, job.ConnectionId); err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return helpers.HandleError(c, err)
+	} else if err != nil || connection.WebviewServerId != identity.WebviewServerId {
+		return helpers.HandleError(c, errNotificationNotFound)
+	}
+
+	return helpers.HandleSuccess(c, job)
+}
@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"draft-notification/helpers"
+	"draft-notification/repositories"
+
+	"github.com/labstack/echo/v4"
+)
+
+type OrganizationController struct {
+	organizations repositories.OrganizationRepo
+}
+
+func NewOrganizationController(repos repositories.Repositories) *OrganizationController {
+	return &OrganizationController{organizations: repos.Organizations}
+}
+
+// GetCurrentOrganization trả về organization của admin đang đăng nhập
+func (ctl *OrganizationController) GetCurrentOrganization(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
+	defer cancel()
+
+	organization, err := ctl.organizations.FindById(ctx, currentOrganization(c))
+	if err != nil {
+		return helpers.HandleError(c, notFound(err, errOrganizationNotFound))
+	}
+
+	return helpers.HandleSuccess(c, organization)
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"draft-notification/apperrors"
+	"draft-notification/repositories"
+	"draft-notification/responses"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Các field được sort của từng loại danh sách, thêm tiền tố "-" để sort giảm dần
+var (
+	serverSortFields     = []string{repositories.SortName, repositories.SortStatus, repositories.SortCreatedAt, repositories.SortUpdatedAt}
+	connectionSortFields = []string{repositories.SortStatus, repositories.SortCreatedAt, repositories.SortUpdatedAt}
+)
+
+// listQuery là tham số chung của danh sách server và connection. Có tham số cursor (để rỗng
+// cho trang đầu) là phân trang theo cursor, ngược lại theo limit/page như cũ.
+type listQuery struct {
+	Sort        repositories.Sort
+	After       *repositories.Cursor
+	CursorMode  bool
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Limit       int
+	Page        int
+}
+
+// parseListQuery đọc sort, createdFrom, createdTo (RFC 3339, createdTo không bao gồm), cursor, limit và page
+func parseListQuery(c echo.Context, sortFields []string) (listQuery, error) {
+	query := listQuery{Sort: repositories.DefaultSort}
+	query.Limit, query.Page = parsePagination(c)
+
+	if value := c.QueryParam("sort"); value != "" {
+		sort, ok := parseSort(value, sortFields)
+		if !ok {
+			return query, apperrors.InvalidField("sort", "oneof", map[string]string{"values": sortValues(sortFields)})
+		}
+		query.Sort = sort
+	}
+
+	var err error
+	if value := c.QueryParam("createdFrom"); value != "" {
+		if query.CreatedFrom, err = time.Parse(time.RFC3339, value); err != nil {
+			return query, apperrors.InvalidField("createdFrom", "rfc3339", nil)
+		}
+	}
+	if value := c.QueryParam("createdTo"); value != "" {
+		if query.CreatedTo, err = time.Parse(time.RFC3339, value); err != nil {
+			return query, apperrors.InvalidField("createdTo", "rfc3339", nil)
+		}
+	}
+
+	if !c.QueryParams().Has("cursor") {
+		return query, nil
+	}
+	query.CursorMode = true
+	query.Page = 0
+	if value := c.QueryParam("cursor"); value != "" {
+		sort, cursor, ok := decodeCursor(value, sortFields)
+		// Cursor chỉ dùng được với đúng thứ tự đã tạo ra nó
+		if !ok || (c.QueryParam("sort") != "" && sort != query.Sort) {
+			return query, apperrors.InvalidField("cursor", "cursor", nil)
+		}
+		query.Sort, query.After = sort, &cursor
+	}
+	return query, nil
+}
+
+// fetchLimit là limit truyền xuống repository, chế độ cursor lấy dư một phần tử để biết còn trang sau
+func (q listQuery) fetchLimit() int {
+	if q.CursorMode {
+		return q.Limit + 1
+	}
+	return q.Limit
+}
+
+// paginate cắt phần tử lấy dư, ghi Link header và trả về pagination của chế độ đang dùng
+func paginate[T any](c echo.Context, query listQuery, list []T, total int64, fields func(T) repositories.SortFields) ([]T, *responses.Pagination, *responses.CursorPagination) {
+	if !query.CursorMode {
+		setPageLinks(c, query, int(total))
+		return list, &responses.Pagination{Total: int(total), Limit: query.Limit, Page: query.Page}, nil
+	}
+
+	pagination := &responses.CursorPagination{Total: int(total), Limit: query.Limit}
+	links := []string{pageLink(c, "first", "cursor", "")}
+	if len(list) > query.Limit {
+		list = list[:query.Limit]
+		pagination.NextCursor = encodeCursor(query.Sort, fields(list[len(list)-1]).Cursor(query.Sort))
+		links = append(links, pageLink(c, "next", "cursor", pagination.NextCursor))
+	}
+	c.Response().Header().Set("Link", strings.Join(links, ", "))
+	return list, nil, pagination
+}
+
+// setPageLinks ghi Link header first, prev, next, last cho chế độ limit/page
+func setPageLinks(c echo.Context, query listQuery, total int) {
+	lastPage := 0
+	if total > 0 {
+		lastPage = (total - 1) / query.Limit
+	}
+	links := []string{pageLink(c, "first", "page", "0")}
+	if query.Page > 0 {
+		links = append(links, pageLink(c, "prev", "page", strconv.Itoa(min(query.Page-1, lastPage))))
+	}
+	if query.Page < lastPage {
+		links = append(links, pageLink(c, "next", "page", strconv.Itoa(query.Page+1)))
+	}
+	links = append(links, pageLink(c, "last", "page", strconv.Itoa(lastPage)))
+	c.Response().Header().Set("Link", strings.Join(links, ", "))
+}
+
+// pageLink tạo một link giữ nguyên các tham số của request, chỉ thay tham số key
+func pageLink(c echo.Context, rel, key, value string) string {
+	link := *c.Request().URL
+	params := link.Query()
+	params.Set(key, value)
+	link.RawQuery = params.Encode()
+	return "<" + link.RequestURI() + `>; rel="` + rel + `"`
+}
+
+func parseSort(value string, sortFields []string) (repositories.Sort, bool) {
+	field, desc := strings.CutPrefix(value, "-")
+	return repositories.Sort{Field: field, Desc: desc}, slices.Contains(sortFields, field)
+}
+
+func formatSort(sort repositories.Sort) string {
+	if sort.Desc {
+		return "-" + sort.Field
+	}
+	return sort.Field
+}
+
+func sortValues(sortFields []string) string {
+	values := make([]string, 0, 2*len(sortFields))
+	for _, field := range sortFields {
+		values = append(values, field, "-"+field)
+	}
+	return strings.Join(values, ", ")
+}
+
+// cursorToken là nội dung của cursor trước khi mã hoá base64, client chỉ coi cursor là chuỗi opaque
+type cursorToken struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	Id    string `json:"id"`
+}
+
+func encodeCursor(sort repositories.Sort, cursor repositories.Cursor) string {
+	token := cursorToken{Sort: formatSort(sort), Id: cursor.Id.Hex()}
+	switch value := cursor.Value.(type) {
+	case string:
+		token.Value = value
+	case time.Time:
+		token.Value = value.Format(time.RFC3339Nano)
+	}
+	raw, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(value string, sortFields []string) (repositories.Sort, repositories.Cursor, bool) {
+	var token cursorToken
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || json.Unmarshal(raw, &token) != nil {
+		return repositories.Sort{}, repositories.Cursor{}, false
+	}
+	sort, ok := parseSort(token.Sort, sortFields)
+	if !ok {
+		return sort, repositories.Cursor{}, false
+	}
+	id, err := primitive.ObjectIDFromHex(token.Id)
+	if err != nil {
+		return sort, repositories.Cursor{}, false
+	}
+
+	cursor := repositories.Cursor{Value: token.Value, Id: id}
+	if sort.Field == repositories.SortCreatedAt || sort.Field == repositories.SortUpdatedAt {
+		at, err := time.Parse(time.RFC3339Nano, token.Value)
+		if err != nil {
+			return sort, repositories.Cursor{}, false
+		}
+		cursor.Value = at
+	}
+	return sort, cursor, true
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"draft-notification/repositories"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	at := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+
+	tests := []struct {
+		name   string
+		sort   repositories.Sort
+		fields []string
+		cursor repositories.Cursor
+	}{
+		{"name", repositories.Sort{Field: repositories.SortName}, serverSortFields, repositories.Cursor{Value: "Server, \"A\"", Id: id}},
+		{"name desc", repositories.Sort{Field: repositories.SortName, Desc: true}, serverSortFields, repositories.Cursor{Value: "", Id: id}},
+		{"status", repositories.Sort{Field: repositories.SortStatus}, connectionSortFields, repositories.Cursor{Value: "active", Id: id}},
+		{"createdAt keeps nanoseconds", repositories.Sort{Field: repositories.SortCreatedAt}, connectionSortFields, repositories.Cursor{Value: at, Id: id}},
+		{"updatedAt desc", repositories.Sort{Field: repositories.SortUpdatedAt, Desc: true}, serverSortFields, repositories.Cursor{Value: at, Id: id}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sort, cursor, ok := decodeCursor(encodeCursor(tt.sort, tt.cursor), tt.fields)
+			if !ok {
+				t.Fatal("decodeCursor rejected its own cursor")
+			}
+			if sort != tt.sort || cursor.Id != tt.cursor.Id {
+				t.Fatalf("decoded %+v %+v, want %+v %+v", sort, cursor, tt.sort, tt.cursor)
+			}
+			if want, isTime := tt.cursor.Value.(time.Time); isTime {
+				if got, _ := cursor.Value.(time.Time); !got.Equal(want) {
+					t.Fatalf("value = %v, want %v", cursor.Value, want)
+				}
+			} else if cursor.Value != tt.cursor.Value {
+				t.Fatalf("value = %v, want %v", cursor.Value, tt.cursor.Value)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+		fields []string
+	}{
+		{"not base64", "!!!", serverSortFields},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"name","v":"a","id":"` + id + `"}`)), serverSortFields},
+		{"not json", encode("name"), serverSortFields},
+		{"unknown sort field", encode(`{"s":"password","v":"a","id":"` + id + `"}`), serverSortFields},
+		// Connection không sort theo name nên cursor của danh sách server không dùng được
+		{"sort field of other list", encode(`{"s":"name","v":"a","id":"` + id + `"}`), connectionSortFields},
+		{"missing id", encode(`{"s":"name","v":"a"}`), serverSortFields},
+		{"invalid id", encode(`{"s":"name","v":"a","id":"xyz"}`), serverSortFields},
+		{"invalid time", encode(`{"s":"-createdAt","v":"yesterday","id":"` + id + `"}`), serverSortFields},
+		{"empty time", encode(`{"s":"updatedAt","v":"","id":"` + id + `"}`), serverSortFields},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if sort, cursor, ok := decodeCursor(tt.cursor, tt.fields); ok {
+				t.Fatalf("decodeCursor = %+v %+v, want invalid", sort, cursor)
+			}
+		})
+	}
+}
@@ -1,277 +1,200 @@
 package controllers
 
 import (
-	"draft-notification/configs"
+	"context"
+	"draft-notification/audit"
 	"draft-notification/dtos"
 	"draft-notification/helpers"
 	"draft-notification/models"
+	"draft-notification/repositories"
 	"draft-notification/responses"
-	"fmt"
-	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var userDeliveryServerCollection *mongo.Collection = configs.GetCollection(configs.DB, "user-delivery-server")
-
-// Helper function for binding and validating request body
-func bindAndValidateUserDeliveryServer(c echo.Context, userDeliveryServer *models.UserDeliveryServer) error {
-	// Bind request body to userDeliveryServer struct
-	if err := c.Bind(userDeliveryServer); err != nil {
-		return err
-	}
+type UserDeliveryServerController struct {
+	userDeliveryServers repositories.UserDeliveryServerRepo
+	connections         repositories.ConnectionRepo
+	audit               *audit.Recorder
+}
 
-	// Validate the userDeliveryServer struct
-	if validationErr := helpers.Validate.Struct(userDeliveryServer); validationErr != nil {
-		return validationErr
+func NewUserDeliveryServerController(repos repositories.Repositories) *UserDeliveryServerController {
+	return &UserDeliveryServerController{
+		userDeliveryServers: repos.UserDeliveryServers,
+		connections:         repos.Connections,
+		audit:               audit.NewRecorder(repos.AuditEvents),
 	}
-
-	return nil
 }
 
-// Helper function to handle error response
-
-func CreateUserDeliveryServer(c echo.Context) error {
-	ctx, cancel := helpers.CreateContext()
+func (ctl *UserDeliveryServerController) CreateUserDeliveryServer(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
 	defer cancel()
 
 	var userDeliveryServer models.UserDeliveryServer
-	var findUserDeliveryServer models.UserDeliveryServer
 
 	// Bind and validate the request body
-	if err := bindAndValidateUserDeliveryServer(c, &userDeliveryServer); err != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, err.Error())
-	}
-
-	existNameErr := userDeliveryServerCollection.FindOne(ctx, bson.M{"name": userDeliveryServer.Name}).Decode(&findUserDeliveryServer)
-	if existNameErr == nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, "Tên user delivery server đã tồn tại")
+	if err := helpers.BindAndValidate(c, &userDeliveryServer); err != nil {
+		return helpers.HandleError(c, err)
 	}
 
 	// Create new user delivery server
 	newUserDeliveryServer := models.UserDeliveryServer{
-		Id:        primitive.NewObjectID(),
-		Name:      userDeliveryServer.Name,
-		Status:    "inactive",
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
+		Id:             primitive.NewObjectID(),
+		OrganizationId: currentOrganization(c),
+		Name:           userDeliveryServer.Name,
+		Status:         "inactive",
+		CreatedAt:      time.Now().UTC(),
+		UpdatedAt:      time.Now().UTC(),
 	}
 
-	result, err := userDeliveryServerCollection.InsertOne(ctx, newUserDeliveryServer)
+	err := ctl.userDeliveryServers.Create(ctx, newUserDeliveryServer)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+		return helpers.HandleError(c, duplicate(err, errUserDeliveryServerNameTaken))
 	}
 
-	return helpers.HandleSuccess(c, result)
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionCreate, models.AuditEntityUserDeliveryServer, newUserDeliveryServer.Id), nil, newUserDeliveryServer)
+
+	return helpers.HandleSuccess(c, responses.CreatedResponse{InsertedID: newUserDeliveryServer.Id})
 }
 
-func GetAllUserDeliveryServers(c echo.Context) error {
-	ctx, cancel := helpers.CreateContext()
+func (ctl *UserDeliveryServerController) GetAllUserDeliveryServers(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
 	defer cancel()
 
-	keyword := c.QueryParam("keyword")
-	status := c.QueryParam("status")
-	limitStr := c.QueryParam("limit")
-	pageStr := c.QueryParam("page")
-
-	limit := 10
-	page := 0
-
-	if limitStr != "" {
-		limitParsed, err := strconv.Atoi(limitStr)
-		if err == nil && limitParsed > 0 {
-			limit = limitParsed
-		}
-	}
-
-	if pageStr != "" {
-		parsedPage, err := strconv.Atoi(pageStr)
-		if err == nil && parsedPage > 0 {
-			page = parsedPage
-		}
-	}
-
-	var userDeliveryServers []models.UserDeliveryServer
-	filter := bson.M{}
-
-	if keyword != "" {
-		filter["name"] = bson.M{"$regex": keyword, "$options": "i"}
-	}
-	if status == "active" || status == "inactive" {
-		filter["status"] = status
-	}
-
-	results, err := userDeliveryServerCollection.Find(ctx, filter, options.Find().SetLimit(int64(limit)).SetSkip(int64(page*limit)))
+	query, err := parseListQuery(c, serverSortFields)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+		return helpers.HandleError(c, err)
 	}
-	defer results.Close(ctx)
-
-	if err := results.All(ctx, &userDeliveryServers); err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+	filter := repositories.ListFilter{
+		OrganizationId: currentOrganization(c),
+		Keyword:        c.QueryParam("keyword"),
+		Status:         parseStatus(c.QueryParam("status")),
+		CreatedFrom:    query.CreatedFrom,
+		CreatedTo:      query.CreatedTo,
+		Sort:           query.Sort,
+		After:          query.After,
+		Limit:          query.fetchLimit(),
+		Page:           query.Page,
 	}
 
-	if len(userDeliveryServers) == 0 {
-		userDeliveryServers = []models.UserDeliveryServer{}
-	}
-
-	// Count total documents matching the filter
-	totalCount, err := userDeliveryServerCollection.CountDocuments(ctx, filter)
+	userDeliveryServers, totalCount, err := ctl.userDeliveryServers.List(ctx, filter)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+		return helpers.HandleError(c, err)
 	}
 
 	// Prepare the response data
-	data := responses.GetAllUserDeliveryServerResponse{
-		List: userDeliveryServers,
-		Pagination: responses.Pagination{
-			Total: int(totalCount),
-			Limit: limit,
-			Page:  page,
-		}}
+	data := responses.GetAllUserDeliveryServerResponse{}
+	data.List, data.Pagination, data.Cursor = paginate(c, query, userDeliveryServers, totalCount, repositories.UserDeliveryServerSortFields)
 	return helpers.HandleSuccess(c, data)
 }
 
-func GetUserDeliveryServerDetail(c echo.Context) error {
-	ctx, cancel := helpers.CreateContext()
+func (ctl *UserDeliveryServerController) GetUserDeliveryServerDetail(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
 	defer cancel()
 
 	id := c.Param("id")
-	objId, _ := primitive.ObjectIDFromHex(id)
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return helpers.HandleError(c, errInvalidId)
+	}
 
-	var userDeliveryServer models.UserDeliveryServer
-	err := userDeliveryServerCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&userDeliveryServer)
+	userDeliveryServer, err := ctl.findUserDeliveryServer(ctx, c, objId)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+		return helpers.HandleError(c, notFound(err, errUserDeliveryServerNotFound))
 	}
 
 	return helpers.HandleSuccess(c, userDeliveryServer)
 }
 
-func UpdateUserDeliveryServer(c echo.Context) error {
-	ctx, cancel := helpers.CreateContext()
+func (ctl *UserDeliveryServerController) UpdateUserDeliveryServer(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
 	defer cancel()
 
 	id := c.Param("id")
 	objId, err := primitive.ObjectIDFromHex(id)
 
 	if err != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, "Id không đúng định dạng")
+		return helpers.HandleError(c, errInvalidId)
 	}
 
 	var userDeliveryServer models.UserDeliveryServer
-	var findUserDeliveryServer models.UserDeliveryServer
 
 	// Bind and validate the request body
-	if err := bindAndValidateUserDeliveryServer(c, &userDeliveryServer); err != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, err.Error())
+	if err := helpers.BindAndValidate(c, &userDeliveryServer); err != nil {
+		return helpers.HandleError(c, err)
 	}
 
-	fmt.Println(userDeliveryServer)
-
-	existIdErr := userDeliveryServerCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&findUserDeliveryServer)
-	if existIdErr != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, "ID không tồn tại trong DB")
+	findUserDeliveryServer, err := ctl.findUserDeliveryServer(ctx, c, objId)
+	if err != nil {
+		return helpers.HandleError(c, notFound(err, errUserDeliveryServerNotFound))
 	}
 	if findUserDeliveryServer.Name == userDeliveryServer.Name {
-		return helpers.HandleSuccess(c, "thành công")
+		return helpers.HandleDone(c)
 	}
 
-	fmt.Println(userDeliveryServer.Name)
-
-	existNameErr := userDeliveryServerCollection.FindOne(ctx, bson.M{"name": userDeliveryServer.Name}).Decode(&findUserDeliveryServer)
-	if existNameErr == nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, "Tên user delivery server đã tồn tại")
-	}
-
-	update := bson.M{"name": userDeliveryServer.Name}
-	result, err := userDeliveryServerCollection.UpdateOne(ctx, bson.M{"_id": objId}, bson.M{"$set": update})
+	updatedUserDeliveryServer, err := ctl.userDeliveryServers.UpdateName(ctx, objId, userDeliveryServer.Name)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+		return helpers.HandleError(c, duplicate(err, errUserDeliveryServerNameTaken))
 	}
 
-	// Retrieve updated user delivery server details
-	var updatedUserDeliveryServer models.UserDeliveryServer
-	if result.MatchedCount == 1 {
-		err := userDeliveryServerCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&updatedUserDeliveryServer)
-		if err != nil {
-			return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
-		}
-	}
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionUpdate, models.AuditEntityUserDeliveryServer, objId), findUserDeliveryServer, updatedUserDeliveryServer)
 
 	return helpers.HandleSuccess(c, updatedUserDeliveryServer)
 }
 
-func ChangeStatusUserDeliveryServer(c echo.Context) error {
-	ctx, cancel := helpers.CreateContext()
+func (ctl *UserDeliveryServerController) ChangeStatusUserDeliveryServer(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
 	defer cancel()
 
 	id := c.Param("id")
-	objId, _ := primitive.ObjectIDFromHex(id)
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return helpers.HandleError(c, errInvalidId)
+	}
 
-	var userDeliveryServer models.UserDeliveryServer
-	err := userDeliveryServerCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&userDeliveryServer)
+	userDeliveryServer, err := ctl.findUserDeliveryServer(ctx, c, objId)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, "Id ko tồn tại trong DB")
+		return helpers.HandleError(c, notFound(err, errUserDeliveryServerNotFound))
 	}
 
 	var request dtos.ChangeStatusUserDeliveryServerRequest
 	if err := c.Bind(&request); err != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, "Invalid JSON format")
+		return helpers.HandleError(c, helpers.ErrInvalidJSON.Wrap(err))
 	}
 
 	if request.Status != "active" && request.Status != "inactive" {
-		return helpers.HandleError(c, http.StatusBadRequest, "Invalid status value")
+		return helpers.HandleError(c, errInvalidStatus)
 	}
 
 	if request.Status == userDeliveryServer.Status {
-		return helpers.HandleSuccess(c, "thành công")
+		return helpers.HandleDone(c)
 	}
 
-	update := bson.M{"status": request.Status}
-	result, err := userDeliveryServerCollection.UpdateOne(ctx, bson.M{"_id": objId}, bson.M{"$set": update})
+	updatedUserDeliveryServer, err := ctl.userDeliveryServers.UpdateStatus(ctx, objId, request.Status)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+		return helpers.HandleError(c, err)
 	}
 
-	if request.Status == "inactive" {
-		results, err := connectionCollection.Find(ctx, bson.M{"userdeliveryserverid": userDeliveryServer.Id})
-
-		if err != nil {
-			return helpers.HandleError(c, http.StatusInternalServerError, "tìm thông tin connection bị lỗi")
-		}
-
-		for results.Next(ctx) {
-			var connection models.Connection
-
-			if err := results.Decode(&connection); err != nil {
-				return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
-			}
-
-			if connection.Status == "active" {
-				_, err := connectionCollection.UpdateOne(ctx, bson.M{"_id": connection.Id}, bson.M{"$set": bson.M{"status": "inactive"}})
-				if err != nil {
-					return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
-				}
-			}
-		}
+	event := ctl.audit.Record(ctx, auditEvent(c, models.AuditActionChangeStatus, models.AuditEntityUserDeliveryServer, objId), userDeliveryServer, updatedUserDeliveryServer)
 
-	}
-
-	// Retrieve updated user delivery server details
-	var updatedUserDeliveryServer models.UserDeliveryServer
-	if result.MatchedCount == 1 {
-		err := userDeliveryServerCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&updatedUserDeliveryServer)
+	if request.Status == "inactive" {
+		deactivated, err := ctl.connections.DeactivateByUserDeliveryServer(ctx, userDeliveryServer.Id)
 		if err != nil {
-			return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+			return helpers.HandleError(c, err)
 		}
+		recordDeactivatedConnections(ctx, ctl.audit, event, deactivated)
 	}
 
 	return helpers.HandleSuccess(c, updatedUserDeliveryServer)
 }
+
+// findUserDeliveryServer chỉ trả về user delivery server thuộc organization của admin hiện tại
+func (ctl *UserDeliveryServerController) findUserDeliveryServer(ctx context.Context, c echo.Context, id primitive.ObjectID) (models.UserDeliveryServer, error) {
+	server, err := ctl.userDeliveryServers.FindById(ctx, id)
+	if err == nil && server.OrganizationId != currentOrganization(c) {
+		return models.UserDeliveryServer{}, repositories.ErrNotFound
+	}
+	return server, err
+}
@@ -1,275 +1,200 @@
 package controllers
 
 import (
-	"draft-notification/configs"
+	"context"
+	"draft-notification/audit"
 	"draft-notification/dtos"
 	"draft-notification/helpers"
 	"draft-notification/models"
+	"draft-notification/repositories"
 	"draft-notification/responses"
-	"fmt"
-	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var webviewServerCollection *mongo.Collection = configs.GetCollection(configs.DB, "webview-server")
-
-// Helper function for binding and validating request body
-func bindAndValidateWebviewServer(c echo.Context, webviewServer *models.WebviewServer) error {
-	// Bind request body to webviewServer struct
-	if err := c.Bind(webviewServer); err != nil {
-		return err
-	}
+type WebviewServerController struct {
+	webviewServers repositories.WebviewServerRepo
+	connections    repositories.ConnectionRepo
+	audit          *audit.Recorder
+}
 
-	// Validate the webviewServer struct
-	if validationErr := helpers.Validate.Struct(webviewServer); validationErr != nil {
-		return validationErr
+func NewWebviewServerController(repos repositories.Repositories) *WebviewServerController {
+	return &WebviewServerController{
+		webviewServers: repos.WebviewServers,
+		connections:    repos.Connections,
+		audit:          audit.NewRecorder(repos.AuditEvents),
 	}
-
-	return nil
 }
 
-func CreateWebviewServer(c echo.Context) error {
-	ctx, cancel := helpers.CreateContext()
+func (ctl *WebviewServerController) CreateWebviewServer(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
 	defer cancel()
 
 	var webviewServer models.WebviewServer
-	var findWebviewServer models.WebviewServer
 
 	// Bind and validate the request body
-	if err := bindAndValidateWebviewServer(c, &webviewServer); err != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, err.Error())
-	}
-
-	existNameErr := webviewServerCollection.FindOne(ctx, bson.M{"name": webviewServer.Name}).Decode(&findWebviewServer)
-	if existNameErr == nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, "Tên webview server đã tồn tại")
+	if err := helpers.BindAndValidate(c, &webviewServer); err != nil {
+		return helpers.HandleError(c, err)
 	}
 
 	// Create new webview server
 	newWebviewServer := models.WebviewServer{
-		Id:        primitive.NewObjectID(),
-		Name:      webviewServer.Name,
-		Status:    "inactive",
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
+		Id:             primitive.NewObjectID(),
+		OrganizationId: currentOrganization(c),
+		Name:           webviewServer.Name,
+		Status:         "inactive",
+		CreatedAt:      time.Now().UTC(),
+		UpdatedAt:      time.Now().UTC(),
 	}
 
-	result, err := webviewServerCollection.InsertOne(ctx, newWebviewServer)
+	err := ctl.webviewServers.Create(ctx, newWebviewServer)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+		return helpers.HandleError(c, duplicate(err, errWebviewServerNameTaken))
 	}
 
-	return helpers.HandleSuccess(c, result)
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionCreate, models.AuditEntityWebviewServer, newWebviewServer.Id), nil, newWebviewServer)
+
+	return helpers.HandleSuccess(c, responses.CreatedResponse{InsertedID: newWebviewServer.Id})
 }
 
-func GetAllWebviewServers(c echo.Context) error {
-	ctx, cancel := helpers.CreateContext()
+func (ctl *WebviewServerController) GetAllWebviewServers(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
 	defer cancel()
 
-	keyword := c.QueryParam("keyword")
-	status := c.QueryParam("status")
-	limitStr := c.QueryParam("limit")
-	pageStr := c.QueryParam("page")
-
-	limit := 10
-	page := 0
-
-	if limitStr != "" {
-		limitParsed, err := strconv.Atoi(limitStr)
-		if err == nil && limitParsed > 0 {
-			limit = limitParsed
-		}
-	}
-
-	if pageStr != "" {
-		parsedPage, err := strconv.Atoi(pageStr)
-		if err == nil && parsedPage > 0 {
-			page = parsedPage
-		}
-	}
-
-	var webviewServers []models.WebviewServer
-	filter := bson.M{}
-
-	if keyword != "" {
-		filter["name"] = bson.M{"$regex": keyword, "$options": "i"}
-	}
-	if status == "active" || status == "inactive" {
-		filter["status"] = status
-	}
-
-	results, err := webviewServerCollection.Find(ctx, filter, options.Find().SetLimit(int64(limit)).SetSkip(int64(page*limit)))
+	query, err := parseListQuery(c, serverSortFields)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+		return helpers.HandleError(c, err)
 	}
-	defer results.Close(ctx)
-
-	if err := results.All(ctx, &webviewServers); err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+	filter := repositories.ListFilter{
+		OrganizationId: currentOrganization(c),
+		Keyword:        c.QueryParam("keyword"),
+		Status:         parseStatus(c.QueryParam("status")),
+		CreatedFrom:    query.CreatedFrom,
+		CreatedTo:      query.CreatedTo,
+		Sort:           query.Sort,
+		After:          query.After,
+		Limit:          query.fetchLimit(),
+		Page:           query.Page,
 	}
 
-	if len(webviewServers) == 0 {
-		webviewServers = []models.WebviewServer{}
-	}
-
-	// Count total documents matching the filter
-	totalCount, err := webviewServerCollection.CountDocuments(ctx, filter)
+	webviewServers, totalCount, err := ctl.webviewServers.List(ctx, filter)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+		return helpers.HandleError(c, err)
 	}
 
 	// Prepare the response data
-	data := responses.GetAllWebviewServerResponse{
-		List: webviewServers,
-		Pagination: responses.Pagination{
-			Total: int(totalCount),
-			Limit: limit,
-			Page:  page,
-		}}
+	data := responses.GetAllWebviewServerResponse{}
+	data.List, data.Pagination, data.Cursor = paginate(c, query, webviewServers, totalCount, repositories.WebviewServerSortFields)
 	return helpers.HandleSuccess(c, data)
 }
 
-func GetWebviewServerDetail(c echo.Context) error {
-	ctx, cancel := helpers.CreateContext()
+func (ctl *WebviewServerController) GetWebviewServerDetail(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
 	defer cancel()
 
 	id := c.Param("id")
-	objId, _ := primitive.ObjectIDFromHex(id)
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return helpers.HandleError(c, errInvalidId)
+	}
 
-	var webviewServer models.WebviewServer
-	err := webviewServerCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&webviewServer)
+	webviewServer, err := ctl.findWebviewServer(ctx, c, objId)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+		return helpers.HandleError(c, notFound(err, errWebviewServerNotFound))
 	}
 
 	return helpers.HandleSuccess(c, webviewServer)
 }
 
-func UpdateWebviewServer(c echo.Context) error {
-	ctx, cancel := helpers.CreateContext()
+func (ctl *WebviewServerController) UpdateWebviewServer(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
 	defer cancel()
 
 	id := c.Param("id")
 	objId, err := primitive.ObjectIDFromHex(id)
 
 	if err != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, "Id không đúng định dạng")
+		return helpers.HandleError(c, errInvalidId)
 	}
 
 	var webviewServer models.WebviewServer
-	var findWebviewServer models.WebviewServer
 
 	// Bind and validate the request body
-	if err := bindAndValidateWebviewServer(c, &webviewServer); err != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, err.Error())
+	if err := helpers.BindAndValidate(c, &webviewServer); err != nil {
+		return helpers.HandleError(c, err)
 	}
 
-	fmt.Println(webviewServer)
-
-	existIdErr := webviewServerCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&findWebviewServer)
-	if existIdErr != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, "ID không tồn tại trong DB")
+	findWebviewServer, err := ctl.findWebviewServer(ctx, c, objId)
+	if err != nil {
+		return helpers.HandleError(c, notFound(err, errWebviewServerNotFound))
 	}
 	if findWebviewServer.Name == webviewServer.Name {
-		return helpers.HandleSuccess(c, "thành công")
+		return helpers.HandleDone(c)
 	}
 
-	fmt.Println(webviewServer.Name)
-
-	existNameErr := webviewServerCollection.FindOne(ctx, bson.M{"name": webviewServer.Name}).Decode(&findWebviewServer)
-	if existNameErr == nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, "Tên webview server đã tồn tại")
-	}
-
-	update := bson.M{"name": webviewServer.Name}
-	result, err := webviewServerCollection.UpdateOne(ctx, bson.M{"_id": objId}, bson.M{"$set": update})
+	updatedWebviewServer, err := ctl.webviewServers.UpdateName(ctx, objId, webviewServer.Name)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+		return helpers.HandleError(c, duplicate(err, errWebviewServerNameTaken))
 	}
 
-	// Retrieve updated webview server details
-	var updatedWebviewServer models.WebviewServer
-	if result.MatchedCount == 1 {
-		err := webviewServerCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&updatedWebviewServer)
-		if err != nil {
-			return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
-		}
-	}
+	ctl.audit.Record(ctx, auditEvent(c, models.AuditActionUpdate, models.AuditEntityWebviewServer, objId), findWebviewServer, updatedWebviewServer)
 
 	return helpers.HandleSuccess(c, updatedWebviewServer)
 }
 
-func ChangeStatusWebviewServer(c echo.Context) error {
-	ctx, cancel := helpers.CreateContext()
+func (ctl *WebviewServerController) ChangeStatusWebviewServer(c echo.Context) error {
+	ctx, cancel := helpers.CreateRequestContext(c)
 	defer cancel()
 
 	id := c.Param("id")
-	objId, _ := primitive.ObjectIDFromHex(id)
+	objId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return helpers.HandleError(c, errInvalidId)
+	}
 
-	var webviewServer models.WebviewServer
-	err := webviewServerCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&webviewServer)
+	webviewServer, err := ctl.findWebviewServer(ctx, c, objId)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, "Id ko tồn tại trong DB")
+		return helpers.HandleError(c, notFound(err, errWebviewServerNotFound))
 	}
 
 	var request dtos.ChangeStatusWebviewServerRequest
 	if err := c.Bind(&request); err != nil {
-		return helpers.HandleError(c, http.StatusBadRequest, "Invalid JSON format")
+		return helpers.HandleError(c, helpers.ErrInvalidJSON.Wrap(err))
 	}
 
 	if request.Status != "active" && request.Status != "inactive" {
-		return helpers.HandleError(c, http.StatusBadRequest, "Invalid status value")
+		return helpers.HandleError(c, errInvalidStatus)
 	}
 
 	if request.Status == webviewServer.Status {
-		return helpers.HandleSuccess(c, "thành công")
+		return helpers.HandleDone(c)
 	}
 
-	update := bson.M{"status": request.Status}
-	result, err := webviewServerCollection.UpdateOne(ctx, bson.M{"_id": objId}, bson.M{"$set": update})
+	updatedWebviewServer, err := ctl.webviewServers.UpdateStatus(ctx, objId, request.Status)
 	if err != nil {
-		return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+		return helpers.HandleError(c, err)
 	}
 
-	if request.Status == "inactive" {
-		results, err := connectionCollection.Find(ctx, bson.M{"webviewserverid": webviewServer.Id})
-
-		if err != nil {
-			return helpers.HandleError(c, http.StatusInternalServerError, "tìm thông tin connection bị lỗi")
-		}
-
-		for results.Next(ctx) {
-			var connection models.Connection
-
-			if err := results.Decode(&connection); err != nil {
-				return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
-			}
-
-			if connection.Status == "active" {
-				_, err := connectionCollection.UpdateOne(ctx, bson.M{"_id": connection.Id}, bson.M{"$set": bson.M{"status": "inactive"}})
-				if err != nil {
-					return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
-				}
-			}
-		}
+	event := ctl.audit.Record(ctx, auditEvent(c, models.AuditActionChangeStatus, models.AuditEntityWebviewServer, objId), webviewServer, updatedWebviewServer)
 
-	}
-
-	// Retrieve updated webview server details
-	var updatedWebviewServer models.WebviewServer
-	if result.MatchedCount == 1 {
-		err := webviewServerCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&updatedWebviewServer)
+	if request.Status == "inactive" {
+		deactivated, err := ctl.connections.DeactivateByWebviewServer(ctx, webviewServer.Id)
 		if err != nil {
-			return helpers.HandleError(c, http.StatusInternalServerError, err.Error())
+			return helpers.HandleError(c, err)
 		}
+		recordDeactivatedConnections(ctx, ctl.audit, event, deactivated)
 	}
 
 	return helpers.HandleSuccess(c, updatedWebviewServer)
 }
+
+// findWebviewServer chỉ trả về webview server thuộc organization của admin hiện tại
+func (ctl *WebviewServerController) findWebviewServer(ctx context.Context, c echo.Context, id primitive.ObjectID) (models.WebviewServer, error) {
+	server, err := ctl.webviewServers.FindById(ctx, id)
+	if err == nil && server.OrganizationId != currentOrganization(c) {
+		return models.WebviewServer{}, repositories.ErrNotFound
+	}
+	return server, err
+}
@@ -0,0 +1,11 @@
+package dtos
+
+type CreateAdminRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+	Role     string `json:"role" validate:"required"`
+}
+
+type ChangeRoleAdminRequest struct {
+	Role string `json:"role"`
+}
@@ -0,0 +1,12 @@
+package dtos
+
+import "time"
+
+type CreateApiKeyRequest struct {
+	Name   string   `json:"name" validate:"required"`
+	Scopes []string `json:"scopes" validate:"required,min=1"`
+	// Side chỉ dùng cho key của connection, mặc định là webviewServer
+	Side       string     `json:"side"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	AllowedIps []string   `json:"allowedIps"`
+}
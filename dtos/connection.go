@@ -7,3 +7,9 @@ type ChangeStatusConnectionRequest struct {
 type UpdateConnectionWebhookUrlRequest struct {
 	UserDeliveryServerWebHookUrl string `json:"userDeliveryServerWebHookUrl"`
 }
+
+// Overlap dạng "1h", "30m"; bỏ trống thì dùng apiKeys.rotationOverlap trong config,
+// chỉ được ngắn hơn giá trị đó (ví dụ "0s" khi key bị lộ)
+type RotateApiKeyRequest struct {
+	Overlap string `json:"overlap"`
+}
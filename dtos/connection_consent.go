@@ -0,0 +1,7 @@
+package dtos
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type CreateConnectionConsentRequest struct {
+	OrganizationId primitive.ObjectID `json:"organizationId" validate:"required"`
+}
@@ -0,0 +1,9 @@
+package dtos
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// ConnectionId bắt buộc khi dùng API key của webview server
+type SendNotificationRequest struct {
+	Content      string             `json:"content" validate:"required"`
+	ConnectionId primitive.ObjectID `json:"connectionId"`
+}
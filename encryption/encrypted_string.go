@@ -0,0 +1,73 @@
+package encryption
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// EncryptedString là string được mã hoá khi ghi xuống storage và giải mã khi đọc lên.
+// Giá trị đã mã hoá được lưu dạng embedded document, string thường là dữ liệu cũ chưa mã hoá,
+// nên hai dạng không bao giờ bị nhầm với nhau. JSON vẫn là string plaintext.
+type EncryptedString string
+
+func (s EncryptedString) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if s == "" || !Enabled() {
+		return bson.MarshalValue(string(s))
+	}
+
+	env, err := seal(string(s))
+	if err != nil {
+		return 0, nil, err
+	}
+	return bson.MarshalValue(env)
+}
+
+func (s *EncryptedString) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+
+	switch t {
+	case bson.TypeString:
+		*s = EncryptedString(raw.StringValue())
+		return nil
+	case bson.TypeNull, bson.TypeUndefined:
+		*s = ""
+		return nil
+	case bson.TypeEmbeddedDocument:
+		var env envelope
+		if err := raw.Unmarshal(&env); err != nil {
+			return err
+		}
+		plaintext, err := open(env)
+		if err != nil {
+			return err
+		}
+		*s = EncryptedString(plaintext)
+		return nil
+	default:
+		return fmt.Errorf("cannot decode %s into EncryptedString", t)
+	}
+}
+
+// NeedsReEncrypt cho biết giá trị BSON đang lưu là plaintext hoặc được mã hoá bằng khoá
+// khác primary, tức là cần được ghi lại bởi lệnh reencrypt
+func NeedsReEncrypt(value bson.RawValue) bool {
+	keyring := activeKeyring()
+	if keyring == nil {
+		return false
+	}
+
+	switch value.Type {
+	case bson.TypeString:
+		return value.StringValue() != ""
+	case bson.TypeEmbeddedDocument:
+		var env envelope
+		if err := value.Unmarshal(&env); err != nil {
+			return false
+		}
+		return env.KeyId != keyring.Primary
+	default:
+		return false
+	}
+}
@@ -0,0 +1,137 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrNoKeyring  = errors.New("encryption keyring is not configured")
+	ErrUnknownKey = errors.New("encryption key not found in keyring")
+)
+
+var (
+	mu      sync.RWMutex
+	current *Keyring
+)
+
+// Use đặt keyring dùng để mã hoá/giải mã các field EncryptedString, nil thì tắt mã hoá
+// (dữ liệu mới được lưu plaintext, dữ liệu đã mã hoá không đọc được)
+func Use(keyring *Keyring) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = keyring
+}
+
+func activeKeyring() *Keyring {
+	mu.RLock()
+	defer mu.RUnlock()
+	if current == nil || len(current.keys) == 0 {
+		return nil
+	}
+	return current
+}
+
+// Enabled cho biết dữ liệu mới có được mã hoá không
+func Enabled() bool {
+	return activeKeyring() != nil
+}
+
+// PrimaryKeyId trả về key ID đang dùng để mã hoá dữ liệu mới
+func PrimaryKeyId() string {
+	if keyring := activeKeyring(); keyring != nil {
+		return keyring.Primary
+	}
+	return ""
+}
+
+// envelope là dữ liệu đã mã hoá: data key ngẫu nhiên cho mỗi giá trị (DEK) được mã hoá
+// bằng khoá KeyId của keyring, dữ liệu được mã hoá bằng DEK. Cả hai dùng AES-256-GCM.
+type envelope struct {
+	KeyId      string `bson:"k"`
+	WrappedKey []byte `bson:"d"`
+	Ciphertext []byte `bson:"c"`
+}
+
+func seal(plaintext string) (envelope, error) {
+	keyring := activeKeyring()
+	if keyring == nil {
+		return envelope{}, ErrNoKeyring
+	}
+	kek, _ := keyring.key(keyring.Primary)
+
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return envelope{}, err
+	}
+
+	// Key ID được dùng làm associated data để envelope không thể bị gắn sang khoá khác
+	wrappedKey, err := gcmSeal(kek, dek, []byte(keyring.Primary))
+	if err != nil {
+		return envelope{}, err
+	}
+	ciphertext, err := gcmSeal(dek, []byte(plaintext), nil)
+	if err != nil {
+		return envelope{}, err
+	}
+
+	return envelope{KeyId: keyring.Primary, WrappedKey: wrappedKey, Ciphertext: ciphertext}, nil
+}
+
+func open(env envelope) (string, error) {
+	keyring := activeKeyring()
+	if keyring == nil {
+		return "", ErrNoKeyring
+	}
+	kek, ok := keyring.key(env.KeyId)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, env.KeyId)
+	}
+
+	dek, err := gcmOpen(kek, env.WrappedKey, []byte(env.KeyId))
+	if err != nil {
+		return "", fmt.Errorf("unwrap data key: %w", err)
+	}
+	plaintext, err := gcmOpen(dek, env.Ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// gcmSeal trả về nonce || ciphertext
+func gcmSeal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func gcmOpen(key, sealed, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
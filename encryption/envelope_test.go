@@ -0,0 +1,241 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type testDoc struct {
+	Value EncryptedString `bson:"value"`
+}
+
+// useTestKeyring tạo keyring với các key ID cho trước (key đầu tiên là primary) và dùng nó cho tới hết test
+func useTestKeyring(t *testing.T, ids ...string) *Keyring {
+	t.Helper()
+	keyring := &Keyring{Primary: ids[0]}
+	for _, id := range ids {
+		key := make([]byte, keySize)
+		if _, err := rand.Read(key); err != nil {
+			t.Fatal(err)
+		}
+		keyring.Keys = append(keyring.Keys, KeyringKey{Id: id, Key: base64.StdEncoding.EncodeToString(key)})
+	}
+	if err := keyring.index(); err != nil {
+		t.Fatal(err)
+	}
+	Use(keyring)
+	t.Cleanup(func() { Use(nil) })
+	return keyring
+}
+
+func marshalValue(t *testing.T, value EncryptedString) bson.Raw {
+	t.Helper()
+	raw, err := bson.Marshal(testDoc{Value: value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestEncryptedString(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyring   bool
+		value     EncryptedString
+		encrypted bool
+	}{
+		{"encrypted", true, "webhook-secret", true},
+		{"empty stays empty", true, "", false},
+		{"no keyring stores plaintext", false, "webhook-secret", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.keyring {
+				useTestKeyring(t, "k1")
+			}
+			raw := marshalValue(t, tt.value)
+			value := raw.Lookup("value")
+			if (value.Type == bson.TypeEmbeddedDocument) != tt.encrypted {
+				t.Fatalf("stored as %s, want encrypted %v", value.Type, tt.encrypted)
+			}
+			if tt.encrypted && bytes.Contains(raw, []byte(tt.value)) {
+				t.Fatal("plaintext is stored in the envelope")
+			}
+
+			var doc testDoc
+			if err := bson.Unmarshal(raw, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if doc.Value != tt.value {
+				t.Fatalf("round trip = %q, want %q", doc.Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	useTestKeyring(t, "k2", "k1")
+	sealed, err := seal("webhook-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealed.KeyId != "k2" {
+		t.Fatalf("sealed with %s, want primary k2", sealed.KeyId)
+	}
+	other, err := seal("other-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flip := func(data []byte) []byte {
+		data = bytes.Clone(data)
+		data[len(data)-1] ^= 1
+		return data
+	}
+
+	tests := []struct {
+		name   string
+		modify func(env *envelope)
+		opened bool
+		err    error
+	}{
+		{"valid", func(env *envelope) {}, true, nil},
+		{"tampered ciphertext", func(env *envelope) { env.Ciphertext = flip(env.Ciphertext) }, false, nil},
+		{"tampered wrapped key", func(env *envelope) { env.WrappedKey = flip(env.WrappedKey) }, false, nil},
+		{"wrapped key of other value", func(env *envelope) { env.WrappedKey = other.WrappedKey }, false, nil},
+		{"truncated", func(env *envelope) { env.Ciphertext = env.Ciphertext[:4] }, false, nil},
+		// Key ID là associated data nên đổi sang khoá khác trong keyring cũng không mở được
+		{"other key id", func(env *envelope) { env.KeyId = "k1" }, false, nil},
+		{"unknown key id", func(env *envelope) { env.KeyId = "k0" }, false, ErrUnknownKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := sealed
+			tt.modify(&env)
+			plaintext, err := open(env)
+			if tt.opened {
+				if err != nil || plaintext != "webhook-secret" {
+					t.Fatalf("open = %q, %v", plaintext, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("open = %q, want error", plaintext)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("open = %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	Use(nil)
+	if _, err := open(sealed); !errors.Is(err, ErrNoKeyring) {
+		t.Fatalf("open without keyring = %v", err)
+	}
+}
+
+func TestNeedsReEncrypt(t *testing.T) {
+	keyring := useTestKeyring(t, "k1", "k2")
+	oldKey := marshalValue(t, "secret").Lookup("value")
+	keyring.Primary = "k2"
+	primaryKey := marshalValue(t, "secret").Lookup("value")
+	plaintext := bson.RawValue{Type: bson.TypeString, Value: stringValue("secret")}
+	empty := bson.RawValue{Type: bson.TypeString, Value: stringValue("")}
+
+	tests := []struct {
+		name  string
+		value bson.RawValue
+		want  bool
+	}{
+		{"plaintext", plaintext, true},
+		{"old key", oldKey, true},
+		{"primary key", primaryKey, false},
+		{"empty", empty, false},
+		{"null", bson.RawValue{Type: bson.TypeNull}, false},
+	}
+	for _, tt := range tests {
+		if got := NeedsReEncrypt(tt.value); got != tt.want {
+			t.Errorf("%s: NeedsReEncrypt = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	Use(nil)
+	if NeedsReEncrypt(plaintext) {
+		t.Error("NeedsReEncrypt without keyring = true")
+	}
+}
+
+func stringValue(value string) []byte {
+	_, data, _ := bson.MarshalValue(value)
+	return data
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+	keyring := &Keyring{}
+	if err := keyring.index(); err != nil {
+		t.Fatal(err)
+	}
+	id, err := keyring.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "keyring.yaml")
+	if err := keyring.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Primary != id || !bytes.Equal(loaded.keys[id], keyring.keys[id]) {
+		t.Fatalf("loaded keyring = %+v", loaded)
+	}
+
+	key := base64.StdEncoding.EncodeToString(make([]byte, keySize))
+	invalid := []Keyring{
+		{Primary: "k2", Keys: []KeyringKey{{Id: "k1", Key: key}}},
+		{Primary: "k1", Keys: []KeyringKey{{Id: "k1", Key: key}, {Id: "k1", Key: key}}},
+		{Primary: "k1", Keys: []KeyringKey{{Id: "k1", Key: base64.StdEncoding.EncodeToString(make([]byte, 16))}}},
+		{Primary: "", Keys: []KeyringKey{{Id: "", Key: key}}},
+	}
+	for i, keyring := range invalid {
+		path := filepath.Join(dir, "invalid.yaml")
+		if err := keyring.Save(path); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadKeyring(path); err == nil {
+			t.Errorf("invalid keyring %d loaded", i)
+		}
+	}
+}
+
+func TestGenerateKeyUniqueIds(t *testing.T) {
+	keyring := &Keyring{}
+	ids := map[string]bool{}
+	// Các khoá tạo liên tiếp trong cùng một giây vẫn có ID khác nhau
+	for i := 0; i < 5; i++ {
+		id, err := keyring.GenerateKey()
+		if err != nil {
+			t.Fatalf("GenerateKey %d: %v", i, err)
+		}
+		if ids[id] {
+			t.Fatalf("GenerateKey returned duplicate id %s", id)
+		}
+		ids[id] = true
+		if keyring.Primary != id {
+			t.Fatalf("primary = %s, want %s", keyring.Primary, id)
+		}
+	}
+	if len(keyring.Keys) != len(ids) || len(keyring.keys) != len(ids) {
+		t.Fatalf("keyring has %d keys, want %d", len(keyring.Keys), len(ids))
+	}
+}
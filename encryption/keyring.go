@@ -0,0 +1,113 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Độ dài khoá AES-256
+const keySize = 32
+
+// Keyring chứa các khoá mã hoá khoá (KEK) theo key ID. Primary dùng để mã hoá dữ liệu mới,
+// các khoá còn lại chỉ dùng để giải mã dữ liệu cũ cho tới khi chạy lệnh reencrypt.
+type Keyring struct {
+	Primary string       `yaml:"primary"`
+	Keys    []KeyringKey `yaml:"keys"`
+
+	keys map[string][]byte
+}
+
+type KeyringKey struct {
+	Id string `yaml:"id"`
+	// Khoá 32 byte mã hoá base64
+	Key string `yaml:"key"`
+}
+
+// LoadKeyring đọc keyring từ file YAML
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read keyring: %w", err)
+	}
+
+	var keyring Keyring
+	if err := yaml.Unmarshal(data, &keyring); err != nil {
+		return nil, fmt.Errorf("parse keyring %s: %w", path, err)
+	}
+	if err := keyring.index(); err != nil {
+		return nil, fmt.Errorf("keyring %s: %w", path, err)
+	}
+	return &keyring, nil
+}
+
+// GenerateKey thêm một khoá ngẫu nhiên vào keyring và đặt nó làm primary, trả về key ID mới
+func (k *Keyring) GenerateKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+
+	// Thêm phần ngẫu nhiên để hai khoá tạo trong cùng một giây không trùng ID
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	id := "k" + time.Now().UTC().Format("20060102150405") + "-" + hex.EncodeToString(suffix)
+	if _, exists := k.keys[id]; exists {
+		return "", fmt.Errorf("key %s already exists", id)
+	}
+
+	k.Keys = append(k.Keys, KeyringKey{Id: id, Key: base64.StdEncoding.EncodeToString(key)})
+	k.Primary = id
+	return id, k.index()
+}
+
+// Save ghi keyring ra file, chỉ chủ sở hữu được đọc
+func (k *Keyring) Save(path string) error {
+	data, err := yaml.Marshal(k)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (k *Keyring) key(id string) ([]byte, bool) {
+	key, ok := k.keys[id]
+	return key, ok
+}
+
+func (k *Keyring) index() error {
+	k.keys = make(map[string][]byte, len(k.Keys))
+	for _, entry := range k.Keys {
+		if entry.Id == "" {
+			return errors.New("key id is required")
+		}
+		if _, exists := k.keys[entry.Id]; exists {
+			return fmt.Errorf("duplicate key id %s", entry.Id)
+		}
+		key, err := base64.StdEncoding.DecodeString(entry.Key)
+		if err != nil || len(key) != keySize {
+			return fmt.Errorf("key %s must be %d bytes encoded as base64", entry.Id, keySize)
+		}
+		k.keys[entry.Id] = key
+	}
+
+	if len(k.keys) > 0 {
+		if _, ok := k.keys[k.Primary]; !ok {
+			return fmt.Errorf("primary key %q is not in the keyring", k.Primary)
+		}
+	}
+	return nil
+}
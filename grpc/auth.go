@@ -0,0 +1,124 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"draft-notification/apperrors"
+	"draft-notification/auth"
+	pb "draft-notification/proto"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionpbalpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+// Metadata chứa API key và connection (bắt buộc với key của webview server)
+const (
+	apiKeyMetadata       = "x-api-key"
+	connectionIdMetadata = "x-connection-id"
+)
+
+// Scope cần có cho từng method (rỗng là chỉ cần API key hợp lệ), method không có trong map thì bị từ chối
+var methodScopes = map[string]string{
+	pb.Messenger_SendMessage_FullMethodName: auth.ScopeNotificationsSend,
+	// Server reflection cho grpcurl, liệt kê được toàn bộ API nên không để public
+	reflectionpb.ServerReflection_ServerReflectionInfo_FullMethodName:      "",
+	reflectionpbalpha.ServerReflection_ServerReflectionInfo_FullMethodName: "",
+}
+
+// Các method, cả unary và stream, không cần API key (health check của orchestrator)
+var publicMethods = map[string]bool{
+	healthpb.Health_Check_FullMethodName: true,
+	healthpb.Health_Watch_FullMethodName: true,
+}
+
+var errInvalidApiKey = apperrors.Unauthorized("invalid_api_key")
+
+type identityKey struct{}
+
+// apiKeyInterceptor xác thực mọi unary call bằng API key và kiểm tra scope của method
+func apiKeyInterceptor(authenticator *auth.ApiKeyAuthenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticateMethod(ctx, authenticator, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// apiKeyStreamInterceptor giống apiKeyInterceptor cho các stream (health Watch, reflection...)
+func apiKeyStreamInterceptor(authenticator *auth.ApiKeyAuthenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateMethod(stream.Context(), authenticator, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authenticateMethod trả về ctx kèm identity của API key nếu key được gọi method
+func authenticateMethod(ctx context.Context, authenticator *auth.ApiKeyAuthenticator, method string) (context.Context, error) {
+	if publicMethods[method] {
+		return ctx, nil
+	}
+
+	identity, err := authenticator.Authenticate(ctx, metadataValue(ctx, apiKeyMetadata), peerIP(ctx))
+	switch {
+	case errors.Is(err, auth.ErrInvalidApiKey):
+		return nil, statusError(ctx, errInvalidApiKey)
+	case errors.Is(err, auth.ErrIpNotAllowed):
+		return nil, statusError(ctx, apperrors.Forbidden("ip_not_allowed"))
+	case err != nil:
+		return nil, statusError(ctx, apperrors.Unavailable("api_key_unavailable", err))
+	}
+
+	scope, ok := methodScopes[method]
+	if !ok || (scope != "" && !identity.HasScope(scope)) {
+		return nil, statusError(ctx, apperrors.Forbidden("missing_scope"))
+	}
+	return context.WithValue(ctx, identityKey{}, identity), nil
+}
+
+// authenticatedStream trả về context đã có identity cho handler của stream
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func identityFromContext(ctx context.Context) (auth.ApiKeyIdentity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(auth.ApiKeyIdentity)
+	return identity, ok
+}
+
+func metadataValue(ctx context.Context, key string) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// peerIP trả về IP của client kết nối trực tiếp tới gRPC server
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,147 @@
+package grpc
+
+import (
+	"context"
+	"draft-notification/auth"
+	"draft-notification/helpers"
+	"draft-notification/models"
+	"draft-notification/repositories"
+	"net"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newAuthTestClient chạy server chỉ có health và reflection với interceptor xác thực,
+// trả về client và một API key của webview server đang active
+func newAuthTestClient(t *testing.T) (*grpc.ClientConn, string) {
+	t.Helper()
+	ctx := context.Background()
+	repos := repositories.NewMemory()
+	now := time.Now().UTC()
+
+	server := models.WebviewServer{Id: primitive.NewObjectID(), Name: "webview", Status: "active", CreatedAt: now, UpdatedAt: now}
+	if err := repos.WebviewServers.Create(ctx, server); err != nil {
+		t.Fatal(err)
+	}
+	key, prefix, hash, err := helpers.NewAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repos.ApiKeys.Create(ctx, models.ApiKey{
+		Id:        primitive.NewObjectID(),
+		OwnerType: models.ApiKeyOwnerWebviewServer,
+		OwnerId:   server.Id,
+		Side:      models.ApiKeySideWebviewServer,
+		Scopes:    []string{auth.ScopeStatsRead},
+		Prefix:    prefix,
+		Hash:      hash,
+		CreatedAt: now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator := auth.NewApiKeyAuthenticator(repos)
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(apiKeyInterceptor(authenticator)),
+		grpc.ChainStreamInterceptor(apiKeyStreamInterceptor(authenticator)),
+	)
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	reflection.Register(s)
+
+	lis := bufconn.Listen(1 << 20)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, key
+}
+
+func TestStreamAuthentication(t *testing.T) {
+	conn, key := newAuthTestClient(t)
+
+	tests := []struct {
+		name   string
+		apiKey string
+		call   func(ctx context.Context) error
+		want   codes.Code
+	}{
+		{
+			name: "health watch is public",
+			call: func(ctx context.Context) error {
+				stream, err := healthpb.NewHealthClient(conn).Watch(ctx, &healthpb.HealthCheckRequest{})
+				if err != nil {
+					return err
+				}
+				_, err = stream.Recv()
+				return err
+			},
+			want: codes.OK,
+		},
+		{
+			name: "reflection without api key",
+			call: reflectionCall(conn),
+			want: codes.Unauthenticated,
+		},
+		{
+			name:   "reflection with invalid api key",
+			apiKey: "0123456789abcdef",
+			call:   reflectionCall(conn),
+			want:   codes.Unauthenticated,
+		},
+		{
+			name:   "reflection with api key",
+			apiKey: key,
+			call:   reflectionCall(conn),
+			want:   codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if tt.apiKey != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, apiKeyMetadata, tt.apiKey)
+			}
+			if got := status.Code(tt.call(ctx)); got != tt.want {
+				t.Fatalf("code = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func reflectionCall(conn *grpc.ClientConn) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+		if err != nil {
+			return err
+		}
+		err = stream.Send(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = stream.Recv()
+		return err
+	}
+}
@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+
+	"draft-notification/apperrors"
+	"draft-notification/i18n"
+	"draft-notification/logging"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+var grpcCodes = map[apperrors.Kind]codes.Code{
+	apperrors.KindInternal:     codes.Internal,
+	apperrors.KindBadRequest:   codes.InvalidArgument,
+	apperrors.KindUnauthorized: codes.Unauthenticated,
+	apperrors.KindForbidden:    codes.PermissionDenied,
+	apperrors.KindNotFound:     codes.NotFound,
+	apperrors.KindConflict:     codes.AlreadyExists,
+	apperrors.KindValidation:   codes.InvalidArgument,
+	apperrors.KindUnavailable:  codes.Unavailable,
+}
+
+// Metadata chọn ngôn ngữ của message lỗi, cùng cú pháp với header Accept-Language
+const acceptLanguageMetadata = "accept-language"
+
+// statusError chuyển lỗi thành gRPC status cùng mapping với HTTP. Message được dịch theo metadata
+// accept-language, mã lỗi ổn định nằm trong ErrorInfo.Reason, chi tiết field trong BadRequest;
+// cause chỉ được ghi log.
+func statusError(ctx context.Context, err error) error {
+	appErr := apperrors.From(err)
+	code := grpcCodes[appErr.Kind]
+	if code == codes.Internal || code == codes.Unavailable {
+		logging.FromContext(ctx).Error("gRPC call lỗi", "code", appErr.Code, "error", err)
+	}
+
+	message, fields := i18n.Localize(i18n.Match(metadataValue(ctx, acceptLanguageMetadata)), appErr)
+	st := status.New(code, message)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: appErr.Code, Domain: "draft-notification"}}
+	if len(fields) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, field := range fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field.Field,
+				Description: field.Message,
+			})
+		}
+		details = append(details, badRequest)
+	}
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
@@ -0,0 +1,79 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"draft-notification/apperrors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusError(t *testing.T) {
+	const leaked = "password hunter2"
+
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   codes.Code
+		wantReason string
+		wantFields []string
+	}{
+		{"bad request", apperrors.BadRequest("invalid_id"), codes.InvalidArgument, "invalid_id", nil},
+		{"unauthorized", apperrors.Unauthorized("unauthorized"), codes.Unauthenticated, "unauthorized", nil},
+		{"forbidden", apperrors.Forbidden("missing_scope"), codes.PermissionDenied, "missing_scope", nil},
+		{"not found", apperrors.NotFound("connection_not_found"), codes.NotFound, "connection_not_found", nil},
+		{"conflict", apperrors.Conflict("webview_server_name_taken"), codes.AlreadyExists, "webview_server_name_taken", nil},
+		{"validation", apperrors.InvalidField("name", "required", nil), codes.InvalidArgument, "validation_failed", []string{"name"}},
+		{"unavailable", fmt.Errorf("query: %w", context.DeadlineExceeded), codes.Unavailable, "storage_unavailable", nil},
+		{"unknown error", errors.New("dial 10.0.0.5: " + leaked), codes.Internal, "internal_error", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(acceptLanguageMetadata, "en"))
+			st := status.Convert(statusError(ctx, tt.err))
+			if st.Code() != tt.wantCode {
+				t.Fatalf("code = %s, want %s", st.Code(), tt.wantCode)
+			}
+			if strings.Contains(st.Message(), leaked) || strings.Contains(st.Message(), tt.wantReason) {
+				t.Fatalf("message = %q, want a localized message without internal details", st.Message())
+			}
+
+			var reason string
+			var fields []string
+			for _, detail := range st.Details() {
+				switch detail := detail.(type) {
+				case *errdetails.ErrorInfo:
+					reason = detail.Reason
+				case *errdetails.BadRequest:
+					for _, violation := range detail.FieldViolations {
+						fields = append(fields, violation.Field)
+					}
+				}
+			}
+			if reason != tt.wantReason {
+				t.Fatalf("reason = %q, want %q", reason, tt.wantReason)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Fatalf("fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestStatusErrorLanguage(t *testing.T) {
+	err := apperrors.InvalidField("name", "required", nil)
+	for _, tt := range []struct{ lang, want string }{{"en", "Invalid data"}, {"vi", "Dữ liệu không hợp lệ"}} {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(acceptLanguageMetadata, tt.lang))
+		if got := status.Convert(statusError(ctx, err)).Message(); got != tt.want {
+			t.Errorf("message in %s = %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+}
@@ -2,76 +2,129 @@ package grpc
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net"
 	"time"
 
+	"draft-notification/apperrors"
+	"draft-notification/auth"
+	"draft-notification/metrics"
 	pb "draft-notification/proto"
+	"draft-notification/queue"
+	"draft-notification/repositories"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 // Server
 type server struct {
 	pb.UnimplementedMessengerServer
+	jobs          repositories.JobRepo
+	authenticator *auth.ApiKeyAuthenticator
 }
 
+// SendMessage chỉ nhận message từ phía webview server của một connection đang active
 func (s *server) SendMessage(ctx context.Context, req *pb.MessageRequest) (*pb.MessageResponse, error) {
-	log.Printf("Received message: %v", req.Content)
-	return &pb.MessageResponse{Result: "Vinh received Message: " + req.Content}, nil
-}
-
-func runServer() {
-	lis, err := net.Listen("tcp", ":50051")
-	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+	identity, ok := identityFromContext(ctx)
+	if !ok {
+		return nil, statusError(ctx, errInvalidApiKey)
 	}
 
-	s := grpc.NewServer()
-	pb.RegisterMessengerServer(s, &server{})
+	var connectionId primitive.ObjectID
+	if raw := metadataValue(ctx, connectionIdMetadata); raw != "" {
+		parsed, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			return nil, statusError(ctx, apperrors.InvalidField(connectionIdMetadata, "object_id", nil))
+		}
+		connectionId = parsed
+	}
 
-	log.Println("gRPC server is running on :50051")
-	if err := s.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+	connection, err := s.authenticator.SendingConnection(ctx, identity, connectionId)
+	switch {
+	case errors.Is(err, auth.ErrConnectionRequired):
+		return nil, statusError(ctx, apperrors.InvalidField(connectionIdMetadata, "required", nil))
+	case errors.Is(err, auth.ErrConnectionNotAllowed):
+		return nil, statusError(ctx, apperrors.Forbidden("connection_not_allowed"))
+	case err != nil:
+		return nil, statusError(ctx, err)
 	}
-}
 
-func runClient() {
-	conn, err :=
-		grpc.NewClient(
-			"localhost:50051",
-			grpc.WithTransportCredentials(insecure.NewCredentials()),
-			grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
-		)
+	job, err := queue.Enqueue(ctx, s.jobs, connection.Id, req.Content)
 	if err != nil {
-		log.Fatalf("did not connect: %v", err)
+		return nil, statusError(ctx, apperrors.Unavailable("queue_unavailable", err))
 	}
-	defer conn.Close()
-
-	c := pb.NewMessengerClient(conn)
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
+	return &pb.MessageResponse{Result: "queued job " + job.Id.Hex()}, nil
+}
 
-	r, err := c.SendMessage(ctx, &pb.MessageRequest{Content: "Hello from client!"})
+// Serve chạy gRPC server trên addr cho tới khi ctx bị huỷ. grpc.health.v1 báo theo các readiness check,
+// mỗi lần check tối đa checkTimeout. Khi ctx bị huỷ server chuyển sang NOT_SERVING, ngừng nhận request mới
+// và chờ các call đang chạy trong shutdownTimeout.
+func Serve(ctx context.Context, addr string, repos repositories.Repositories, shutdownTimeout, checkTimeout time.Duration) error {
+	lis, err := net.Listen("tcp", addr)
 	if err != nil {
-		log.Fatalf("could not send message: %v", err)
+		return fmt.Errorf("failed to listen: %w", err)
 	}
-	log.Printf("Server response: %s", r.Result)
+	return serve(ctx, lis, repos, shutdownTimeout, checkTimeout)
 }
 
-func RunGrpc() {
-	// Start server in a goroutine
-	go runServer()
-
-	// Wait for server to start
-	time.Sleep(2 * time.Second)
+func serve(ctx context.Context, lis net.Listener, repos repositories.Repositories, shutdownTimeout, checkTimeout time.Duration) error {
+	authenticator := auth.NewApiKeyAuthenticator(repos)
+	s := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(metrics.UnaryServerInterceptor(), requestLogInterceptor(), apiKeyInterceptor(authenticator)),
+		grpc.ChainStreamInterceptor(metrics.StreamServerInterceptor(), apiKeyStreamInterceptor(authenticator)),
+	)
+	pb.RegisterMessengerServer(s, &server{jobs: repos.Jobs, authenticator: authenticator})
+
+	// grpc.health.v1 cho probe của orchestrator, server reflection cho grpcurl
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(s, healthServer)
+	reflection.Register(s)
+
+	healthCtx, stopHealth := context.WithCancel(ctx)
+	defer stopHealth()
+	go trackReadiness(healthCtx, healthServer, checkTimeout)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("gRPC server is running", "addr", lis.Addr().String())
+		serveErr <- s.Serve(lis)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
 
-	// Run client
-	runClient()
+	// Shutdown đặt mọi service sang NOT_SERVING và bỏ qua các cập nhật sau đó của trackReadiness
+	slog.Info("gRPC server is shutting down")
+	healthServer.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		slog.Warn("gRPC graceful stop timed out, closing remaining calls")
+		s.Stop()
+	}
 
-	// Keep the server running
-	select {}
+	// Serve chưa kịp chạy khi ctx bị huỷ ngay lúc khởi động thì trả về ErrServerStopped
+	if err := <-serveErr; !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
 }
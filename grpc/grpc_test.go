@@ -0,0 +1,124 @@
+package grpc
+
+import (
+	"context"
+	apphealth "draft-notification/health"
+	pb "draft-notification/proto"
+	"draft-notification/repositories"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func dialBufconn(t *testing.T, lis *bufconn.Listener) *grpc.ClientConn {
+	t.Helper()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestServeHealth(t *testing.T) {
+	tests := []struct {
+		name  string
+		check error
+		want  healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{"checks pass", nil, healthpb.HealthCheckResponse_SERVING},
+		{"check fails", errors.New("storage down"), healthpb.HealthCheckResponse_NOT_SERVING},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apphealth.Register("grpc-test", func(ctx context.Context) error { return tt.check })
+			t.Cleanup(func() { apphealth.Register("grpc-test", func(ctx context.Context) error { return nil }) })
+
+			lis := bufconn.Listen(1 << 20)
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() { done <- serve(ctx, lis, repositories.NewMemory(), time.Second, time.Second) }()
+			defer func() {
+				cancel()
+				<-done
+			}()
+
+			// Health không cần API key, báo cho cả server và service Messenger
+			client := healthpb.NewHealthClient(dialBufconn(t, lis))
+			for _, service := range []string{"", pb.Messenger_ServiceDesc.ServiceName} {
+				deadline := time.Now().Add(2 * time.Second)
+				for {
+					resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+					if err == nil && resp.Status == tt.want {
+						break
+					}
+					if time.Now().After(deadline) {
+						t.Fatalf("Check(%q) = %v, %v, want %v", service, resp.GetStatus(), err, tt.want)
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+			}
+			if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"}); status.Code(err) != codes.NotFound {
+				t.Fatalf("Check(unknown) = %v, want NotFound", err)
+			}
+		})
+	}
+}
+
+func TestServeShutdownTimeout(t *testing.T) {
+	const shutdownTimeout = 300 * time.Millisecond
+	tests := []struct {
+		name string
+		// openStream giữ một health Watch stream mở để GracefulStop phải chờ
+		openStream bool
+		min, max   time.Duration
+	}{
+		{"idle server stops without waiting", false, 0, shutdownTimeout},
+		{"open stream is closed after the timeout", true, shutdownTimeout, shutdownTimeout + time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lis := bufconn.Listen(1 << 20)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan error, 1)
+			go func() { done <- serve(ctx, lis, repositories.NewMemory(), shutdownTimeout, time.Second) }()
+
+			if tt.openStream {
+				stream, err := healthpb.NewHealthClient(dialBufconn(t, lis)).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+				if err != nil {
+					t.Fatalf("Watch: %v", err)
+				}
+				if _, err := stream.Recv(); err != nil {
+					t.Fatalf("Recv: %v", err)
+				}
+			}
+
+			start := time.Now()
+			cancel()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("serve: %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("serve did not return after shutdown")
+			}
+			if elapsed := time.Since(start); elapsed < tt.min || elapsed > tt.max {
+				t.Fatalf("shutdown took %v, want between %v and %v", elapsed, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	apphealth "draft-notification/health"
+	pb "draft-notification/proto"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthCheckInterval là chu kỳ chạy lại readiness check để cập nhật grpc.health.v1
+const healthCheckInterval = 5 * time.Second
+
+// healthServices là các service được báo trạng thái, "" là trạng thái chung của server
+var healthServices = []string{"", pb.Messenger_ServiceDesc.ServiceName}
+
+// trackReadiness đặt trạng thái grpc.health.v1 theo các readiness check đã đăng ký (giống /readyz)
+// cho tới khi ctx bị huỷ. Server bắt đầu ở NOT_SERVING cho tới lần check đầu tiên.
+func trackReadiness(ctx context.Context, server *health.Server, checkTimeout time.Duration) {
+	current := healthpb.HealthCheckResponse_NOT_SERVING
+	setStatus(server, current)
+
+	update := func() {
+		report := apphealth.Ready(ctx, checkTimeout)
+		status := healthpb.HealthCheckResponse_SERVING
+		if report.Status != apphealth.StatusOk {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		if status == current || ctx.Err() != nil {
+			return
+		}
+		slog.Info("gRPC health status changed", "status", status.String(), "checks", report.Checks)
+		current = status
+		setStatus(server, status)
+	}
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		update()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func setStatus(server *health.Server, status healthpb.HealthCheckResponse_ServingStatus) {
+	for _, service := range healthServices {
+		server.SetServingStatus(service, status)
+	}
+}
@@ -0,0 +1,66 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apphealth "draft-notification/health"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestTrackReadiness(t *testing.T) {
+	tests := []struct {
+		name  string
+		check error
+		want  healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{"checks pass", nil, healthpb.HealthCheckResponse_SERVING},
+		{"check fails", errors.New("storage down"), healthpb.HealthCheckResponse_NOT_SERVING},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apphealth.Register("grpc-test", func(ctx context.Context) error { return tt.check })
+			t.Cleanup(func() { apphealth.Register("grpc-test", func(ctx context.Context) error { return nil }) })
+
+			ctx, cancel := context.WithCancel(context.Background())
+			server := health.NewServer()
+			done := make(chan struct{})
+			go func() {
+				trackReadiness(ctx, server, time.Second)
+				close(done)
+			}()
+
+			for _, service := range healthServices {
+				waitForStatus(t, server, service, tt.want)
+			}
+
+			// Shutdown giữ NOT_SERVING dù readiness check vẫn ok
+			server.Shutdown()
+			cancel()
+			<-done
+			for _, service := range healthServices {
+				waitForStatus(t, server, service, healthpb.HealthCheckResponse_NOT_SERVING)
+			}
+		})
+	}
+}
+
+func waitForStatus(t *testing.T, server *health.Server, service string, want healthpb.HealthCheckResponse_ServingStatus) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err == nil && resp.Status == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("status of %q = %v (err %v), want %v", service, resp.GetStatus(), err, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,54 @@
+package grpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"draft-notification/logging"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata chứa request ID, lấy từ client hoặc được sinh mới và trả lại trong header của response
+const requestIdMetadata = "x-request-id"
+
+// requestLogInterceptor gắn request ID vào logger của call và ghi một dòng log khi call kết thúc
+func requestLogInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		requestId := metadataValue(ctx, requestIdMetadata)
+		if requestId == "" || len(requestId) > 128 {
+			requestId = newRequestId()
+		}
+		grpc.SetHeader(ctx, metadata.Pairs(requestIdMetadata, requestId))
+
+		logger := slog.Default().With("request_id", requestId)
+		ctx = logging.NewContext(ctx, logger)
+
+		resp, err := handler(ctx, req)
+
+		level := slog.LevelInfo
+		if err != nil {
+			level = slog.LevelWarn
+		}
+		logger.Log(ctx, level, "gRPC request",
+			"method", info.FullMethod,
+			"code", status.Code(err).String(),
+			"duration_ms", time.Since(start).Milliseconds(),
+			"peer_ip", peerIP(ctx),
+		)
+		return resp, err
+	}
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
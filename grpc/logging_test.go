@@ -0,0 +1,96 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"draft-notification/logging"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// headerStream giữ lại header mà interceptor gửi cho client
+type headerStream struct {
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string { return "/test.Service/Call" }
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *headerStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *headerStream) SetTrailer(md metadata.MD) error { return nil }
+
+func TestRequestLogInterceptor(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestId string
+		want      string
+	}{
+		{"incoming request id is echoed", "req-123", "req-123"},
+		{"missing request id is generated", "", ""},
+		{"oversized request id is replaced", strings.Repeat("x", 129), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := slog.Default()
+			t.Cleanup(func() { slog.SetDefault(old) })
+			var buf bytes.Buffer
+			if err := logging.Setup(&buf, "debug", logging.FormatJSON); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+			if tt.requestId != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(requestIdMetadata, tt.requestId))
+			}
+			stream := &headerStream{}
+			ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+
+			info := &grpc.UnaryServerInfo{FullMethod: stream.Method()}
+			_, err := requestLogInterceptor()(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				logging.FromContext(ctx).InfoContext(ctx, "handler")
+				return nil, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids := stream.header.Get(requestIdMetadata)
+			if len(ids) != 1 || ids[0] == "" || (tt.want != "" && ids[0] != tt.want) || len(ids[0]) > 128 {
+				t.Fatalf("response %s = %q, want %q", requestIdMetadata, ids, tt.want)
+			}
+
+			// Log của handler và dòng log của call cùng mang request id trả về cho client
+			var records []map[string]any
+			decoder := json.NewDecoder(&buf)
+			for decoder.More() {
+				var record map[string]any
+				if err := decoder.Decode(&record); err != nil {
+					t.Fatal(err)
+				}
+				records = append(records, record)
+			}
+			if len(records) != 2 {
+				t.Fatalf("logged %d records, want 2: %v", len(records), records)
+			}
+			for _, record := range records {
+				if record["request_id"] != ids[0] {
+					t.Errorf("%q request_id = %v, want %q", record["msg"], record["request_id"], ids[0])
+				}
+			}
+			if access := records[1]; access["msg"] != "gRPC request" || access["method"] != info.FullMethod || access["code"] != "OK" {
+				t.Errorf("access log = %v", access)
+			}
+		})
+	}
+}
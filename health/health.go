@@ -0,0 +1,111 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Check trả về lỗi khi dependency chưa sẵn sàng
+type Check func(ctx context.Context) error
+
+var (
+	mu     sync.RWMutex
+	checks = map[string]Check{}
+)
+
+// Register thêm (hoặc thay) một readiness check, mỗi role đăng ký các dependency nó cần
+func Register(name string, check Check) {
+	mu.Lock()
+	defer mu.Unlock()
+	checks[name] = check
+}
+
+const (
+	StatusOk          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Ready chạy song song mọi check đã đăng ký, mỗi check có tối đa timeout
+func Ready(ctx context.Context, timeout time.Duration) Report {
+	mu.RLock()
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	registered := make(map[string]Check, len(checks))
+	for name, check := range checks {
+		registered[name] = check
+	}
+	mu.RUnlock()
+	sort.Strings(names)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			result := CheckResult{Status: StatusOk}
+			if err := registered[name](ctx); err != nil {
+				result = CheckResult{Status: StatusUnavailable, Error: err.Error()}
+			}
+			result.DurationMs = time.Since(start).Milliseconds()
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOk, Checks: make(map[string]CheckResult, len(names))}
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i].Status != StatusOk {
+			report.Status = StatusUnavailable
+		}
+	}
+	return report
+}
+
+// LiveHandler trả 200 khi process còn phục vụ được request, không kiểm tra dependency
+func LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, http.StatusOK, Report{Status: StatusOk})
+	})
+}
+
+// ReadyHandler trả 200 khi mọi check đều ok, ngược lại 503 kèm kết quả từng check
+func ReadyHandler(timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := Ready(r.Context(), timeout)
+		status := http.StatusOK
+		if report.Status != StatusOk {
+			status = http.StatusServiceUnavailable
+		}
+		writeReport(w, status, report)
+	})
+}
+
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
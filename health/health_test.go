@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// withChecks thay các check đã đăng ký trong lúc chạy test
+func withChecks(t *testing.T, registered map[string]Check) {
+	t.Helper()
+	mu.Lock()
+	old := checks
+	checks = map[string]Check{}
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		checks = old
+		mu.Unlock()
+	})
+	for name, check := range registered {
+		Register(name, check)
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	tests := []struct {
+		name       string
+		checks     map[string]Check
+		wantStatus int
+		wantChecks map[string]CheckResult
+	}{
+		{"no checks", nil, http.StatusOK, nil},
+		{
+			name:       "all checks pass",
+			checks:     map[string]Check{"storage": ok, "migrations": ok},
+			wantStatus: http.StatusOK,
+			wantChecks: map[string]CheckResult{"storage": {Status: StatusOk}, "migrations": {Status: StatusOk}},
+		},
+		{
+			name: "one check fails",
+			checks: map[string]Check{
+				"storage":   ok,
+				"queue_lag": func(ctx context.Context) error { return errors.New("oldest pending job is 10m old") },
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantChecks: map[string]CheckResult{
+				"storage":   {Status: StatusOk},
+				"queue_lag": {Status: StatusUnavailable, Error: "oldest pending job is 10m old"},
+			},
+		},
+		{
+			name: "check exceeds the timeout",
+			checks: map[string]Check{"storage": func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}},
+			wantStatus: http.StatusServiceUnavailable,
+			wantChecks: map[string]CheckResult{"storage": {Status: StatusUnavailable, Error: context.DeadlineExceeded.Error()}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withChecks(t, tt.checks)
+
+			// Liveness không phụ thuộc dependency
+			live := httptest.NewRecorder()
+			LiveHandler().ServeHTTP(live, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if live.Code != http.StatusOK {
+				t.Fatalf("/healthz = %d, want 200", live.Code)
+			}
+
+			rec := httptest.NewRecorder()
+			ReadyHandler(50*time.Millisecond).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tt.wantStatus || rec.Header().Get("Cache-Control") != "no-store" {
+				t.Fatalf("/readyz = %d (Cache-Control %q), want %d", rec.Code, rec.Header().Get("Cache-Control"), tt.wantStatus)
+			}
+
+			var report Report
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatal(err)
+			}
+			wantReport := StatusOk
+			if tt.wantStatus != http.StatusOK {
+				wantReport = StatusUnavailable
+			}
+			if report.Status != wantReport || len(report.Checks) != len(tt.wantChecks) {
+				t.Fatalf("report = %+v, want status %s with %d checks", report, wantReport, len(tt.wantChecks))
+			}
+			for name, want := range tt.wantChecks {
+				got := report.Checks[name]
+				got.DurationMs = 0
+				if got != want {
+					t.Errorf("checks[%s] = %+v, want %+v", name, got, want)
+				}
+			}
+		})
+	}
+}
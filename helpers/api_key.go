@@ -2,9 +2,14 @@ package helpers
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 )
 
+// Số ký tự đầu của API key được lưu rõ để tra cứu và hiển thị
+const APIKeyPrefixLength = 8
+
 func GenerateAPIKey(length int) (string, error) {
 	bytes := make([]byte, length)
 	if _, err := rand.Read(bytes); err != nil {
@@ -12,3 +17,38 @@ func GenerateAPIKey(length int) (string, error) {
 	}
 	return hex.EncodeToString(bytes), nil
 }
+
+// NewAPIKey tạo API key mới cùng prefix và hash để lưu DB, key đầy đủ chỉ được trả về một lần
+func NewAPIKey() (key, prefix, hash string, err error) {
+	key, err = GenerateAPIKey(32)
+	if err != nil {
+		return "", "", "", err
+	}
+	return key, APIKeyPrefix(key), HashAPIKey(key), nil
+}
+
+func APIKeyPrefix(key string) string {
+	if len(key) <= APIKeyPrefixLength {
+		return key
+	}
+	return key[:APIKeyPrefixLength]
+}
+
+// HashAPIKey trả về SHA-256 (hex) của key, đủ an toàn vì key là chuỗi ngẫu nhiên 256 bit
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyMatches so sánh key với hash đã lưu trong thời gian hằng số
+func APIKeyMatches(key, hash string) bool {
+	return hash != "" && subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(hash)) == 1
+}
+
+// MaskAPIKey chỉ giữ lại prefix của key để nhận diện
+func MaskAPIKey(key string) string {
+	if key == "" {
+		return ""
+	}
+	return APIKeyPrefix(key) + "******"
+}
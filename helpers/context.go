@@ -3,10 +3,18 @@ package helpers
 import (
 	"time"
 
+	"github.com/labstack/echo/v4"
 	"golang.org/x/net/context"
 )
 
-// Helper function to create context with timeout
-func CreateContext() (context.Context, context.CancelFunc) {
-	return context.WithTimeout(context.Background(), 10*time.Second)
+// Timeout cho mỗi request, được ghi đè bởi config khi khởi động
+var RequestTimeout = 10 * time.Second
+
+// Số phần tử tối đa của một trang danh sách, limit lớn hơn bị giảm về giá trị này
+var MaxPageSize = 100
+
+// CreateRequestContext tạo context có timeout từ context của request để giữ trace context,
+// request id và bị huỷ khi client ngắt kết nối
+func CreateRequestContext(c echo.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.Request().Context(), RequestTimeout)
 }
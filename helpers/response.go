@@ -1,18 +1,93 @@
 package helpers
 
 import (
+	"draft-notification/apperrors"
+	"draft-notification/i18n"
+	"draft-notification/logging"
 	"draft-notification/responses"
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 )
 
-// Helper function to handle error response
-func HandleError(c echo.Context, status int, errMsg string) error {
-	return c.JSON(status, responses.Response{Code: status, Message: errMsg, Data: &echo.Map{}})
+// Language trả về ngôn ngữ của response theo header Accept-Language
+func Language(c echo.Context) string {
+	return i18n.Match(c.Request().Header.Get("Accept-Language"))
+}
+
+// localize chọn ngôn ngữ cho response và ghi Content-Language
+func localize(c echo.Context) string {
+	lang := Language(c)
+	c.Response().Header().Set("Content-Language", lang)
+	return lang
+}
+
+// HandleError trả về response lỗi theo loại lỗi với message đã dịch và mã lỗi ổn định ở reason.
+// Lỗi không phải *apperrors.Error được ghi log và trả về 500/503 với message chung để không lộ chi tiết nội bộ.
+func HandleError(c echo.Context, err error) error {
+	appErr := apperrors.From(err)
+	status := appErr.Status()
+
+	if status >= http.StatusInternalServerError {
+		logging.FromContext(c.Request().Context()).Error("Request lỗi", "code", appErr.Code, "error", err)
+	}
+
+	var data interface{} = &echo.Map{}
+	if appErr.Data != nil {
+		data = appErr.Data
+	}
+	message, fields := i18n.Localize(localize(c), appErr)
+	return c.JSON(status, responses.Response{
+		Code:    status,
+		Message: message,
+		Data:    data,
+		Reason:  appErr.Code,
+		Errors:  fields,
+	})
 }
 
 // Helper function for success response
 func HandleSuccess(c echo.Context, data interface{}) error {
-	return c.JSON(http.StatusOK, responses.Response{Code: http.StatusOK, Message: "success", Data: data})
+	message := i18n.Message(localize(c), successReason, nil)
+	return c.JSON(http.StatusOK, responses.Response{Code: http.StatusOK, Message: message, Data: data, Reason: successReason})
+}
+
+// HandleDone trả về success cho thao tác không có dữ liệu trả về, data là message đã dịch
+func HandleDone(c echo.Context) error {
+	return HandleSuccess(c, i18n.Message(Language(c), successReason, nil))
+}
+
+const successReason = "success"
+
+// HTTPErrorHandler thay handler mặc định của echo để lỗi của router (404, 405), của middleware
+// có sẵn hay lỗi handler trả về trực tiếp có cùng dạng response với HandleError
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) && he.Code < http.StatusInternalServerError {
+		reason, ok := httpErrorReasons[he.Code]
+		if !ok {
+			reason = "bad_request"
+		}
+		message := i18n.Message(localize(c), reason, nil)
+		err = c.JSON(he.Code, responses.Response{Code: he.Code, Message: message, Data: &echo.Map{}, Reason: reason})
+	} else {
+		err = HandleError(c, err)
+	}
+	if err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+var httpErrorReasons = map[int]string{
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusNotFound:              "route_not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusRequestEntityTooLarge: "request_too_large",
+	http.StatusUnsupportedMediaType:  "unsupported_media_type",
+	http.StatusTooManyRequests:       "too_many_requests",
 }
@@ -0,0 +1,58 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"draft-notification/apperrors"
+	"draft-notification/responses"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestHandleError(t *testing.T) {
+	const leaked = "password hunter2"
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantReason string
+		wantFields int
+	}{
+		{"conflict", apperrors.Conflict("webview_server_name_taken"), http.StatusConflict, "webview_server_name_taken", 0},
+		{"validation", apperrors.InvalidField("name", "required", nil), http.StatusUnprocessableEntity, "validation_failed", 1},
+		{"unknown error", errors.New("dial 10.0.0.5: " + leaked), http.StatusInternalServerError, "internal_error", 0},
+		{"echo error", echo.NewHTTPError(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed, "method_not_allowed", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Language", "en")
+			rec := httptest.NewRecorder()
+			HTTPErrorHandler(tt.err, echo.New().NewContext(req, rec))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if strings.Contains(rec.Body.String(), leaked) {
+				t.Fatalf("body leaks the cause: %s", rec.Body.String())
+			}
+			var body responses.Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != tt.wantStatus || body.Reason != tt.wantReason || body.Message == "" || body.Message == tt.wantReason || len(body.Errors) != tt.wantFields {
+				t.Fatalf("body = %+v", body)
+			}
+			if rec.Header().Get("Content-Language") != "en" {
+				t.Fatalf("Content-Language = %q", rec.Header().Get("Content-Language"))
+			}
+		})
+	}
+}
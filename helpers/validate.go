@@ -1,5 +1,67 @@
 package helpers
 
-import "github.com/go-playground/validator/v10"
+import (
+	"draft-notification/apperrors"
+	"errors"
+	"reflect"
+	"strings"
 
-var Validate = validator.New()
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+var Validate = newValidator()
+
+// ErrInvalidJSON là lỗi khi body không parse được
+var ErrInvalidJSON = apperrors.BadRequest("invalid_json")
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	// Lỗi validation dùng tên field trong JSON để client map được về form
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// BindAndValidate bind body vào request và kiểm tra các tag validate
+func BindAndValidate(c echo.Context, request interface{}) error {
+	if err := c.Bind(request); err != nil {
+		return ErrInvalidJSON.Wrap(err)
+	}
+	return ValidateStruct(request)
+}
+
+// ValidateStruct trả về lỗi 422 với chi tiết từng field không hợp lệ
+func ValidateStruct(request interface{}) error {
+	err := Validate.Struct(request)
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	fields := make([]apperrors.FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields = append(fields, apperrors.FieldError{
+			Field:  fieldErr.Field(),
+			Code:   validationCode(fieldErr),
+			Params: map[string]string{"param": fieldErr.Param()},
+		})
+	}
+	return apperrors.Validation(fields...)
+}
+
+// validationCode trả về mã lỗi của field, min được tách theo độ dài chuỗi hay số phần tử
+func validationCode(fieldErr validator.FieldError) string {
+	if fieldErr.Tag() == "min" {
+		if fieldErr.Kind() == reflect.String {
+			return "min_length"
+		}
+		return "min_items"
+	}
+	return fieldErr.Tag()
+}
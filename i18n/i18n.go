@@ -0,0 +1,90 @@
+package i18n
+
+import (
+	"strings"
+
+	"draft-notification/apperrors"
+
+	"golang.org/x/text/language"
+)
+
+// Ngôn ngữ hỗ trợ, thứ tự khớp với tags của matcher
+const (
+	Vietnamese = "vi"
+	English    = "en"
+)
+
+var Languages = []string{Vietnamese, English}
+
+var matcher = language.NewMatcher([]language.Tag{language.Vietnamese, language.English})
+
+var catalogues = map[string]map[string]string{
+	Vietnamese: messagesVi,
+	English:    messagesEn,
+}
+
+// defaultLanguage được set từ config lúc khởi động, dùng khi client không gửi Accept-Language
+// hoặc không khớp ngôn ngữ nào
+var defaultLanguage = Vietnamese
+
+func Supported(lang string) bool {
+	_, ok := catalogues[lang]
+	return ok
+}
+
+func SetDefault(lang string) {
+	if Supported(lang) {
+		defaultLanguage = lang
+	}
+}
+
+// Match chọn ngôn ngữ hỗ trợ phù hợp nhất với header Accept-Language
+func Match(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return defaultLanguage
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return defaultLanguage
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return defaultLanguage
+	}
+	return Languages[index]
+}
+
+// Message trả về câu thông báo của key, {name} trong câu được thay bằng params[name].
+// Key chưa được dịch sang lang thì dùng ngôn ngữ mặc định, không có trong catalogue thì trả về key.
+func Message(lang, key string, params map[string]string) string {
+	message, ok := lookup(lang, key)
+	if !ok {
+		return key
+	}
+	for name, value := range params {
+		message = strings.ReplaceAll(message, "{"+name+"}", value)
+	}
+	return message
+}
+
+// Localize trả về message của lỗi và chi tiết field đã dịch sang lang
+func Localize(lang string, err *apperrors.Error) (string, []apperrors.FieldError) {
+	var fields []apperrors.FieldError
+	for _, field := range err.Fields {
+		key := "field." + field.Code
+		if _, ok := lookup(lang, key); !ok {
+			key = "field.invalid"
+		}
+		field.Message = Message(lang, key, field.Params)
+		fields = append(fields, field)
+	}
+	return Message(lang, err.Code, err.Params), fields
+}
+
+func lookup(lang, key string) (string, bool) {
+	if message, ok := catalogues[lang][key]; ok {
+		return message, true
+	}
+	message, ok := catalogues[defaultLanguage][key]
+	return message, ok
+}
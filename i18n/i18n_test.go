@@ -0,0 +1,105 @@
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"draft-notification/apperrors"
+)
+
+// withDefault đổi ngôn ngữ mặc định trong một test rồi trả lại như cũ
+func withDefault(t *testing.T, lang string) {
+	t.Helper()
+	previous := defaultLanguage
+	SetDefault(lang)
+	t.Cleanup(func() { defaultLanguage = previous })
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		defaultLang    string
+		acceptLanguage string
+		want           string
+	}{
+		{"empty uses default en", English, "", English},
+		{"empty uses default vi", Vietnamese, "", Vietnamese},
+		{"unsupported uses default", English, "fr-FR, de;q=0.8", English},
+		{"invalid header uses default", English, ";;q=abc", English},
+		{"region falls back to base", English, "vi-VN", Vietnamese},
+		{"english region", Vietnamese, "en-GB", English},
+		{"q-value prefers english", Vietnamese, "vi;q=0.4, en;q=0.9", English},
+		{"q-value prefers vietnamese", English, "en;q=0.5, vi-VN;q=0.8", Vietnamese},
+		{"unsupported first", English, "fr, vi;q=0.7", Vietnamese},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withDefault(t, tt.defaultLang)
+			if got := Match(tt.acceptLanguage); got != tt.want {
+				t.Fatalf("Match(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetDefaultIgnoresUnsupported(t *testing.T) {
+	withDefault(t, English)
+	SetDefault("fr")
+	if defaultLanguage != English {
+		t.Fatalf("defaultLanguage = %q, want %q", defaultLanguage, English)
+	}
+}
+
+var placeholder = regexp.MustCompile(`\{[a-zA-Z]+\}`)
+
+// Mọi key phải có ở cả hai catalogue với cùng các placeholder
+func TestCataloguesHaveSameKeys(t *testing.T) {
+	for _, lang := range Languages {
+		for _, other := range Languages {
+			for key, message := range catalogues[lang] {
+				translated, ok := catalogues[other][key]
+				if !ok {
+					t.Errorf("key %q is in %s but not in %s", key, lang, other)
+					continue
+				}
+				if got, want := placeholders(translated), placeholders(message); got != want {
+					t.Errorf("key %q has placeholders %s in %s but %s in %s", key, got, other, want, lang)
+				}
+			}
+		}
+	}
+}
+
+func placeholders(message string) string {
+	found := placeholder.FindAllString(message, -1)
+	sort.Strings(found)
+	return strings.Join(found, ",")
+}
+
+func TestLocalize(t *testing.T) {
+	withDefault(t, Vietnamese)
+	err := apperrors.Validation(
+		apperrors.FieldError{Field: "name", Code: "required"},
+		apperrors.FieldError{Field: "status", Code: "no_such_code"},
+	)
+
+	message, fields := Localize(English, err)
+	if message != catalogues[English]["validation_failed"] {
+		t.Fatalf("message = %q", message)
+	}
+	if fields[0].Message != catalogues[English]["field.required"] || fields[1].Message != catalogues[English]["field.invalid"] {
+		t.Fatalf("fields = %+v", fields)
+	}
+	if err.Fields[0].Message != "" {
+		t.Fatalf("Localize modified the error: %+v", err.Fields)
+	}
+	if got := Message(English, "field.min_length", map[string]string{"param": "8"}); got != "Must be at least 8 characters" {
+		t.Fatalf("Message with params = %q", got)
+	}
+	if got := Message(English, "no_such_key", nil); got != "no_such_key" {
+		t.Fatalf("Message of unknown key = %q, want the key", got)
+	}
+}
@@ -0,0 +1,71 @@
+package i18n
+
+var messagesEn = map[string]string{
+	"success": "Success",
+
+	"internal_error":      "Internal server error",
+	"storage_unavailable": "Storage is temporarily unavailable",
+	"queue_unavailable":   "Could not enqueue the notification",
+	"api_key_unavailable": "Could not verify the API key",
+
+	"bad_request":            "Bad request",
+	"invalid_json":           "Invalid JSON format",
+	"invalid_id":             "Invalid ID",
+	"validation_failed":      "Invalid data",
+	"unauthorized":           "Unauthorized",
+	"route_not_found":        "Route not found",
+	"method_not_allowed":     "Method not allowed",
+	"request_too_large":      "Request entity too large",
+	"unsupported_media_type": "Unsupported media type",
+	"too_many_requests":      "Too many requests, please try again later",
+
+	"invalid_token":         "Invalid token",
+	"invalid_credentials":   "Invalid username or password",
+	"invalid_refresh_token": "Invalid refresh token",
+	"invalid_api_key":       "Invalid API key",
+	"ip_not_allowed":        "This IP is not allowed to use the API key",
+	"missing_scope":         "The API key is missing the scope for this operation",
+	"missing_permission":    "You do not have permission to perform this operation",
+	"no_organization":       "The admin does not belong to any organization",
+	"self_demotion":         "You cannot change your own role",
+
+	"not_found":                      "Resource not found",
+	"webview_server_not_found":       "Webview server not found",
+	"user_delivery_server_not_found": "User delivery server not found",
+	"connection_not_found":           "Connection not found",
+	"admin_not_found":                "Admin not found",
+	"api_key_not_found":              "API key not found",
+	"organization_not_found":         "Organization not found",
+	"consent_not_found":              "Consent not found",
+	"notification_not_found":         "Notification not found",
+
+	"duplicate":                       "Resource already exists",
+	"webview_server_name_taken":       "Webview server name is already taken",
+	"user_delivery_server_name_taken": "User delivery server name is already taken",
+	"connection_exists":               "A connection between the webview server and the user delivery server already exists",
+	"consent_exists":                  "The organization already has consent for this webview server",
+	"username_taken":                  "Username is already taken",
+	"api_key_rotation_in_progress":    "The previous API key is still valid, rotate again after it expires or with overlap \"0s\" to revoke both old keys",
+	"user_delivery_server_inactive":   "User delivery server is not active",
+	"webview_server_inactive":         "Webview server is not active",
+
+	"connection_not_allowed": "The API key is not allowed to send through this connection",
+	"webhook_not_verified":   "The connection's webhook URL has not been verified",
+	"consent_required":       "The webview server's organization has not granted consent to the current organization",
+
+	"field.invalid":                     "Invalid value",
+	"field.required":                    "Is required",
+	"field.min_length":                  "Must be at least {param} characters",
+	"field.min_items":                   "Must contain at least {param} items",
+	"field.oneof":                       "Must be one of: {values}",
+	"field.object_id":                   "Invalid ID",
+	"field.rfc3339":                     "Must be an RFC 3339 timestamp",
+	"field.cursor":                      "Invalid cursor or cursor does not match the sort",
+	"field.duration":                    "Invalid duration",
+	"field.future":                      "Must be in the future",
+	"field.scope":                       "Invalid scope: {value}",
+	"field.ip":                          "Invalid IP or CIDR: {value}",
+	"field.self_consent":                "Consent is not needed for the current organization",
+	"field.webhook_url":                 "Invalid webhook URL: {reason}",
+	"field.webhook_verification_failed": "Could not verify the webhook URL: {reason}",
+}
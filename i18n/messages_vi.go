@@ -0,0 +1,71 @@
+package i18n
+
+var messagesVi = map[string]string{
+	"success": "Thành công",
+
+	"internal_error":      "Lỗi hệ thống",
+	"storage_unavailable": "Storage tạm thời không khả dụng",
+	"queue_unavailable":   "Không thể đưa notification vào hàng đợi",
+	"api_key_unavailable": "Không thể xác thực API key",
+
+	"bad_request":            "Request không hợp lệ",
+	"invalid_json":           "JSON không đúng định dạng",
+	"invalid_id":             "ID không hợp lệ",
+	"validation_failed":      "Dữ liệu không hợp lệ",
+	"unauthorized":           "Chưa xác thực",
+	"route_not_found":        "Không tìm thấy đường dẫn",
+	"method_not_allowed":     "Phương thức không được hỗ trợ",
+	"request_too_large":      "Request quá lớn",
+	"unsupported_media_type": "Content-Type không được hỗ trợ",
+	"too_many_requests":      "Quá nhiều request, vui lòng thử lại sau",
+
+	"invalid_token":         "Token không hợp lệ",
+	"invalid_credentials":   "Sai tên đăng nhập hoặc mật khẩu",
+	"invalid_refresh_token": "Refresh token không hợp lệ",
+	"invalid_api_key":       "API key không hợp lệ",
+	"ip_not_allowed":        "IP không được phép dùng API key này",
+	"missing_scope":         "API key không có scope cho thao tác này",
+	"missing_permission":    "Không có quyền thực hiện thao tác này",
+	"no_organization":       "Admin chưa thuộc organization nào",
+	"self_demotion":         "Không thể tự đổi role của chính mình",
+
+	"not_found":                      "Không tìm thấy dữ liệu",
+	"webview_server_not_found":       "Webview server không tồn tại",
+	"user_delivery_server_not_found": "User delivery server không tồn tại",
+	"connection_not_found":           "Connection không tồn tại",
+	"admin_not_found":                "Admin không tồn tại",
+	"api_key_not_found":              "API key không tồn tại",
+	"organization_not_found":         "Organization không tồn tại",
+	"consent_not_found":              "Consent không tồn tại",
+	"notification_not_found":         "Notification không tồn tại",
+
+	"duplicate":                       "Dữ liệu đã tồn tại",
+	"webview_server_name_taken":       "Tên webview server đã tồn tại",
+	"user_delivery_server_name_taken": "Tên user delivery server đã tồn tại",
+	"connection_exists":               "Connection giữa webview server và user delivery server đã tồn tại",
+	"consent_exists":                  "Organization đã được cấp consent cho webview server này",
+	"username_taken":                  "Username đã tồn tại",
+	"api_key_rotation_in_progress":    "API key cũ vẫn còn hiệu lực, chỉ rotate lại sau khi nó hết hạn hoặc với overlap \"0s\" để thu hồi cả hai key cũ",
+	"user_delivery_server_inactive":   "User delivery server chưa active",
+	"webview_server_inactive":         "Webview server chưa active",
+
+	"connection_not_allowed": "API key không được gửi qua connection này",
+	"webhook_not_verified":   "Webhook URL của connection chưa được xác minh",
+	"consent_required":       "Organization của webview server chưa cấp consent cho organization hiện tại",
+
+	"field.invalid":                     "Không hợp lệ",
+	"field.required":                    "Không được để trống",
+	"field.min_length":                  "Phải có ít nhất {param} ký tự",
+	"field.min_items":                   "Phải có ít nhất {param} phần tử",
+	"field.oneof":                       "Phải là một trong các giá trị: {values}",
+	"field.object_id":                   "ID không hợp lệ",
+	"field.rfc3339":                     "Thời gian phải theo định dạng RFC 3339",
+	"field.cursor":                      "Cursor không hợp lệ hoặc không khớp với sort",
+	"field.duration":                    "Khoảng thời gian không hợp lệ",
+	"field.future":                      "Phải là thời điểm trong tương lai",
+	"field.scope":                       "Scope không hợp lệ: {value}",
+	"field.ip":                          "IP hoặc CIDR không hợp lệ: {value}",
+	"field.self_consent":                "Không cần consent cho organization hiện tại",
+	"field.webhook_url":                 "Webhook URL không hợp lệ: {reason}",
+	"field.webhook_verification_failed": "Không xác minh được webhook URL: {reason}",
+}
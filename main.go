@@ -1,37 +1,15 @@
 package main
 
 import (
-	"draft-notification/configs"
-	"draft-notification/middlewares"
-	"draft-notification/routes"
-	"log"
-
-	"github.com/labstack/echo/v4"
+	"draft-notification/commands"
+	"log/slog"
+	"os"
 )
 
 // Main function
 func main() {
-	e := echo.New()
-
-	e.Use(middlewares.ValidateToken)
-
-	configs.ConnectDB()
-	routes.WebviewServerRoute(e)
-	routes.UserDeliveryServerRoute(e)
-	routes.ConnectionRoute(e)
-
-	log.Println("🚀 Server đang chạy trên http://localhost:8080")
-	e.Start(":8080")
+	if err := commands.Execute(os.Args[1:]); err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
 }
-
-// package main
-
-// import (
-// 	"draft-notification/grpc"
-// 	"draft-notification/queue"
-// )
-
-// func main() {
-// 	queue.RunQueue()
-// 	grpc.RunGrpc()
-// }
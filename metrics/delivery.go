@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Kết quả gửi một job tới webhook
+const (
+	DeliverySuccess = "success"
+	DeliveryFailure = "failure"
+)
+
+var (
+	deliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "delivery",
+		Name:      "attempts_total",
+		Help:      "Webhook delivery attempts by connection, user delivery server and result.",
+	}, []string{"connection_id", "user_delivery_server_id", "result"})
+
+	deliveryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "delivery",
+		Name:      "duration_seconds",
+		Help:      "Webhook delivery latency by connection, user delivery server and result.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"connection_id", "user_delivery_server_id", "result"})
+
+	connectionLabels         = newLabelLimiter(DefaultMaxLabelValues)
+	userDeliveryServerLabels = newLabelLimiter(DefaultMaxLabelValues)
+)
+
+func init() {
+	registry.MustRegister(deliveries, deliveryDuration)
+}
+
+// SetMaxDeliveryLabelValues đặt số connection / user delivery server tối đa có series riêng,
+// phần vượt quá được gộp vào label "other"
+func SetMaxDeliveryLabelValues(max int) {
+	connectionLabels.setMax(max)
+	userDeliveryServerLabels.setMax(max)
+}
+
+// ObserveDelivery ghi kết quả và thời gian của một lần gửi webhook
+func ObserveDelivery(connectionId, userDeliveryServerId, result string, duration time.Duration) {
+	labels := []string{connectionLabels.value(connectionId), userDeliveryServerLabels.value(userDeliveryServerId), result}
+	deliveries.WithLabelValues(labels...).Inc()
+	deliveryDuration.WithLabelValues(labels...).Observe(duration.Seconds())
+}
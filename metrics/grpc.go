@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	grpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "gRPC unary calls by full method name and status code.",
+	}, []string{"method", "code"})
+
+	grpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "gRPC unary call latency by full method name and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	grpcStreams = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "streams_total",
+		Help:      "gRPC streams (health Watch, reflection...) by full method name and status code.",
+	}, []string{"method", "code"})
+
+	// Stream như health Watch có thể mở suốt vòng đời của client nên bucket dài hơn unary call
+	grpcStreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "stream_duration_seconds",
+		Help:      "gRPC stream lifetime by full method name and status code.",
+		Buckets:   []float64{.1, 1, 10, 60, 300, 1800, 3600},
+	}, []string{"method", "code"})
+)
+
+func init() {
+	registry.MustRegister(grpcRequests, grpcDuration, grpcStreams, grpcStreamDuration)
+}
+
+// UnaryServerInterceptor đo mọi unary call, đặt trước các interceptor xác thực để đếm cả call bị từ chối
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err).String()
+		grpcRequests.WithLabelValues(info.FullMethod, code).Inc()
+		grpcDuration.WithLabelValues(info.FullMethod, code).Observe(time.Since(start).Seconds())
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor giống UnaryServerInterceptor cho stream, ghi khi stream kết thúc
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+
+		code := status.Code(err).String()
+		grpcStreams.WithLabelValues(info.FullMethod, code).Inc()
+		grpcStreamDuration.WithLabelValues(info.FullMethod, code).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
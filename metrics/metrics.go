@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prefix của mọi metric do service export
+const namespace = "notification"
+
+// Registry riêng thay vì prometheus.DefaultRegisterer để chỉ export các metric của service
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler trả về các metric theo định dạng Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// DefaultMaxLabelValues là số giá trị khác nhau tối đa của một label có cardinality cao
+const DefaultMaxLabelValues = 1000
+
+// OtherLabelValue thay cho các giá trị vượt quá giới hạn cardinality
+const OtherLabelValue = "other"
+
+// labelLimiter giới hạn số giá trị khác nhau của một label (connection, user delivery server),
+// các giá trị mới sau khi đạt giới hạn được gộp vào OtherLabelValue
+type labelLimiter struct {
+	mu   sync.Mutex
+	max  int
+	seen map[string]struct{}
+}
+
+func newLabelLimiter(max int) *labelLimiter {
+	return &labelLimiter{max: max, seen: map[string]struct{}{}}
+}
+
+func (l *labelLimiter) value(v string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.seen[v]; ok {
+		return v
+	}
+	if len(l.seen) >= l.max {
+		return OtherLabelValue
+	}
+	l.seen[v] = struct{}{}
+	return v
+}
+
+func (l *labelLimiter) setMax(max int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.max = max
+}
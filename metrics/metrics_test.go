@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLabelLimiter(t *testing.T) {
+	tests := []struct {
+		name   string
+		max    int
+		values []string
+		want   []string
+	}{
+		{"under the limit", 3, []string{"a", "b", "a"}, []string{"a", "b", "a"}},
+		{"new values over the limit", 2, []string{"a", "b", "c", "a", "d"}, []string{"a", "b", OtherLabelValue, "a", OtherLabelValue}},
+		{"no values allowed", 0, []string{"a"}, []string{OtherLabelValue}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newLabelLimiter(tt.max)
+			got := make([]string, 0, len(tt.values))
+			for _, v := range tt.values {
+				got = append(got, limiter.value(v))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("values = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObserveDelivery(t *testing.T) {
+	oldConnections, oldServers := connectionLabels, userDeliveryServerLabels
+	connectionLabels, userDeliveryServerLabels = newLabelLimiter(DefaultMaxLabelValues), newLabelLimiter(DefaultMaxLabelValues)
+	t.Cleanup(func() { connectionLabels, userDeliveryServerLabels = oldConnections, oldServers })
+	SetMaxDeliveryLabelValues(1)
+
+	ObserveDelivery("conn-1", "server-1", DeliverySuccess, time.Millisecond)
+	ObserveDelivery("conn-1", "server-1", DeliverySuccess, time.Millisecond)
+	ObserveDelivery("conn-1", "server-1", DeliveryFailure, time.Millisecond)
+	// Connection và server mới sau khi đạt giới hạn được gộp vào "other"
+	ObserveDelivery("conn-2", "server-2", DeliveryFailure, time.Millisecond)
+	ObserveDelivery("conn-3", "server-1", DeliveryFailure, time.Millisecond)
+
+	tests := []struct {
+		labels []string
+		want   float64
+	}{
+		{[]string{"conn-1", "server-1", DeliverySuccess}, 2},
+		{[]string{"conn-1", "server-1", DeliveryFailure}, 1},
+		{[]string{OtherLabelValue, OtherLabelValue, DeliveryFailure}, 1},
+		{[]string{OtherLabelValue, "server-1", DeliveryFailure}, 1},
+		{[]string{"conn-2", "server-2", DeliveryFailure}, 0},
+	}
+	for _, tt := range tests {
+		if got := testutil.ToFloat64(deliveries.WithLabelValues(tt.labels...)); got != tt.want {
+			t.Errorf("deliveries%v = %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+	if got := testutil.CollectAndCount(deliveryDuration); got != 4 {
+		t.Errorf("delivery duration series = %d, want 4", got)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	const method = "/test.Service/Watch"
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"stream ends normally", nil, codes.OK},
+		{"stream rejected", status.Error(codes.Unauthenticated, "missing api key"), codes.Unauthenticated},
+	}
+
+	interceptor := StreamServerInterceptor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := grpcStreams.WithLabelValues(method, tt.code.String())
+			before := testutil.ToFloat64(counter)
+			err := interceptor(nil, nil, &grpc.StreamServerInfo{FullMethod: method}, func(srv interface{}, stream grpc.ServerStream) error {
+				return tt.err
+			})
+			if err != tt.err {
+				t.Fatalf("interceptor = %v, want %v", err, tt.err)
+			}
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Fatalf("streams_total{code=%s} increased by %v, want 1", tt.code, got)
+			}
+		})
+	}
+}
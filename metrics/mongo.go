@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+var (
+	mongoOpenConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mongo_pool",
+		Name:      "open_connections",
+		Help:      "Connections currently open in the Mongo connection pools.",
+	})
+
+	mongoInUseConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mongo_pool",
+		Name:      "in_use_connections",
+		Help:      "Connections currently checked out of the Mongo connection pools.",
+	})
+
+	mongoCheckoutFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mongo_pool",
+		Name:      "checkout_failures_total",
+		Help:      "Failed connection checkouts by reason.",
+	}, []string{"reason"})
+
+	mongoCheckoutDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "mongo_pool",
+		Name:      "checkout_duration_seconds",
+		Help:      "Time spent waiting for a connection from the Mongo pool.",
+		Buckets:   []float64{.0005, .001, .005, .01, .05, .1, .5, 1, 5},
+	})
+
+	mongoPoolCleared = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mongo_pool",
+		Name:      "cleared_total",
+		Help:      "Times a Mongo connection pool was cleared after an error.",
+	})
+)
+
+func init() {
+	registry.MustRegister(mongoOpenConnections, mongoInUseConnections, mongoCheckoutFailures, mongoCheckoutDuration, mongoPoolCleared)
+}
+
+// MongoPoolMonitor cập nhật metric của connection pool từ event của Mongo driver
+func MongoPoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{Event: func(e *event.PoolEvent) {
+		switch e.Type {
+		case event.ConnectionCreated:
+			mongoOpenConnections.Inc()
+		case event.ConnectionClosed:
+			mongoOpenConnections.Dec()
+		case event.GetSucceeded:
+			mongoInUseConnections.Inc()
+			mongoCheckoutDuration.Observe(e.Duration.Seconds())
+		case event.ConnectionReturned:
+			mongoInUseConnections.Dec()
+		case event.GetFailed:
+			mongoCheckoutFailures.WithLabelValues(e.Reason).Inc()
+		case event.PoolCleared:
+			mongoPoolCleared.Inc()
+		}
+	}}
+}
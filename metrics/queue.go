@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Kết quả xử lý một job của worker
+const (
+	JobResultDone     = "done"
+	JobResultRetry    = "retry"
+	JobResultFailed   = "failed"
+	JobResultReleased = "released"
+)
+
+var jobsProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "queue",
+	Name:      "jobs_processed_total",
+	Help:      "Jobs processed by the workers by result (done, retry, failed, released).",
+}, []string{"result"})
+
+func init() {
+	registry.MustRegister(jobsProcessed)
+}
+
+// ObserveJob ghi kết quả xử lý một job, result retry là số lần retry
+func ObserveJob(result string) {
+	jobsProcessed.WithLabelValues(result).Inc()
+}
+
+// QueueStats là trạng thái hiện tại của hàng đợi
+type QueueStats struct {
+	// Số job theo status (pending, leased, failed)
+	Depth map[string]int64
+	// Tuổi của lease lâu nhất đang được giữ
+	OldestLeaseAge time.Duration
+	// Thời gian job pending đến hạn lâu nhất đã phải chờ
+	OldestPendingAge time.Duration
+}
+
+// queueCollector đọc trạng thái hàng đợi từ storage mỗi lần Prometheus scrape
+type queueCollector struct {
+	stats func(ctx context.Context) (QueueStats, error)
+
+	depth          *prometheus.Desc
+	oldestLeaseAge *prometheus.Desc
+	oldestPending  *prometheus.Desc
+	up             *prometheus.Desc
+}
+
+// RegisterQueue export độ sâu và tuổi của hàng đợi, stats được gọi mỗi lần scrape
+func RegisterQueue(stats func(ctx context.Context) (QueueStats, error)) {
+	collector := &queueCollector{
+		stats:          stats,
+		depth:          prometheus.NewDesc(namespace+"_queue_depth", "Jobs in the queue by status.", []string{"status"}, nil),
+		oldestLeaseAge: prometheus.NewDesc(namespace+"_queue_oldest_lease_age_seconds", "Age of the oldest lease still held by a worker.", nil, nil),
+		oldestPending:  prometheus.NewDesc(namespace+"_queue_oldest_pending_age_seconds", "How long the oldest due pending job has been waiting.", nil, nil),
+		up:             prometheus.NewDesc(namespace+"_queue_stats_up", "Whether the last read of the queue stats succeeded.", nil, nil),
+	}
+	if err := registry.Register(collector); err != nil {
+		slog.Error("Đăng ký queue metrics lỗi", "error", err)
+	}
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.depth
+	ch <- c.oldestLeaseAge
+	ch <- c.oldestPending
+	ch <- c.up
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stats, err := c.stats(ctx)
+	if err != nil {
+		slog.Error("Đọc queue stats lỗi", "error", err)
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1)
+	for status, count := range stats.Depth {
+		ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(count), status)
+	}
+	ch <- prometheus.MustNewConstMetric(c.oldestLeaseAge, prometheus.GaugeValue, stats.OldestLeaseAge.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.oldestPending, prometheus.GaugeValue, stats.OldestPendingAge.Seconds())
+}
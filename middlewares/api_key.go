@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"draft-notification/apperrors"
+	"draft-notification/auth"
+	"draft-notification/helpers"
+	"errors"
+
+	"github.com/labstack/echo/v4"
+)
+
+const apiKeyIdentityKey = "apiKeyIdentity"
+
+// Header chứa API key của webview server / connection gọi vào các route /api
+const ApiKeyHeader = "X-Api-Key"
+
+// ApiKeyPathPrefix là prefix của các route xác thực bằng API key thay vì access token
+const ApiKeyPathPrefix = "/api/"
+
+// ValidateApiKey xác thực API key từ header, IP client lấy theo IPExtractor của echo
+func ValidateApiKey(authenticator *auth.ApiKeyAuthenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx, cancel := helpers.CreateRequestContext(c)
+			defer cancel()
+
+			identity, err := authenticator.Authenticate(ctx, c.Request().Header.Get(ApiKeyHeader), c.RealIP())
+			switch {
+			case errors.Is(err, auth.ErrInvalidApiKey):
+				return helpers.HandleError(c, apperrors.Unauthorized("invalid_api_key"))
+			case errors.Is(err, auth.ErrIpNotAllowed):
+				return helpers.HandleError(c, apperrors.Forbidden("ip_not_allowed").
+					WithData(map[string]string{"ip": c.RealIP()}))
+			case err != nil:
+				return helpers.HandleError(c, apperrors.Unavailable("api_key_unavailable", err))
+			}
+
+			c.Set(apiKeyIdentityKey, identity)
+			return next(c)
+		}
+	}
+}
+
+// RequireScope chỉ cho phép API key có scope truy cập route
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			identity, ok := CurrentApiKey(c)
+			if !ok || !identity.HasScope(scope) {
+				return helpers.HandleError(c, apperrors.Forbidden("missing_scope").
+					WithData(map[string]string{"scope": scope}))
+			}
+			return next(c)
+		}
+	}
+}
+
+// CurrentApiKey trả về chủ sở hữu API key của request
+func CurrentApiKey(c echo.Context) (auth.ApiKeyIdentity, bool) {
+	identity, ok := c.Get(apiKeyIdentityKey).(auth.ApiKeyIdentity)
+	return identity, ok
+}
@@ -1,20 +1,74 @@
 package middlewares
 
 import (
-	"net/http"
+	"draft-notification/apperrors"
+	"draft-notification/auth"
+	"draft-notification/helpers"
+	"draft-notification/repositories"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
-// Middleware để kiểm tra token từ request header
-func ValidateToken(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		token := c.Request().Header.Get("Authorization")
+const claimsKey = "claims"
 
-		if token != "Bearer vinh" {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid token"})
-		}
+// Các route không cần access token
+var publicPaths = map[string]bool{
+	"/auth/login":   true,
+	"/auth/refresh": true,
+	"/metrics":      true,
+	"/healthz":      true,
+	"/readyz":       true,
+}
+
+// Middleware để kiểm tra access token (JWT) từ request header và session của nó.
+// Các route /api dùng API key (ValidateApiKey) nên được bỏ qua.
+func ValidateToken(tokens *auth.TokenIssuer, sessions repositories.AdminSessionRepo) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if publicPaths[c.Path()] || strings.HasPrefix(c.Path(), ApiKeyPathPrefix) {
+				return next(c)
+			}
+
+			header := c.Request().Header.Get("Authorization")
+			tokenString, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				return invalidToken(c)
+			}
+
+			claims, err := tokens.ParseAccessToken(tokenString)
+			if err != nil {
+				return invalidToken(c)
+			}
+
+			ctx, cancel := helpers.CreateRequestContext(c)
+			defer cancel()
+
+			// Session bị thu hồi (logout) thì access token cũng hết hiệu lực
+			session, err := sessions.FindById(ctx, claims.SessionId)
+			if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+				return helpers.HandleError(c, err)
+			}
+			if err != nil || !session.Active(time.Now()) {
+				return invalidToken(c)
+			}
 
-		return next(c)
+			c.Set(claimsKey, claims)
+			return next(c)
+		}
 	}
 }
+
+// CurrentClaims trả về claims của admin đang đăng nhập, nil nếu route là public
+func CurrentClaims(c echo.Context) *auth.Claims {
+	claims, _ := c.Get(claimsKey).(*auth.Claims)
+	return claims
+}
+
+var errInvalidToken = apperrors.Unauthorized("invalid_token")
+
+func invalidToken(c echo.Context) error {
+	return helpers.HandleError(c, errInvalidToken)
+}
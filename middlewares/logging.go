@@ -0,0 +1,50 @@
+package middlewares
+
+import (
+	"draft-notification/logging"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequestLog gắn request ID (do middleware.RequestID tạo) vào logger của request
+// và ghi một dòng log cho mỗi request
+func RequestLog() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			logger := slog.Default().With("request_id", c.Response().Header().Get(echo.HeaderXRequestID))
+			req := c.Request()
+			c.SetRequest(req.WithContext(logging.NewContext(req.Context(), logger)))
+
+			err := next(c)
+
+			status := c.Response().Status
+			if err != nil {
+				status = http.StatusInternalServerError
+				var he *echo.HTTPError
+				if errors.As(err, &he) {
+					status = he.Code
+				}
+			}
+
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logger.Log(c.Request().Context(), level, "HTTP request",
+				"method", req.Method,
+				"route", c.Path(),
+				"uri", req.RequestURI,
+				"status", status,
+				"duration_ms", time.Since(start).Milliseconds(),
+				"remote_ip", c.RealIP(),
+			)
+			return err
+		}
+	}
+}
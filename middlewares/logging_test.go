@@ -0,0 +1,87 @@
+package middlewares
+
+import (
+	"bytes"
+	"draft-notification/logging"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// captureLogs đặt logger mặc định ghi JSON vào buffer và trả về các record đã ghi
+func captureLogs(t *testing.T) func() []map[string]any {
+	t.Helper()
+	old := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(old) })
+	var buf bytes.Buffer
+	if err := logging.Setup(&buf, "debug", logging.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	return func() []map[string]any {
+		var records []map[string]any
+		decoder := json.NewDecoder(&buf)
+		for decoder.More() {
+			var record map[string]any
+			if err := decoder.Decode(&record); err != nil {
+				t.Fatal(err)
+			}
+			records = append(records, record)
+		}
+		return records
+	}
+}
+
+func TestRequestLog(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestId string
+	}{
+		{"incoming request id is echoed", "req-123"},
+		{"missing request id is generated", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := captureLogs(t)
+			e := echo.New()
+			e.Use(middleware.RequestID())
+			e.Use(RequestLog())
+			e.GET("/things/:id", func(c echo.Context) error {
+				logging.FromContext(c.Request().Context()).Info("handler")
+				return c.NoContent(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/things/1", nil)
+			if tt.requestId != "" {
+				req.Header.Set(echo.HeaderXRequestID, tt.requestId)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			requestId := rec.Header().Get(echo.HeaderXRequestID)
+			if requestId == "" || (tt.requestId != "" && requestId != tt.requestId) {
+				t.Fatalf("response %s = %q, want %q", echo.HeaderXRequestID, requestId, tt.requestId)
+			}
+
+			// Log của handler và dòng log của request cùng mang request id trả về cho client
+			got := records()
+			if len(got) != 2 {
+				t.Fatalf("logged %d records, want 2: %v", len(got), got)
+			}
+			for _, record := range got {
+				if record["request_id"] != requestId {
+					t.Errorf("%q request_id = %v, want %q", record["msg"], record["request_id"], requestId)
+				}
+			}
+			access := got[1]
+			if access["msg"] != "HTTP request" || access["route"] != "/things/:id" || access["status"] != float64(http.StatusNoContent) {
+				t.Errorf("access log = %v", access)
+			}
+		})
+	}
+}
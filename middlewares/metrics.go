@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"draft-notification/metrics"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Metrics ghi số request và latency theo route template và status code
+func Metrics() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			// Lỗi chưa được ghi vào response, lấy status từ lỗi giống HTTPErrorHandler mặc định
+			status := c.Response().Status
+			if err != nil {
+				status = http.StatusInternalServerError
+				var he *echo.HTTPError
+				if errors.As(err, &he) {
+					status = he.Code
+				}
+			}
+
+			// Request không khớp route nào gộp chung để không tạo series theo URL tuỳ ý
+			route := c.Path()
+			if route == "" || route == "/*" {
+				route = "unmatched"
+			}
+
+			metrics.ObserveHTTPRequest(c.Request().Method, route, status, time.Since(start))
+			return err
+		}
+	}
+}
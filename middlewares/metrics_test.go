@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"draft-notification/metrics"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestMetricsRouteLabel(t *testing.T) {
+	e := echo.New()
+	e.Use(Metrics())
+	e.GET("/metrics-test/:id", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	e.POST("/metrics-test/:id/fail", func(c echo.Context) error { return echo.NewHTTPError(http.StatusConflict) })
+
+	requests := []struct {
+		method, path string
+	}{
+		{http.MethodGet, "/metrics-test/1"},
+		{http.MethodGet, "/metrics-test/2"},
+		{http.MethodGet, "/metrics-test/3"},
+		{http.MethodPost, "/metrics-test/4/fail"},
+		{http.MethodGet, "/random-1"},
+		{http.MethodGet, "/random-2/x/y"},
+	}
+	for _, r := range requests {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(r.method, r.path, nil))
+	}
+
+	// Id trong URL và URL không khớp route không tạo series riêng
+	want := []string{
+		`method="GET",route="/metrics-test/:id",status="200"} 3`,
+		`method="GET",route="unmatched",status="404"} 2`,
+		`method="POST",route="/metrics-test/:id/fail",status="409"} 1`,
+	}
+	if got := scrapeHTTPRequests(t); !reflect.DeepEqual(got, want) {
+		t.Fatalf("http_requests_total series = %q, want %q", got, want)
+	}
+}
+
+var httpRequestsLine = regexp.MustCompile(`(?m)^notification_http_requests_total\{(.*)$`)
+
+func scrapeHTTPRequests(t *testing.T) []string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	var series []string
+	for _, match := range httpRequestsLine.FindAllStringSubmatch(rec.Body.String(), -1) {
+		series = append(series, match[1])
+	}
+	sort.Strings(series)
+	return series
+}
@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"draft-notification/apperrors"
+	"draft-notification/auth"
+	"draft-notification/helpers"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequirePermission chỉ cho phép admin có role chứa permission truy cập route
+func RequirePermission(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims := CurrentClaims(c)
+			if claims == nil || !auth.HasPermission(claims.Role, permission) {
+				role := ""
+				if claims != nil {
+					role = claims.Role
+				}
+				return helpers.HandleError(c, apperrors.Forbidden("missing_permission").
+					WithData(map[string]string{"permission": permission, "role": role}))
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Admin struct {
+	Id             primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	OrganizationId primitive.ObjectID `json:"organizationId,omitempty" bson:"organizationId"`
+	Username       string             `json:"username,omitempty" bson:"username"`
+	Role           string             `json:"role,omitempty" bson:"role"`
+	PasswordHash   string             `json:"-" bson:"passwordHash"`
+	CreatedAt      time.Time          `json:"createdAt,omitempty" bson:"createdAt"`
+	UpdatedAt      time.Time          `json:"updatedAt,omitempty" bson:"updatedAt"`
+}
+
+// AdminSession là một phiên đăng nhập, access token chứa Id của session để có thể thu hồi
+type AdminSession struct {
+	Id               primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	AdminId          primitive.ObjectID `json:"adminId,omitempty" bson:"adminId"`
+	RefreshTokenHash string             `json:"-" bson:"refreshTokenHash"`
+	ExpiresAt        time.Time          `json:"expiresAt,omitempty" bson:"expiresAt"`
+	RevokedAt        time.Time          `json:"revokedAt,omitempty" bson:"revokedAt"`
+	CreatedAt        time.Time          `json:"createdAt,omitempty" bson:"createdAt"`
+	UpdatedAt        time.Time          `json:"updatedAt,omitempty" bson:"updatedAt"`
+}
+
+// Active cho biết session còn dùng được tại thời điểm now
+func (s AdminSession) Active(now time.Time) bool {
+	return s.RevokedAt.IsZero() && now.Before(s.ExpiresAt)
+}
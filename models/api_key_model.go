@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scoped API key được cấp cho một webview server hoặc một connection
+const (
+	ApiKeyOwnerWebviewServer = "webviewServer"
+	ApiKeyOwnerConnection    = "connection"
+)
+
+// ApiKey là key bổ sung chỉ có các scope được liệt kê, khác với key chính của connection
+// (có toàn quyền cho phía của nó)
+type ApiKey struct {
+	Id             primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	OrganizationId primitive.ObjectID `json:"organizationId,omitempty" bson:"organizationId"`
+	Name           string             `json:"name,omitempty" bson:"name"`
+	OwnerType      string             `json:"ownerType,omitempty" bson:"ownerType"`
+	OwnerId        primitive.ObjectID `json:"ownerId,omitempty" bson:"ownerId"`
+	// Side là phía của connection mà key đại diện (ApiKeySide...), luôn là webviewServer với key của webview server
+	Side       string             `json:"side,omitempty" bson:"side"`
+	Scopes     []string           `json:"scopes" bson:"scopes"`
+	AllowedIps []string           `json:"allowedIps,omitempty" bson:"allowedIps"`
+	Prefix     string             `json:"prefix,omitempty" bson:"prefix"`
+	Hash       string             `json:"-" bson:"hash"`
+	ExpiresAt  time.Time          `json:"expiresAt,omitempty" bson:"expiresAt"`
+	RevokedAt  time.Time          `json:"revokedAt,omitempty" bson:"revokedAt"`
+	CreatedBy  primitive.ObjectID `json:"createdBy,omitempty" bson:"createdBy"`
+	CreatedAt  time.Time          `json:"createdAt,omitempty" bson:"createdAt"`
+	UpdatedAt  time.Time          `json:"updatedAt,omitempty" bson:"updatedAt"`
+}
+
+// Active cho biết key chưa bị thu hồi và chưa hết hạn tại thời điểm now
+func (k ApiKey) Active(now time.Time) bool {
+	return k.RevokedAt.IsZero() && (k.ExpiresAt.IsZero() || now.Before(k.ExpiresAt))
+}
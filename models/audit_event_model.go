@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Loại entity được ghi audit
+const (
+	AuditEntityWebviewServer      = "webviewServer"
+	AuditEntityUserDeliveryServer = "userDeliveryServer"
+	AuditEntityConnection         = "connection"
+	AuditEntityConnectionConsent  = "connectionConsent"
+	AuditEntityApiKey             = "apiKey"
+	AuditEntityAdmin              = "admin"
+)
+
+// Các thao tác được ghi audit
+const (
+	AuditActionCreate              = "create"
+	AuditActionUpdate              = "update"
+	AuditActionDelete              = "delete"
+	AuditActionChangeStatus        = "changeStatus"
+	AuditActionChangeRole          = "changeRole"
+	AuditActionUpdateWebhookUrl    = "updateWebhookUrl"
+	AuditActionVerifyWebhook       = "verifyWebhook"
+	AuditActionRotateApiKey        = "rotateApiKey"
+	AuditActionRotateWebhookSecret = "rotateWebhookSecret"
+	AuditActionRevoke              = "revoke"
+)
+
+// AuditEvent ghi lại một thay đổi dữ liệu, thay đổi dây chuyền (ví dụ connection bị
+// deactivate theo server) có ParentId là event gây ra nó
+type AuditEvent struct {
+	Id             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OrganizationId primitive.ObjectID `json:"organizationId" bson:"organizationId"`
+	ActorId        primitive.ObjectID `json:"actorId,omitempty" bson:"actorId"`
+	ActorUsername  string             `json:"actorUsername,omitempty" bson:"actorUsername"`
+	Action         string             `json:"action" bson:"action"`
+	EntityType     string             `json:"entityType" bson:"entityType"`
+	EntityId       primitive.ObjectID `json:"entityId" bson:"entityId"`
+	Changes        []AuditChange      `json:"changes" bson:"changes"`
+	ParentId       primitive.ObjectID `json:"parentId,omitempty" bson:"parentId"`
+	RequestId      string             `json:"requestId,omitempty" bson:"requestId"`
+	SourceIp       string             `json:"sourceIp,omitempty" bson:"sourceIp"`
+	CreatedAt      time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// AuditChange là giá trị trước/sau của một field, field lồng nhau dùng dạng "a.b"
+type AuditChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before,omitempty" bson:"before"`
+	After  interface{} `json:"after,omitempty" bson:"after"`
+}
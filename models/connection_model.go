@@ -1,38 +1,84 @@
 package models
 
 import (
+	"draft-notification/encryption"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Connection struct {
-	Id                           primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Status                       string             `json:"status,omitempty"`
-	CreatedAt                    time.Time          `json:"createdAt,omitempty"`
-	UpdatedAt                    time.Time          `json:"updatedAt,omitempty"`
-	WebviewServerApiKey          string             `json:"webviewServerApiKey,omitempty"`
-	UserDeliveryServerApiKey     string             `json:"userDeliveryServerApiKey,omitempty"`
-	WebviewServerId              primitive.ObjectID `json:"webviewServerId,omitempty" validate:"required"`
-	UserDeliveryServerId         primitive.ObjectID `json:"userDeliveryServerId,omitempty"`
-	UserDeliveryServerWebHookUrl string             `json:"userDeliveryServerWebHookUrl,omitempty"`
+	Id                               primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	OrganizationId                   primitive.ObjectID `json:"organizationId,omitempty" bson:"organizationId"`
+	Status                           string             `json:"status,omitempty" bson:"status"`
+	CreatedAt                        time.Time          `json:"createdAt,omitempty" bson:"createdAt"`
+	UpdatedAt                        time.Time          `json:"updatedAt,omitempty" bson:"updatedAt"`
+	WebviewServerApiKeyPrefix        string             `json:"webviewServerApiKeyPrefix,omitempty" bson:"webviewServerApiKeyPrefix"`
+	WebviewServerApiKeyHash          string             `json:"-" bson:"webviewServerApiKeyHash"`
+	UserDeliveryServerApiKeyPrefix   string             `json:"userDeliveryServerApiKeyPrefix,omitempty" bson:"userDeliveryServerApiKeyPrefix"`
+	UserDeliveryServerApiKeyHash     string             `json:"-" bson:"userDeliveryServerApiKeyHash"`
+	WebviewServerId                  primitive.ObjectID `json:"webviewServerId,omitempty" bson:"webviewServerId" validate:"required"`
+	UserDeliveryServerId             primitive.ObjectID `json:"userDeliveryServerId,omitempty" bson:"userDeliveryServerId"`
+	UserDeliveryServerWebHookUrl     string             `json:"userDeliveryServerWebHookUrl,omitempty" bson:"userDeliveryServerWebHookUrl"`
+	WebviewServerApiKeyRotation      *ApiKeyRotation    `json:"webviewServerApiKeyRotation,omitempty" bson:"webviewServerApiKeyRotation"`
+	UserDeliveryServerApiKeyRotation *ApiKeyRotation    `json:"userDeliveryServerApiKeyRotation,omitempty" bson:"userDeliveryServerApiKeyRotation"`
+	// Secret dùng ký challenge xác minh webhook và ký payload gửi tới webhook
+	WebhookSecret      encryption.EncryptedString `json:"-" bson:"webhookSecret"`
+	WebhookVerifiedUrl string                     `json:"webhookVerifiedUrl,omitempty" bson:"webhookVerifiedUrl"`
+	WebhookVerifiedAt  time.Time                  `json:"webhookVerifiedAt,omitempty" bson:"webhookVerifiedAt"`
+}
+
+// WebhookVerified cho biết webhook URL hiện tại đã qua bước xác minh chưa
+func (c Connection) WebhookVerified() bool {
+	return c.UserDeliveryServerWebHookUrl != "" && c.WebhookVerifiedUrl == c.UserDeliveryServerWebHookUrl && !c.WebhookVerifiedAt.IsZero()
+}
+
+// Mỗi connection có một API key cho từng phía
+const (
+	ApiKeySideWebviewServer      = "webviewServer"
+	ApiKeySideUserDeliveryServer = "userDeliveryServer"
+)
+
+// ApiKeyRotation ghi lại lần rotate gần nhất của một phía, key cũ vẫn hợp lệ tới PreviousKeyExpiresAt
+type ApiKeyRotation struct {
+	PreviousKeyPrefix    string             `json:"previousKeyPrefix,omitempty" bson:"previousKeyPrefix"`
+	PreviousKeyHash      string             `json:"-" bson:"previousKeyHash"`
+	PreviousKeyExpiresAt time.Time          `json:"previousKeyExpiresAt,omitempty" bson:"previousKeyExpiresAt"`
+	RotatedBy            primitive.ObjectID `json:"rotatedBy,omitempty" bson:"rotatedBy"`
+	RotatedAt            time.Time          `json:"rotatedAt,omitempty" bson:"rotatedAt"`
+}
+
+// PreviousKeyActive cho biết key cũ còn được chấp nhận tại thời điểm now không
+func (r *ApiKeyRotation) PreviousKeyActive(now time.Time) bool {
+	return r != nil && r.PreviousKeyHash != "" && now.Before(r.PreviousKeyExpiresAt)
 }
 
 // Struct chứa thông tin của WebviewServer & UserDeliveryServer
 type ServerInfo struct {
 	Id   primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Name string             `json:"name,omitempty"`
+	Name string             `json:"name,omitempty" bson:"name"`
+}
+
+// ConnectionWithServers là connection kèm thông tin webview server và user delivery server của nó,
+// server không còn tồn tại thì ServerInfo rỗng
+type ConnectionWithServers struct {
+	Connection         `bson:",inline"`
+	WebviewServer      ServerInfo `bson:"webviewServer"`
+	UserDeliveryServer ServerInfo `bson:"userDeliveryServer"`
 }
 
 // Struct chứa danh sách connection kèm thông tin server
 type ConnectionResponse struct {
-	Id                           primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Status                       string             `json:"status,omitempty"`
-	CreatedAt                    time.Time          `json:"createdAt,omitempty"`
-	UpdatedAt                    time.Time          `json:"updatedAt,omitempty"`
-	WebviewServerApiKey          string             `json:"webviewServerApiKey,omitempty"`
-	UserDeliveryServerApiKey     string             `json:"userDeliveryServerApiKey,omitempty"`
-	WebviewServer                ServerInfo         `json:"webviewServer,omitempty"`
-	UserDeliveryServer           ServerInfo         `json:"userDeliveryServer,omitempty"`
-	UserDeliveryServerWebHookUrl string             `json:"userDeliveryServerWebHookUrl,omitempty"`
+	Id                               primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Status                           string             `json:"status,omitempty"`
+	CreatedAt                        time.Time          `json:"createdAt,omitempty"`
+	UpdatedAt                        time.Time          `json:"updatedAt,omitempty"`
+	WebviewServerApiKey              string             `json:"webviewServerApiKey,omitempty"`
+	UserDeliveryServerApiKey         string             `json:"userDeliveryServerApiKey,omitempty"`
+	WebviewServer                    ServerInfo         `json:"webviewServer,omitempty"`
+	UserDeliveryServer               ServerInfo         `json:"userDeliveryServer,omitempty"`
+	UserDeliveryServerWebHookUrl     string             `json:"userDeliveryServerWebHookUrl,omitempty"`
+	WebviewServerApiKeyRotation      *ApiKeyRotation    `json:"webviewServerApiKeyRotation,omitempty"`
+	UserDeliveryServerApiKeyRotation *ApiKeyRotation    `json:"userDeliveryServerApiKeyRotation,omitempty"`
+	WebhookVerified                  bool               `json:"webhookVerified"`
 }
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"draft-notification/encryption"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	JobStatusPending = "pending"
+	JobStatusLeased  = "leased"
+	JobStatusDone    = "done"
+	JobStatusFailed  = "failed"
+)
+
+type Job struct {
+	Id             primitive.ObjectID         `json:"id,omitempty" bson:"_id,omitempty"`
+	ConnectionId   primitive.ObjectID         `json:"connectionId,omitempty" bson:"connectionId"`
+	Message        encryption.EncryptedString `json:"message,omitempty" bson:"message"`
+	Status         string                     `json:"status,omitempty" bson:"status"`
+	Attempts       int                        `json:"attempts" bson:"attempts"`
+	LastError      string                     `json:"lastError,omitempty" bson:"lastError"`
+	RunAt          time.Time                  `json:"runAt,omitempty" bson:"runAt"`
+	LeasedAt       time.Time                  `json:"leasedAt,omitempty" bson:"leasedAt"`
+	LeaseExpiresAt time.Time                  `json:"leaseExpiresAt,omitempty" bson:"leaseExpiresAt"`
+	// LeaseId đổi sau mỗi lần lease, Ack/Release/Fail chỉ áp dụng cho đúng lần lease đã lấy job
+	LeaseId   primitive.ObjectID `json:"-" bson:"leaseId"`
+	CreatedAt time.Time          `json:"createdAt,omitempty" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt,omitempty" bson:"updatedAt"`
+	// W3C trace context (traceparent, tracestate) của request đưa job vào hàng đợi
+	TraceContext map[string]string `json:"-" bson:"traceContext"`
+}
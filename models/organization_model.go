@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Organization struct {
+	Id        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name      string             `json:"name,omitempty" bson:"name" validate:"required"`
+	CreatedAt time.Time          `json:"createdAt,omitempty" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt,omitempty" bson:"updatedAt"`
+}
+
+// ConnectionConsent cho phép một organization khác tạo connection tới webview server
+type ConnectionConsent struct {
+	Id                    primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	OrganizationId        primitive.ObjectID `json:"organizationId,omitempty" bson:"organizationId"`
+	WebviewServerId       primitive.ObjectID `json:"webviewServerId,omitempty" bson:"webviewServerId"`
+	GranteeOrganizationId primitive.ObjectID `json:"granteeOrganizationId,omitempty" bson:"granteeOrganizationId" validate:"required"`
+	CreatedBy             primitive.ObjectID `json:"createdBy,omitempty" bson:"createdBy"`
+	CreatedAt             time.Time          `json:"createdAt,omitempty" bson:"createdAt"`
+}
@@ -7,9 +7,10 @@ import (
 )
 
 type UserDeliveryServer struct {
-	Id        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Name      string             `json:"name,omitempty" validate:"required"`
-	Status    string             `json:"status,omitempty"`
-	CreatedAt time.Time          `json:"createdAt,omitempty"`
-	UpdatedAt time.Time          `json:"updatedAt,omitempty"`
+	Id             primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	OrganizationId primitive.ObjectID `json:"organizationId,omitempty" bson:"organizationId"`
+	Name           string             `json:"name,omitempty" bson:"name" validate:"required"`
+	Status         string             `json:"status,omitempty" bson:"status"`
+	CreatedAt      time.Time          `json:"createdAt,omitempty" bson:"createdAt"`
+	UpdatedAt      time.Time          `json:"updatedAt,omitempty" bson:"updatedAt"`
 }
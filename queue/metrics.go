@@ -0,0 +1,29 @@
+package queue
+
+import (
+	"context"
+	"draft-notification/metrics"
+	"draft-notification/repositories"
+	"time"
+)
+
+// RegisterMetrics export độ sâu và tuổi của hàng đợi, đọc từ storage mỗi lần scrape
+func RegisterMetrics(jobs repositories.JobRepo) {
+	metrics.RegisterQueue(func(ctx context.Context) (metrics.QueueStats, error) {
+		stats, err := jobs.Stats(ctx)
+		if err != nil {
+			return metrics.QueueStats{}, err
+		}
+
+		now := time.Now()
+		result := metrics.QueueStats{Depth: stats.Counts}
+		if !stats.OldestLeasedAt.IsZero() {
+			result.OldestLeaseAge = now.Sub(stats.OldestLeasedAt)
+		}
+		// Job pending có RunAt trong tương lai (đang chờ retry) chưa được tính là chờ
+		if !stats.OldestRunAt.IsZero() && stats.OldestRunAt.Before(now) {
+			result.OldestPendingAge = now.Sub(stats.OldestRunAt)
+		}
+		return result, nil
+	})
+}
@@ -1,56 +1,48 @@
 package queue
 
 import (
-	"fmt"
+	"context"
+	"draft-notification/encryption"
+	"draft-notification/logging"
+	"draft-notification/models"
+	"draft-notification/repositories"
+	"draft-notification/tracing"
 	"time"
-)
-
-type Job struct {
-	ID      int
-	Message string
-}
 
-type Queue struct {
-	jobs []Job
-}
-
-func (q *Queue) Enqueue(job Job) {
-	q.jobs = append(q.jobs, job)
-}
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
 
-func (q *Queue) Dequeue() Job {
-	if len(q.jobs) == 0 {
-		return Job{}
+// Enqueue đưa một message của connection vào hàng đợi, job sẵn sàng để xử lý ngay.
+// Trace context của ctx được lưu cùng job để worker nối tiếp trace.
+func Enqueue(ctx context.Context, jobs repositories.JobRepo, connectionId primitive.ObjectID, message string) (models.Job, error) {
+	now := time.Now().UTC()
+	job := models.Job{
+		Id:           primitive.NewObjectID(),
+		ConnectionId: connectionId,
+		Message:      encryption.EncryptedString(message),
+		Status:       models.JobStatusPending,
+		RunAt:        now,
+		CreatedAt:    now,
+		UpdatedAt:    now,
 	}
-	job := q.jobs[0]
-	q.jobs = q.jobs[1:]
-	return job
-}
-
-func (q *Queue) IsEmpty() bool {
-	return len(q.jobs) == 0
-}
-
-func sendEmail(job Job) {
-	fmt.Printf("Sending email: %s\n", job.Message)
-	time.Sleep(2 * time.Second) // Giả lập thời gian gửi email
-	fmt.Printf("Email sent: %s\n", job.Message)
-}
-
-func RunQueue() {
-	queue := &Queue{}
-
-	// Đưa công việc vào hàng đợi
-	queue.Enqueue(Job{ID: 1, Message: "Welcome to our service!"})
-	queue.Enqueue(Job{ID: 2, Message: "Your account has been activated."})
-	queue.Enqueue(Job{ID: 3, Message: "Your password reset link."})
 
-	// Xử lý công việc trong hàng đợi
-	for !queue.IsEmpty() {
-		job := queue.Dequeue()
-		go sendEmail(job) // Xử lý công việc gửi email trong một goroutine
+	ctx, span := tracing.Tracer().Start(ctx, "queue enqueue", trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(
+		attribute.String("job.id", job.Id.Hex()),
+		attribute.String("connection.id", connectionId.Hex()),
+	))
+	defer span.End()
+
+	job.TraceContext = tracing.Inject(ctx)
+	if err := jobs.Enqueue(ctx, job); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return models.Job{}, err
 	}
 
-	// Đợi cho tất cả email được gửi
-	time.Sleep(3 * time.Second)
+	logging.FromContext(ctx).InfoContext(ctx, "Notification đã vào hàng đợi",
+		"notification_id", job.Id.Hex(), "connection_id", connectionId.Hex())
+	return job, nil
 }
@@ -0,0 +1,170 @@
+package queue
+
+import (
+	"context"
+	"draft-notification/logging"
+	"draft-notification/metrics"
+	"draft-notification/models"
+	"draft-notification/repositories"
+	"draft-notification/tracing"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Handler xử lý một job, ctx bị huỷ khi worker hết thời gian drain lúc shutdown
+type Handler func(ctx context.Context, job models.Job) error
+
+type Worker struct {
+	Jobs          repositories.JobRepo
+	Handler       Handler
+	Concurrency   int
+	LeaseDuration time.Duration
+	PollInterval  time.Duration
+	MaxAttempts   int
+
+	running atomic.Bool
+}
+
+// Running cho biết worker đang nhận job, dùng cho readiness check
+func (w *Worker) Running() bool {
+	return w.running.Load()
+}
+
+// Run xử lý job cho tới khi ctx bị huỷ. Khi đó worker ngừng lease job mới,
+// chờ các job đang chạy xong trong drainTimeout, sau đó huỷ chúng và trả job về hàng đợi.
+func (w *Worker) Run(ctx context.Context, drainTimeout time.Duration) error {
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	w.running.Store(true)
+	defer w.running.Store(false)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx, jobCtx)
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	slog.Info("Worker đang dừng, chờ job đang chạy", "drain_timeout", drainTimeout.String())
+
+	timer := time.NewTimer(drainTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		slog.Warn("Hết thời gian drain, huỷ các job đang chạy")
+		cancelJobs()
+		<-done
+	}
+	return nil
+}
+
+func (w *Worker) loop(ctx, jobCtx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.Jobs.Lease(ctx, w.LeaseDuration, w.MaxAttempts)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Lease job lỗi", "error", err)
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(w.PollInterval):
+			}
+			continue
+		}
+
+		w.process(jobCtx, *job)
+	}
+}
+
+func (w *Worker) process(jobCtx context.Context, job models.Job) {
+	// Span của job là con của request đã đưa job vào hàng đợi
+	handlerCtx, span := tracing.Tracer().Start(tracing.Extract(jobCtx, job.TraceContext), "queue process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("job.id", job.Id.Hex()),
+			attribute.String("connection.id", job.ConnectionId.Hex()),
+			attribute.Int("job.attempt", job.Attempts+1),
+		))
+	defer span.End()
+
+	// Mọi log trên đường gửi (worker và dispatcher) mang notification, connection và lần thử
+	logger := slog.Default().With(
+		"notification_id", job.Id.Hex(),
+		"connection_id", job.ConnectionId.Hex(),
+		"attempt", job.Attempts+1,
+	)
+	handlerCtx = logging.NewContext(handlerCtx, logger)
+
+	handlerErr := w.Handler(handlerCtx, job)
+	if handlerErr != nil {
+		span.RecordError(handlerErr)
+		span.SetStatus(codes.Error, handlerErr.Error())
+	}
+
+	// Dùng context riêng để vẫn cập nhật được trạng thái job khi đang shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var err error
+	switch {
+	case handlerErr == nil:
+		metrics.ObserveJob(metrics.JobResultDone)
+		logger.InfoContext(handlerCtx, "Job hoàn thành")
+		err = w.Jobs.Ack(ctx, job)
+	case jobCtx.Err() != nil && errors.Is(handlerErr, jobCtx.Err()):
+		logger.WarnContext(handlerCtx, "Job bị huỷ khi shutdown, trả về hàng đợi")
+		metrics.ObserveJob(metrics.JobResultReleased)
+		err = w.Jobs.Release(ctx, job)
+	default:
+		final := job.Attempts+1 >= w.MaxAttempts
+		if final {
+			metrics.ObserveJob(metrics.JobResultFailed)
+		} else {
+			metrics.ObserveJob(metrics.JobResultRetry)
+		}
+		logger.ErrorContext(handlerCtx, "Job lỗi", "error", handlerErr, "final", final)
+		err = w.Jobs.Fail(ctx, job, handlerErr, time.Now().Add(backoff(job.Attempts)), final)
+	}
+
+	switch {
+	case errors.Is(err, repositories.ErrLeaseLost):
+		// Lease hết hạn trong lúc xử lý, worker đang giữ job quyết định trạng thái của nó
+		logger.WarnContext(handlerCtx, "Job đã bị lease lại, bỏ qua kết quả")
+	case err != nil:
+		logger.ErrorContext(handlerCtx, "Cập nhật trạng thái job lỗi", "error", err)
+	}
+}
+
+// backoff tăng gấp đôi sau mỗi lần thử, tối đa 5 phút
+func backoff(attempts int) time.Duration {
+	delay := time.Second << attempts
+	if delay <= 0 || delay > 5*time.Minute {
+		return 5 * time.Minute
+	}
+	return delay
+}
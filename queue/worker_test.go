@@ -0,0 +1,186 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"draft-notification/logging"
+	"draft-notification/models"
+	"draft-notification/repositories"
+	"draft-notification/webhook"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWorkerDrain(t *testing.T) {
+	tests := []struct {
+		name         string
+		drainTimeout time.Duration
+		// handler chạy sau khi job đã bắt đầu và ctx của worker đã bị huỷ
+		handler    func(ctx context.Context) error
+		wantStatus string
+	}{
+		{
+			name:         "job finishes within drain timeout",
+			drainTimeout: time.Second,
+			handler: func(ctx context.Context) error {
+				time.Sleep(50 * time.Millisecond)
+				return ctx.Err()
+			},
+			wantStatus: models.JobStatusDone,
+		},
+		{
+			name:         "job outlives drain timeout",
+			drainTimeout: 50 * time.Millisecond,
+			handler: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			wantStatus: models.JobStatusPending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repositories.NewMemory()
+			job, err := Enqueue(context.Background(), repos.Jobs, [12]byte{1}, "hello")
+			if err != nil {
+				t.Fatalf("Enqueue: %v", err)
+			}
+
+			started := make(chan struct{})
+			stopped := make(chan struct{})
+			worker := &Worker{
+				Jobs: repos.Jobs,
+				Handler: func(ctx context.Context, job models.Job) error {
+					close(started)
+					<-stopped
+					return tt.handler(ctx)
+				},
+				Concurrency:   2,
+				LeaseDuration: time.Minute,
+				PollInterval:  10 * time.Millisecond,
+				MaxAttempts:   3,
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() { done <- worker.Run(ctx, tt.drainTimeout) }()
+
+			<-started
+			cancel()
+			close(stopped)
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("Run: %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Run did not return after drain")
+			}
+
+			// Job đang chạy lúc shutdown được ack hoặc trả về hàng đợi, không bị mất
+			got, err := repos.Jobs.FindById(context.Background(), job.Id)
+			if err != nil {
+				t.Fatalf("FindById: %v", err)
+			}
+			if got.Status != tt.wantStatus || got.Attempts != 0 {
+				t.Fatalf("job = status %s attempts %d, want %s attempts 0", got.Status, got.Attempts, tt.wantStatus)
+			}
+			if tt.wantStatus == models.JobStatusPending {
+				// Job được trả về với runAt là lúc Release, storage memory so thời gian tới millisecond
+				time.Sleep(2 * time.Millisecond)
+				leased, err := repos.Jobs.Lease(context.Background(), time.Minute, 3)
+				if err != nil || leased == nil || leased.Id != job.Id {
+					t.Fatalf("Lease after release = %v, %v, want job %s", leased, err, job.Id.Hex())
+				}
+			}
+		})
+	}
+}
+
+func TestWorkerDeliveryLogs(t *testing.T) {
+	old := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(old) })
+	var buf bytes.Buffer
+	if err := logging.Setup(&buf, "debug", logging.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer hook.Close()
+
+	ctx := context.Background()
+	repos := repositories.NewMemory()
+	now := time.Now().UTC()
+	connection := models.Connection{
+		Id:                           primitive.NewObjectID(),
+		WebviewServerId:              primitive.NewObjectID(),
+		UserDeliveryServerId:         primitive.NewObjectID(),
+		UserDeliveryServerWebHookUrl: hook.URL,
+		Status:                       "active",
+		CreatedAt:                    now,
+		UpdatedAt:                    now,
+	}
+	if err := repos.Connections.Create(ctx, connection); err != nil {
+		t.Fatal(err)
+	}
+	job, err := Enqueue(ctx, repos.Jobs, connection.Id, "hello")
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	dispatcher := webhook.NewDispatcher(repos.Connections, webhook.Policy{AllowPrivateNetworks: true}, time.Second)
+	worker := &Worker{Jobs: repos.Jobs, Handler: dispatcher.Deliver, Concurrency: 1, LeaseDuration: time.Minute, PollInterval: 10 * time.Millisecond, MaxAttempts: 3}
+	workerCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- worker.Run(workerCtx, time.Second) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := repos.Jobs.FindById(ctx, job.Id)
+		if err == nil && got.Status == models.JobStatusDone {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job = %+v, %v, want done", got, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	// Log của dispatcher và của worker cho job đều nối được về notification và connection
+	want := map[string]map[string]any{
+		"Webhook đã phản hồi": {"user_delivery_server_id": connection.UserDeliveryServerId.Hex(), "status": float64(http.StatusOK)},
+		"Job hoàn thành":      {},
+	}
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var record map[string]any
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		fields, ok := want[record["msg"].(string)]
+		if !ok {
+			continue
+		}
+		delete(want, record["msg"].(string))
+		fields["notification_id"] = job.Id.Hex()
+		fields["connection_id"] = connection.Id.Hex()
+		fields["attempt"] = float64(1)
+		for key, value := range fields {
+			if record[key] != value {
+				t.Errorf("%q %s = %v, want %v", record["msg"], key, record[key], value)
+			}
+		}
+	}
+	for msg := range want {
+		t.Errorf("missing log %q", msg)
+	}
+}
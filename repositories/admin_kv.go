@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type kvAdminRepo struct {
+	store kvStore
+}
+
+func (r *kvAdminRepo) Create(ctx context.Context, admin models.Admin) error {
+	return r.store.update(func(tx kvTx) error {
+		found, err := kvFind(tx, adminCollectionName, func(existing models.Admin) bool {
+			return existing.Username == admin.Username
+		})
+		if err != nil {
+			return err
+		}
+		if len(found) > 0 {
+			return ErrDuplicate
+		}
+		return kvPut(tx, adminCollectionName, admin.Id.Hex(), admin)
+	})
+}
+
+func (r *kvAdminRepo) FindById(ctx context.Context, id primitive.ObjectID) (admin models.Admin, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		admin, err = kvGet[models.Admin](tx, adminCollectionName, id.Hex())
+		return err
+	})
+	return admin, err
+}
+
+func (r *kvAdminRepo) FindByUsername(ctx context.Context, username string) (admin models.Admin, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		found, err := kvFind(tx, adminCollectionName, func(existing models.Admin) bool {
+			return existing.Username == username
+		})
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			return ErrNotFound
+		}
+		admin = found[0]
+		return nil
+	})
+	return admin, err
+}
+
+func (r *kvAdminRepo) List(ctx context.Context, filter AdminFilter) (list []models.Admin, total int64, err error) {
+	matchName, err := keywordMatcher(filter.Keyword)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.store.view(func(tx kvTx) error {
+		list, err = kvFind(tx, adminCollectionName, func(admin models.Admin) bool {
+			return admin.OrganizationId == filter.OrganizationId &&
+				matchName(admin.Username) && (filter.Role == "" || admin.Role == filter.Role)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return kvPage(list, filter.Limit, filter.Page), int64(len(list)), nil
+}
+
+func (r *kvAdminRepo) UpdateRole(ctx context.Context, id primitive.ObjectID, role string) (admin models.Admin, err error) {
+	err = r.store.update(func(tx kvTx) error {
+		admin, err = kvGet[models.Admin](tx, adminCollectionName, id.Hex())
+		if err != nil {
+			return err
+		}
+		admin.Role = role
+		admin.UpdatedAt = time.Now().UTC()
+		return kvPut(tx, adminCollectionName, id.Hex(), admin)
+	})
+	return admin, err
+}
+
+type kvAdminSessionRepo struct {
+	store kvStore
+}
+
+func (r *kvAdminSessionRepo) Create(ctx context.Context, session models.AdminSession) error {
+	return r.store.update(func(tx kvTx) error {
+		return kvPut(tx, adminSessionCollectionName, session.Id.Hex(), session)
+	})
+}
+
+func (r *kvAdminSessionRepo) FindById(ctx context.Context, id primitive.ObjectID) (session models.AdminSession, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		session, err = kvGet[models.AdminSession](tx, adminSessionCollectionName, id.Hex())
+		return err
+	})
+	return session, err
+}
+
+func (r *kvAdminSessionRepo) UpdateRefreshToken(ctx context.Context, id primitive.ObjectID, previousHash, refreshTokenHash string, expiresAt time.Time) error {
+	return r.store.update(func(tx kvTx) error {
+		session, err := kvGet[models.AdminSession](tx, adminSessionCollectionName, id.Hex())
+		if err != nil {
+			return err
+		}
+		if session.RefreshTokenHash != previousHash {
+			return ErrNotFound
+		}
+		session.RefreshTokenHash = refreshTokenHash
+		session.ExpiresAt = expiresAt
+		session.UpdatedAt = time.Now().UTC()
+		return kvPut(tx, adminSessionCollectionName, id.Hex(), session)
+	})
+}
+
+func (r *kvAdminSessionRepo) Revoke(ctx context.Context, id primitive.ObjectID) error {
+	return r.modify(id, func(session *models.AdminSession) {
+		session.RevokedAt = time.Now().UTC()
+	})
+}
+
+func (r *kvAdminSessionRepo) modify(id primitive.ObjectID, fn func(session *models.AdminSession)) error {
+	return r.store.update(func(tx kvTx) error {
+		session, err := kvGet[models.AdminSession](tx, adminSessionCollectionName, id.Hex())
+		if err != nil {
+			return err
+		}
+		fn(&session)
+		session.UpdatedAt = time.Now().UTC()
+		return kvPut(tx, adminSessionCollectionName, id.Hex(), session)
+	})
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestKVUpdateRefreshTokenCompareAndSwap(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemory()
+	now := time.Now().UTC()
+	session := models.AdminSession{
+		Id:               primitive.NewObjectID(),
+		AdminId:          primitive.NewObjectID(),
+		RefreshTokenHash: "hash-1",
+		ExpiresAt:        now.Add(time.Hour),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := repos.AdminSessions.Create(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hai lần refresh cùng đọc hash-1, chỉ lần đổi hash trước thành công
+	if err := repos.AdminSessions.UpdateRefreshToken(ctx, session.Id, "hash-1", "hash-2", now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("first UpdateRefreshToken: %v", err)
+	}
+	if err := repos.AdminSessions.UpdateRefreshToken(ctx, session.Id, "hash-1", "hash-3", now.Add(2*time.Hour)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second UpdateRefreshToken = %v, want ErrNotFound", err)
+	}
+	if err := repos.AdminSessions.UpdateRefreshToken(ctx, primitive.NewObjectID(), "hash-2", "hash-3", now); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("UpdateRefreshToken of missing session = %v, want ErrNotFound", err)
+	}
+
+	found, err := repos.AdminSessions.FindById(ctx, session.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.RefreshTokenHash != "hash-2" || !found.ExpiresAt.After(now.Add(time.Hour)) {
+		t.Fatalf("session = %+v, want hash-2 from the first refresh", found)
+	}
+}
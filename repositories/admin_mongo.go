@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoAdminRepo struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoAdminRepo) Create(ctx context.Context, admin models.Admin) error {
+	return insertOne(ctx, r.collection, admin)
+}
+
+func (r *mongoAdminRepo) FindById(ctx context.Context, id primitive.ObjectID) (models.Admin, error) {
+	var admin models.Admin
+	err := findOne(ctx, r.collection, bson.M{"_id": id}, &admin)
+	return admin, err
+}
+
+func (r *mongoAdminRepo) FindByUsername(ctx context.Context, username string) (models.Admin, error) {
+	var admin models.Admin
+	err := findOne(ctx, r.collection, bson.M{"username": username}, &admin)
+	return admin, err
+}
+
+func (r *mongoAdminRepo) List(ctx context.Context, filter AdminFilter) ([]models.Admin, int64, error) {
+	query := bson.M{"organizationId": filter.OrganizationId}
+	if filter.Keyword != "" {
+		query["username"] = bson.M{"$regex": filter.Keyword, "$options": "i"}
+	}
+	if filter.Role != "" {
+		query["role"] = filter.Role
+	}
+	return findSortedList[models.Admin](ctx, r.collection, query, DefaultSort, nil, filter.Limit, filter.Page)
+}
+
+func (r *mongoAdminRepo) UpdateRole(ctx context.Context, id primitive.ObjectID, role string) (models.Admin, error) {
+	var admin models.Admin
+	err := updateAndFind(ctx, r.collection, bson.M{"_id": id}, bson.M{"role": role, "updatedAt": time.Now().UTC()}, &admin)
+	return admin, err
+}
+
+type mongoAdminSessionRepo struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoAdminSessionRepo) Create(ctx context.Context, session models.AdminSession) error {
+	_, err := r.collection.InsertOne(ctx, session)
+	return err
+}
+
+func (r *mongoAdminSessionRepo) FindById(ctx context.Context, id primitive.ObjectID) (models.AdminSession, error) {
+	var session models.AdminSession
+	err := findOne(ctx, r.collection, bson.M{"_id": id}, &session)
+	return session, err
+}
+
+func (r *mongoAdminSessionRepo) UpdateRefreshToken(ctx context.Context, id primitive.ObjectID, previousHash, refreshTokenHash string, expiresAt time.Time) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "refreshTokenHash": previousHash}, bson.M{"$set": bson.M{
+		"refreshTokenHash": refreshTokenHash,
+		"expiresAt":        expiresAt,
+		"updatedAt":        time.Now().UTC(),
+	}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoAdminSessionRepo) Revoke(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now().UTC()
+	var session models.AdminSession
+	return updateAndFind(ctx, r.collection, bson.M{"_id": id}, bson.M{"revokedAt": now, "updatedAt": now}, &session)
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"draft-notification/helpers"
+	"draft-notification/models"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// apiKeyFields trả về tên field lưu prefix, hash và thông tin rotation API key của một phía connection
+func apiKeyFields(side string) (prefixField, hashField, rotationField string, err error) {
+	switch side {
+	case models.ApiKeySideWebviewServer:
+		return "webviewServerApiKeyPrefix", "webviewServerApiKeyHash", "webviewServerApiKeyRotation", nil
+	case models.ApiKeySideUserDeliveryServer:
+		return "userDeliveryServerApiKeyPrefix", "userDeliveryServerApiKeyHash", "userDeliveryServerApiKeyRotation", nil
+	}
+	return "", "", "", fmt.Errorf("unknown api key side %q", side)
+}
+
+// legacyApiKeyFilter khớp các connection còn lưu API key dạng plaintext. Dữ liệu này có từ
+// trước khi đổi sang camelCase nên dùng tên field lowercase cũ.
+var legacyApiKeyFilter = bson.M{"$or": bson.A{
+	bson.M{"webviewserverapikey": bson.M{"$exists": true}},
+	bson.M{"userdeliveryserverapikey": bson.M{"$exists": true}},
+	bson.M{"webviewserverapikeyrotation.previouskey": bson.M{"$exists": true}},
+	bson.M{"userdeliveryserverapikeyrotation.previouskey": bson.M{"$exists": true}},
+}}
+
+// hashLegacyApiKeys thay các API key plaintext trong document bằng prefix và hash,
+// trả về false nếu document không có key plaintext nào
+func hashLegacyApiKeys(doc bson.M) bool {
+	changed := hashLegacyApiKey(doc, "webviewserverapikey", "webviewserverapikeyprefix", "webviewserverapikeyhash")
+	changed = hashLegacyApiKey(doc, "userdeliveryserverapikey", "userdeliveryserverapikeyprefix", "userdeliveryserverapikeyhash") || changed
+	for _, field := range []string{"webviewserverapikeyrotation", "userdeliveryserverapikeyrotation"} {
+		if rotation, ok := doc[field].(bson.M); ok {
+			changed = hashLegacyApiKey(rotation, "previouskey", "previouskeyprefix", "previouskeyhash") || changed
+		}
+	}
+	return changed
+}
+
+func hashLegacyApiKey(doc bson.M, keyField, prefixField, hashField string) bool {
+	key, ok := doc[keyField].(string)
+	if !ok {
+		return false
+	}
+	delete(doc, keyField)
+	if key != "" {
+		doc[prefixField] = helpers.APIKeyPrefix(key)
+		doc[hashField] = helpers.HashAPIKey(key)
+	}
+	return true
+}
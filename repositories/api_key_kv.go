@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type kvApiKeyRepo struct {
+	store kvStore
+}
+
+func (r *kvApiKeyRepo) Create(ctx context.Context, apiKey models.ApiKey) error {
+	return r.store.update(func(tx kvTx) error {
+		return kvPut(tx, apiKeyCollectionName, apiKey.Id.Hex(), apiKey)
+	})
+}
+
+func (r *kvApiKeyRepo) FindById(ctx context.Context, id primitive.ObjectID) (apiKey models.ApiKey, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		apiKey, err = kvGet[models.ApiKey](tx, apiKeyCollectionName, id.Hex())
+		return err
+	})
+	return apiKey, err
+}
+
+func (r *kvApiKeyRepo) FindByPrefix(ctx context.Context, prefix string) ([]models.ApiKey, error) {
+	return r.find(func(apiKey models.ApiKey) bool {
+		return apiKey.Prefix == prefix
+	})
+}
+
+func (r *kvApiKeyRepo) ListByOwner(ctx context.Context, ownerType string, ownerId primitive.ObjectID) ([]models.ApiKey, error) {
+	return r.find(func(apiKey models.ApiKey) bool {
+		return apiKey.OwnerType == ownerType && apiKey.OwnerId == ownerId
+	})
+}
+
+func (r *kvApiKeyRepo) Revoke(ctx context.Context, id primitive.ObjectID) (apiKey models.ApiKey, err error) {
+	err = r.store.update(func(tx kvTx) error {
+		apiKey, err = kvGet[models.ApiKey](tx, apiKeyCollectionName, id.Hex())
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		apiKey.RevokedAt = now
+		apiKey.UpdatedAt = now
+		return kvPut(tx, apiKeyCollectionName, id.Hex(), apiKey)
+	})
+	return apiKey, err
+}
+
+func (r *kvApiKeyRepo) find(match func(models.ApiKey) bool) (list []models.ApiKey, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		list, err = kvFind(tx, apiKeyCollectionName, match)
+		return err
+	})
+	return list, err
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoApiKeyRepo struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoApiKeyRepo) Create(ctx context.Context, apiKey models.ApiKey) error {
+	_, err := r.collection.InsertOne(ctx, apiKey)
+	return err
+}
+
+func (r *mongoApiKeyRepo) FindById(ctx context.Context, id primitive.ObjectID) (models.ApiKey, error) {
+	var apiKey models.ApiKey
+	err := findOne(ctx, r.collection, bson.M{"_id": id}, &apiKey)
+	return apiKey, err
+}
+
+func (r *mongoApiKeyRepo) FindByPrefix(ctx context.Context, prefix string) ([]models.ApiKey, error) {
+	return r.find(ctx, bson.M{"prefix": prefix})
+}
+
+func (r *mongoApiKeyRepo) ListByOwner(ctx context.Context, ownerType string, ownerId primitive.ObjectID) ([]models.ApiKey, error) {
+	return r.find(ctx, bson.M{"ownerType": ownerType, "ownerId": ownerId})
+}
+
+func (r *mongoApiKeyRepo) Revoke(ctx context.Context, id primitive.ObjectID) (models.ApiKey, error) {
+	now := time.Now().UTC()
+	var apiKey models.ApiKey
+	err := updateAndFind(ctx, r.collection, bson.M{"_id": id}, bson.M{"revokedAt": now, "updatedAt": now}, &apiKey)
+	return apiKey, err
+}
+
+func (r *mongoApiKeyRepo) find(ctx context.Context, filter bson.M) ([]models.ApiKey, error) {
+	results, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close(ctx)
+
+	list := []models.ApiKey{}
+	err = results.All(ctx, &list)
+	return list, err
+}
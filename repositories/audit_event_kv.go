@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+	"slices"
+)
+
+type kvAuditEventRepo struct {
+	store kvStore
+}
+
+func (r *kvAuditEventRepo) Create(ctx context.Context, event models.AuditEvent) error {
+	return r.store.update(func(tx kvTx) error {
+		return kvPut(tx, auditEventCollectionName, event.Id.Hex(), event)
+	})
+}
+
+func (r *kvAuditEventRepo) List(ctx context.Context, filter AuditEventFilter) ([]models.AuditEvent, int64, error) {
+	list, err := r.find(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Key là ObjectID dạng hex nên thứ tự key cũng là thứ tự thời gian
+	slices.Reverse(list)
+	return kvPage(list, filter.Limit, filter.Page), int64(len(list)), nil
+}
+
+func (r *kvAuditEventRepo) Export(ctx context.Context, filter AuditEventFilter, fn func(models.AuditEvent) error) error {
+	list, err := r.find(filter)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range list {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *kvAuditEventRepo) find(filter AuditEventFilter) (list []models.AuditEvent, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		list, err = kvFind(tx, auditEventCollectionName, func(event models.AuditEvent) bool {
+			return event.OrganizationId == filter.OrganizationId &&
+				(filter.ActorId.IsZero() || event.ActorId == filter.ActorId) &&
+				(filter.EntityType == "" || event.EntityType == filter.EntityType) &&
+				(filter.EntityId.IsZero() || event.EntityId == filter.EntityId) &&
+				(filter.Action == "" || event.Action == filter.Action) &&
+				(filter.RequestId == "" || event.RequestId == filter.RequestId) &&
+				(filter.From.IsZero() || !event.CreatedAt.Before(filter.From)) &&
+				(filter.To.IsZero() || event.CreatedAt.Before(filter.To))
+		})
+		return err
+	})
+	return list, err
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoAuditEventRepo struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoAuditEventRepo) Create(ctx context.Context, event models.AuditEvent) error {
+	_, err := r.collection.InsertOne(ctx, event)
+	return err
+}
+
+func (r *mongoAuditEventRepo) List(ctx context.Context, filter AuditEventFilter) ([]models.AuditEvent, int64, error) {
+	query := auditEventQuery(filter)
+	results, err := r.collection.Find(ctx, query, pageOptions(filter.Limit, filter.Page).SetSort(bson.D{{Key: "_id", Value: -1}}))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer results.Close(ctx)
+
+	list := []models.AuditEvent{}
+	if err := results.All(ctx, &list); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+func (r *mongoAuditEventRepo) Export(ctx context.Context, filter AuditEventFilter, fn func(models.AuditEvent) error) error {
+	results, err := r.collection.Find(ctx, auditEventQuery(filter), options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer results.Close(ctx)
+
+	for results.Next(ctx) {
+		var event models.AuditEvent
+		if err := results.Decode(&event); err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return results.Err()
+}
+
+func auditEventQuery(filter AuditEventFilter) bson.M {
+	query := bson.M{"organizationId": filter.OrganizationId}
+	if !filter.ActorId.IsZero() {
+		query["actorId"] = filter.ActorId
+	}
+	if filter.EntityType != "" {
+		query["entityType"] = filter.EntityType
+	}
+	if !filter.EntityId.IsZero() {
+		query["entityId"] = filter.EntityId
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.RequestId != "" {
+		query["requestId"] = filter.RequestId
+	}
+
+	createdRange(query, filter.From, filter.To)
+	return query
+}
@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const benchPageSize = 50
+
+var benchConnectionCounts = []int{100, 1000}
+
+// BenchmarkListConnections đo cách cũ: một query lấy trang, một query đếm và hai FindById cho mỗi connection
+func BenchmarkListConnections(b *testing.B) {
+	benchConnectionList(b, func(ctx context.Context, repos Repositories, filter ConnectionFilter) error {
+		connections, _, err := repos.Connections.List(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, connection := range connections {
+			if _, err := repos.WebviewServers.FindById(ctx, connection.WebviewServerId); err != nil && !errors.Is(err, ErrNotFound) {
+				return err
+			}
+			if _, err := repos.UserDeliveryServers.FindById(ctx, connection.UserDeliveryServerId); err != nil && !errors.Is(err, ErrNotFound) {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func BenchmarkListWithServers(b *testing.B) {
+	benchConnectionList(b, func(ctx context.Context, repos Repositories, filter ConnectionFilter) error {
+		_, _, err := repos.Connections.ListWithServers(ctx, filter)
+		return err
+	})
+}
+
+func benchConnectionList(b *testing.B, list func(ctx context.Context, repos Repositories, filter ConnectionFilter) error) {
+	backends := map[string]func(b *testing.B) Repositories{"memory": func(b *testing.B) Repositories { return NewMemory() }}
+	if os.Getenv(testMongoURIEnv) != "" {
+		backends["mongo"] = func(b *testing.B) Repositories { return openTestMongo(b) }
+	}
+
+	ctx := context.Background()
+	for name, open := range backends {
+		for _, total := range benchConnectionCounts {
+			b.Run(fmt.Sprintf("%s/%d", name, total), func(b *testing.B) {
+				repos := open(b)
+				userDeliveryServerId := seedConnections(b, repos, total)
+				pages := (total + benchPageSize - 1) / benchPageSize
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					filter := ConnectionFilter{
+						UserDeliveryServerId: userDeliveryServerId,
+						Sort:                 DefaultSort,
+						Limit:                benchPageSize,
+						Page:                 i % pages,
+					}
+					if err := list(ctx, repos, filter); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// seedConnections tạo một user delivery server nối với total webview server khác nhau
+func seedConnections(b *testing.B, repos Repositories, total int) primitive.ObjectID {
+	ctx := context.Background()
+	organizationId := primitive.NewObjectID()
+	now := time.Now().UTC()
+
+	userDeliveryServer := models.UserDeliveryServer{
+		Id:             primitive.NewObjectID(),
+		OrganizationId: organizationId,
+		Name:           "bench-user-delivery-server",
+		Status:         "active",
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := repos.UserDeliveryServers.Create(ctx, userDeliveryServer); err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < total; i++ {
+		createdAt := now.Add(time.Duration(i) * time.Millisecond)
+		webviewServer := models.WebviewServer{
+			Id:             primitive.NewObjectID(),
+			OrganizationId: organizationId,
+			Name:           fmt.Sprintf("bench-webview-server-%d", i),
+			Status:         "active",
+			CreatedAt:      createdAt,
+			UpdatedAt:      createdAt,
+		}
+		if err := repos.WebviewServers.Create(ctx, webviewServer); err != nil {
+			b.Fatal(err)
+		}
+
+		connection := models.Connection{
+			Id:                   primitive.NewObjectID(),
+			OrganizationId:       organizationId,
+			Status:               "active",
+			CreatedAt:            createdAt,
+			UpdatedAt:            createdAt,
+			WebviewServerId:      webviewServer.Id,
+			UserDeliveryServerId: userDeliveryServer.Id,
+		}
+		if err := repos.Connections.Create(ctx, connection); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return userDeliveryServer.Id
+}
@@ -0,0 +1,219 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/encryption"
+	"draft-notification/models"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type kvConnectionRepo struct {
+	store kvStore
+}
+
+func (r *kvConnectionRepo) Create(ctx context.Context, connection models.Connection) error {
+	return r.store.update(func(tx kvTx) error {
+		if exists, err := r.existsPair(tx, connection.WebviewServerId, connection.UserDeliveryServerId); err != nil {
+			return err
+		} else if exists {
+			return ErrDuplicate
+		}
+		return kvPut(tx, connectionCollectionName, connection.Id.Hex(), connection)
+	})
+}
+
+func (r *kvConnectionRepo) FindById(ctx context.Context, id primitive.ObjectID) (connection models.Connection, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		connection, err = kvGet[models.Connection](tx, connectionCollectionName, id.Hex())
+		return err
+	})
+	return connection, err
+}
+
+func (r *kvConnectionRepo) existsPair(tx kvTx, webviewServerId, userDeliveryServerId primitive.ObjectID) (bool, error) {
+	found, err := kvFind(tx, connectionCollectionName, func(connection models.Connection) bool {
+		return connection.WebviewServerId == webviewServerId && connection.UserDeliveryServerId == userDeliveryServerId
+	})
+	return len(found) > 0, err
+}
+
+func (r *kvConnectionRepo) List(ctx context.Context, filter ConnectionFilter) (list []models.Connection, total int64, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		list, err = kvFind(tx, connectionCollectionName, func(connection models.Connection) bool {
+			return connection.UserDeliveryServerId == filter.UserDeliveryServerId &&
+				(filter.WebviewServerId.IsZero() || connection.WebviewServerId == filter.WebviewServerId) &&
+				(filter.Status == "" || connection.Status == filter.Status) &&
+				inCreatedRange(connection.CreatedAt, filter.CreatedFrom, filter.CreatedTo)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return kvSortedPage(list, ConnectionSortFields, filter.Sort, filter.After, filter.Limit, filter.Page), int64(len(list)), nil
+}
+
+// ListWithServers đọc server của trang trong cùng transaction, mỗi server chỉ đọc một lần
+func (r *kvConnectionRepo) ListWithServers(ctx context.Context, filter ConnectionFilter) (list []models.ConnectionWithServers, total int64, err error) {
+	connections, total, err := r.List(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	list = make([]models.ConnectionWithServers, 0, len(connections))
+	err = r.store.view(func(tx kvTx) error {
+		servers := map[string]models.ServerInfo{}
+		serverInfo := func(bucket string, id primitive.ObjectID) (models.ServerInfo, error) {
+			key := bucket + "/" + id.Hex()
+			if server, ok := servers[key]; ok {
+				return server, nil
+			}
+			server, err := kvGet[models.ServerInfo](tx, bucket, id.Hex())
+			if errors.Is(err, ErrNotFound) {
+				err = nil
+			}
+			servers[key] = server
+			return server, err
+		}
+
+		for _, connection := range connections {
+			item := models.ConnectionWithServers{Connection: connection}
+			if item.WebviewServer, err = serverInfo(webviewServerCollectionName, connection.WebviewServerId); err != nil {
+				return err
+			}
+			if item.UserDeliveryServer, err = serverInfo(userDeliveryServerCollectionName, connection.UserDeliveryServerId); err != nil {
+				return err
+			}
+			list = append(list, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+func (r *kvConnectionRepo) UpdateWebhookUrl(ctx context.Context, id primitive.ObjectID, webhookUrl string, verifiedAt time.Time) (models.Connection, error) {
+	return r.modify(id, func(connection *models.Connection) {
+		connection.UserDeliveryServerWebHookUrl = webhookUrl
+		connection.WebhookVerifiedUrl = ""
+		connection.WebhookVerifiedAt = verifiedAt
+		if !verifiedAt.IsZero() {
+			connection.WebhookVerifiedUrl = webhookUrl
+		}
+	})
+}
+
+func (r *kvConnectionRepo) UpdateWebhookSecret(ctx context.Context, id primitive.ObjectID, secret string) (models.Connection, error) {
+	return r.modify(id, func(connection *models.Connection) {
+		connection.WebhookSecret = encryption.EncryptedString(secret)
+	})
+}
+
+func (r *kvConnectionRepo) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) (models.Connection, error) {
+	return r.modify(id, func(connection *models.Connection) {
+		connection.Status = status
+	})
+}
+
+func (r *kvConnectionRepo) RotateApiKey(ctx context.Context, id primitive.ObjectID, side string, prefix, hash string, rotation models.ApiKeyRotation) (connection models.Connection, err error) {
+	if _, _, _, err := apiKeyFields(side); err != nil {
+		return models.Connection{}, err
+	}
+
+	err = r.store.update(func(tx kvTx) error {
+		connection, err = kvGet[models.Connection](tx, connectionCollectionName, id.Hex())
+		if err != nil {
+			return err
+		}
+
+		currentHash := connection.WebviewServerApiKeyHash
+		if side == models.ApiKeySideUserDeliveryServer {
+			currentHash = connection.UserDeliveryServerApiKeyHash
+		}
+		if currentHash != rotation.PreviousKeyHash {
+			return ErrNotFound
+		}
+
+		if side == models.ApiKeySideWebviewServer {
+			connection.WebviewServerApiKeyPrefix = prefix
+			connection.WebviewServerApiKeyHash = hash
+			connection.WebviewServerApiKeyRotation = &rotation
+		} else {
+			connection.UserDeliveryServerApiKeyPrefix = prefix
+			connection.UserDeliveryServerApiKeyHash = hash
+			connection.UserDeliveryServerApiKeyRotation = &rotation
+		}
+		connection.UpdatedAt = time.Now().UTC()
+		return kvPut(tx, connectionCollectionName, id.Hex(), connection)
+	})
+	return connection, err
+}
+
+func (r *kvConnectionRepo) FindByApiKeyPrefix(ctx context.Context, prefix string, now time.Time) (list []models.Connection, err error) {
+	matchPrevious := func(rotation *models.ApiKeyRotation) bool {
+		return rotation.PreviousKeyActive(now) && rotation.PreviousKeyPrefix == prefix
+	}
+
+	err = r.store.view(func(tx kvTx) error {
+		list, err = kvFind(tx, connectionCollectionName, func(connection models.Connection) bool {
+			return connection.WebviewServerApiKeyPrefix == prefix || connection.UserDeliveryServerApiKeyPrefix == prefix ||
+				matchPrevious(connection.WebviewServerApiKeyRotation) || matchPrevious(connection.UserDeliveryServerApiKeyRotation)
+		})
+		return err
+	})
+	return list, err
+}
+
+func (r *kvConnectionRepo) DeactivateByWebviewServer(ctx context.Context, webviewServerId primitive.ObjectID) ([]models.Connection, error) {
+	return r.deactivate(func(connection models.Connection) bool {
+		return connection.WebviewServerId == webviewServerId
+	})
+}
+
+func (r *kvConnectionRepo) DeactivateByUserDeliveryServer(ctx context.Context, userDeliveryServerId primitive.ObjectID) ([]models.Connection, error) {
+	return r.deactivate(func(connection models.Connection) bool {
+		return connection.UserDeliveryServerId == userDeliveryServerId
+	})
+}
+
+func (r *kvConnectionRepo) deactivate(match func(models.Connection) bool) (active []models.Connection, err error) {
+	err = r.store.update(func(tx kvTx) error {
+		active, err = kvFind(tx, connectionCollectionName, func(connection models.Connection) bool {
+			return connection.Status == "active" && match(connection)
+		})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		for _, connection := range active {
+			connection.Status = "inactive"
+			connection.UpdatedAt = now
+			if err := kvPut(tx, connectionCollectionName, connection.Id.Hex(), connection); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return active, err
+}
+
+// modify đọc, sửa và ghi lại một connection trong cùng transaction
+func (r *kvConnectionRepo) modify(id primitive.ObjectID, fn func(connection *models.Connection)) (connection models.Connection, err error) {
+	err = r.store.update(func(tx kvTx) error {
+		connection, err = kvGet[models.Connection](tx, connectionCollectionName, id.Hex())
+		if err != nil {
+			return err
+		}
+		fn(&connection)
+		connection.UpdatedAt = time.Now().UTC()
+		return kvPut(tx, connectionCollectionName, id.Hex(), connection)
+	})
+	return connection, err
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestKVRotateApiKeyCompareAndSwap(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemory()
+	now := time.Now().UTC()
+	connection := models.Connection{
+		Id:                             primitive.NewObjectID(),
+		WebviewServerId:                primitive.NewObjectID(),
+		UserDeliveryServerId:           primitive.NewObjectID(),
+		WebviewServerApiKeyPrefix:      "web-1",
+		WebviewServerApiKeyHash:        "web-hash-1",
+		UserDeliveryServerApiKeyPrefix: "delivery-1",
+		UserDeliveryServerApiKeyHash:   "delivery-hash-1",
+		CreatedAt:                      now,
+		UpdatedAt:                      now,
+	}
+	if err := repos.Connections.Create(ctx, connection); err != nil {
+		t.Fatal(err)
+	}
+	rotation := func(previousHash string) models.ApiKeyRotation {
+		return models.ApiKeyRotation{PreviousKeyHash: previousHash, PreviousKeyExpiresAt: now.Add(time.Hour), RotatedAt: now}
+	}
+
+	// Hai lần rotate cùng đọc web-hash-1, chỉ lần rotate trước thành công
+	if _, err := repos.Connections.RotateApiKey(ctx, connection.Id, models.ApiKeySideWebviewServer, "web-2", "web-hash-2", rotation("web-hash-1")); err != nil {
+		t.Fatalf("first RotateApiKey: %v", err)
+	}
+	if _, err := repos.Connections.RotateApiKey(ctx, connection.Id, models.ApiKeySideWebviewServer, "web-3", "web-hash-3", rotation("web-hash-1")); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second RotateApiKey = %v, want ErrNotFound", err)
+	}
+	// Hash của phía còn lại không ảnh hưởng
+	updated, err := repos.Connections.RotateApiKey(ctx, connection.Id, models.ApiKeySideUserDeliveryServer, "delivery-2", "delivery-hash-2", rotation("delivery-hash-1"))
+	if err != nil {
+		t.Fatalf("RotateApiKey of the user delivery server side: %v", err)
+	}
+	if updated.WebviewServerApiKeyHash != "web-hash-2" || updated.WebviewServerApiKeyRotation.PreviousKeyHash != "web-hash-1" ||
+		updated.UserDeliveryServerApiKeyHash != "delivery-hash-2" {
+		t.Fatalf("connection = %+v, want the keys of the first rotation of each side", updated)
+	}
+	if _, err := repos.Connections.RotateApiKey(ctx, primitive.NewObjectID(), models.ApiKeySideWebviewServer, "web-4", "web-hash-4", rotation("web-hash-2")); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("RotateApiKey of missing connection = %v, want ErrNotFound", err)
+	}
+}
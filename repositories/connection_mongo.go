@@ -0,0 +1,237 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/encryption"
+	"draft-notification/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoConnectionRepo struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoConnectionRepo) Create(ctx context.Context, connection models.Connection) error {
+	return insertOne(ctx, r.collection, connection)
+}
+
+func (r *mongoConnectionRepo) FindById(ctx context.Context, id primitive.ObjectID) (models.Connection, error) {
+	var connection models.Connection
+	err := findOne(ctx, r.collection, bson.M{"_id": id}, &connection)
+	return connection, err
+}
+
+func (r *mongoConnectionRepo) List(ctx context.Context, filter ConnectionFilter) ([]models.Connection, int64, error) {
+	return findSortedList[models.Connection](ctx, r.collection, connectionQuery(filter), filter.Sort, filter.After, filter.Limit, filter.Page)
+}
+
+// ListWithServers lấy tổng số và trang connection kèm tên server trong một aggregation. $sort đứng
+// trước $facet để dùng được index, $lookup chỉ chạy trên các connection của trang.
+func (r *mongoConnectionRepo) ListWithServers(ctx context.Context, filter ConnectionFilter) ([]models.ConnectionWithServers, int64, error) {
+	page := bson.A{}
+	if filter.After != nil {
+		page = append(page, bson.M{"$match": cursorCondition(filter.Sort, *filter.After)})
+	} else if filter.Page > 0 {
+		page = append(page, bson.M{"$skip": filter.Page * filter.Limit})
+	}
+	if filter.Limit > 0 {
+		page = append(page, bson.M{"$limit": filter.Limit})
+	}
+	page = append(page,
+		bson.M{"$lookup": bson.M{"from": webviewServerCollectionName, "localField": "webviewServerId", "foreignField": "_id", "as": "webviewServer"}},
+		bson.M{"$lookup": bson.M{"from": userDeliveryServerCollectionName, "localField": "userDeliveryServerId", "foreignField": "_id", "as": "userDeliveryServer"}},
+		bson.M{"$set": bson.M{
+			"webviewServer":      serverInfoExpression("$webviewServer"),
+			"userDeliveryServer": serverInfoExpression("$userDeliveryServer"),
+		}},
+		// Danh sách không trả về hash và secret, bỏ đi để không phải giải mã
+		bson.M{"$unset": bson.A{"webviewServerApiKeyHash", "userDeliveryServerApiKeyHash", "webhookSecret"}},
+	)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: connectionQuery(filter)}},
+		{{Key: "$sort", Value: sortDocument(filter.Sort)}},
+		{{Key: "$facet", Value: bson.M{
+			"total": bson.A{bson.M{"$count": "count"}},
+			"page":  page,
+		}}},
+	}
+
+	results, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer results.Close(ctx)
+
+	var facets []struct {
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+		Page []models.ConnectionWithServers `bson:"page"`
+	}
+	if err := results.All(ctx, &facets); err != nil {
+		return nil, 0, err
+	}
+
+	list := []models.ConnectionWithServers{}
+	var total int64
+	if len(facets) > 0 {
+		list = append(list, facets[0].Page...)
+		if len(facets[0].Total) > 0 {
+			total = facets[0].Total[0].Count
+		}
+	}
+	return list, total, nil
+}
+
+// serverInfoExpression lấy _id và name của server đầu tiên trong kết quả $lookup, rỗng nếu không có
+func serverInfoExpression(field string) bson.M {
+	return bson.M{
+		"_id":  bson.M{"$arrayElemAt": bson.A{field + "._id", 0}},
+		"name": bson.M{"$arrayElemAt": bson.A{field + ".name", 0}},
+	}
+}
+
+func connectionQuery(filter ConnectionFilter) bson.M {
+	query := bson.M{
+		"userDeliveryServerId": filter.UserDeliveryServerId,
+	}
+	if !filter.WebviewServerId.IsZero() {
+		query["webviewServerId"] = filter.WebviewServerId
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	createdRange(query, filter.CreatedFrom, filter.CreatedTo)
+	return query
+}
+
+func (r *mongoConnectionRepo) UpdateWebhookUrl(ctx context.Context, id primitive.ObjectID, webhookUrl string, verifiedAt time.Time) (models.Connection, error) {
+	verifiedUrl := ""
+	if !verifiedAt.IsZero() {
+		verifiedUrl = webhookUrl
+	}
+
+	var connection models.Connection
+	err := updateAndFind(ctx, r.collection, bson.M{"_id": id}, bson.M{
+		"userDeliveryServerWebHookUrl": webhookUrl,
+		"webhookVerifiedUrl":           verifiedUrl,
+		"webhookVerifiedAt":            verifiedAt,
+		"updatedAt":                    time.Now().UTC(),
+	}, &connection)
+	return connection, err
+}
+
+func (r *mongoConnectionRepo) UpdateWebhookSecret(ctx context.Context, id primitive.ObjectID, secret string) (models.Connection, error) {
+	var connection models.Connection
+	err := updateAndFind(ctx, r.collection, bson.M{"_id": id}, bson.M{"webhookSecret": encryption.EncryptedString(secret), "updatedAt": time.Now().UTC()}, &connection)
+	return connection, err
+}
+
+func (r *mongoConnectionRepo) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) (models.Connection, error) {
+	var connection models.Connection
+	err := updateAndFind(ctx, r.collection, bson.M{"_id": id}, bson.M{"status": status, "updatedAt": time.Now().UTC()}, &connection)
+	return connection, err
+}
+
+func (r *mongoConnectionRepo) RotateApiKey(ctx context.Context, id primitive.ObjectID, side string, prefix, hash string, rotation models.ApiKeyRotation) (models.Connection, error) {
+	prefixField, hashField, rotationField, err := apiKeyFields(side)
+	if err != nil {
+		return models.Connection{}, err
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, hashField: rotation.PreviousKeyHash}, bson.M{"$set": bson.M{
+		prefixField:   prefix,
+		hashField:     hash,
+		rotationField: rotation,
+		"updatedAt":   time.Now().UTC(),
+	}})
+	if err != nil {
+		return models.Connection{}, err
+	}
+	if result.MatchedCount == 0 {
+		return models.Connection{}, ErrNotFound
+	}
+
+	var connection models.Connection
+	err = findOne(ctx, r.collection, bson.M{"_id": id}, &connection)
+	return connection, err
+}
+
+func (r *mongoConnectionRepo) FindByApiKeyPrefix(ctx context.Context, prefix string, now time.Time) ([]models.Connection, error) {
+	results, err := r.collection.Find(ctx, bson.M{"$or": bson.A{
+		bson.M{"webviewServerApiKeyPrefix": prefix},
+		bson.M{"userDeliveryServerApiKeyPrefix": prefix},
+		bson.M{"webviewServerApiKeyRotation.previousKeyPrefix": prefix, "webviewServerApiKeyRotation.previousKeyExpiresAt": bson.M{"$gt": now}},
+		bson.M{"userDeliveryServerApiKeyRotation.previousKeyPrefix": prefix, "userDeliveryServerApiKeyRotation.previousKeyExpiresAt": bson.M{"$gt": now}},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close(ctx)
+
+	list := []models.Connection{}
+	err = results.All(ctx, &list)
+	return list, err
+}
+
+// hashLegacyApiKeys thay các API key plaintext lưu trước đây bằng prefix và hash
+func (r *mongoConnectionRepo) hashLegacyApiKeys(ctx context.Context) error {
+	results, err := r.collection.Find(ctx, legacyApiKeyFilter)
+	if err != nil {
+		return err
+	}
+	defer results.Close(ctx)
+
+	for results.Next(ctx) {
+		var doc bson.M
+		if err := results.Decode(&doc); err != nil {
+			return err
+		}
+		if !hashLegacyApiKeys(doc) {
+			continue
+		}
+		if _, err := r.collection.ReplaceOne(ctx, bson.M{"_id": doc["_id"]}, doc); err != nil {
+			return err
+		}
+	}
+	return results.Err()
+}
+
+func (r *mongoConnectionRepo) DeactivateByWebviewServer(ctx context.Context, webviewServerId primitive.ObjectID) ([]models.Connection, error) {
+	return r.deactivate(ctx, bson.M{"webviewServerId": webviewServerId})
+}
+
+func (r *mongoConnectionRepo) DeactivateByUserDeliveryServer(ctx context.Context, userDeliveryServerId primitive.ObjectID) ([]models.Connection, error) {
+	return r.deactivate(ctx, bson.M{"userDeliveryServerId": userDeliveryServerId})
+}
+
+func (r *mongoConnectionRepo) deactivate(ctx context.Context, filter bson.M) ([]models.Connection, error) {
+	filter["status"] = "active"
+	results, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close(ctx)
+
+	active := []models.Connection{}
+	if err := results.All(ctx, &active); err != nil {
+		return nil, err
+	}
+	if len(active) == 0 {
+		return active, nil
+	}
+
+	// Chỉ cập nhật các connection đã đọc để danh sách trả về khớp với những gì bị thay đổi
+	ids := make(bson.A, 0, len(active))
+	for _, connection := range active {
+		ids = append(ids, connection.Id)
+	}
+	_, err = r.collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "status": "active"},
+		bson.M{"$set": bson.M{"status": "inactive", "updatedAt": time.Now().UTC()}})
+	return active, err
+}
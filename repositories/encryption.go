@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/configs"
+	"draft-notification/encryption"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// encryptedFields là các field kiểu encryption.EncryptedString theo collection
+var encryptedFields = map[string][]string{
+	connectionCollectionName: {"webhookSecret"},
+	jobCollectionName:        {"message"},
+}
+
+// reEncryptValue giải mã value nếu nó là plaintext hoặc được mã hoá bằng khoá cũ,
+// giá trị trả về sẽ được mã hoá bằng khoá primary khi ghi lại
+func reEncryptValue(value bson.RawValue) (encryption.EncryptedString, bool, error) {
+	if !encryption.NeedsReEncrypt(value) {
+		return "", false, nil
+	}
+
+	var plaintext encryption.EncryptedString
+	if err := value.Unmarshal(&plaintext); err != nil {
+		return "", false, err
+	}
+	return plaintext, true, nil
+}
+
+// reEncrypt ghi lại các field mã hoá bằng khoá primary, chỉ ghi khi field chưa bị thay đổi
+// kể từ lúc đọc để không đè lên cập nhật đồng thời
+func reEncrypt(ctx context.Context, db *mongo.Database) (int64, error) {
+	var total int64
+	for name, fields := range encryptedFields {
+		collection := configs.GetCollection(db, name)
+
+		projection := bson.M{}
+		for _, field := range fields {
+			projection[field] = 1
+		}
+		cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetProjection(projection))
+		if err != nil {
+			return total, err
+		}
+
+		for cursor.Next(ctx) {
+			id := cursor.Current.Lookup("_id")
+			for _, field := range fields {
+				value, err := cursor.Current.LookupErr(field)
+				if err != nil {
+					continue
+				}
+				plaintext, needed, err := reEncryptValue(value)
+				if err != nil {
+					cursor.Close(ctx)
+					return total, err
+				}
+				if !needed {
+					continue
+				}
+
+				result, err := collection.UpdateOne(ctx, bson.M{"_id": id, field: value}, bson.M{"$set": bson.M{field: plaintext}})
+				if err != nil {
+					cursor.Close(ctx)
+					return total, err
+				}
+				total += result.ModifiedCount
+			}
+		}
+		err = cursor.Err()
+		cursor.Close(ctx)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// kvReEncrypt giống reEncrypt cho kv store, toàn bộ chạy trong một transaction.
+// Document được đọc dạng bson.D để giữ nguyên các field khác.
+func kvReEncrypt(store kvStore) (total int64, err error) {
+	err = store.update(func(tx kvTx) error {
+		for bucket, fields := range encryptedFields {
+			changed := map[string]bson.D{}
+			err := tx.forEach(bucket, func(key string, raw []byte) error {
+				var doc bson.D
+				if err := bson.Unmarshal(raw, &doc); err != nil {
+					return err
+				}
+
+				for _, field := range fields {
+					value, err := bson.Raw(raw).LookupErr(field)
+					if err != nil {
+						continue
+					}
+					plaintext, needed, err := reEncryptValue(value)
+					if err != nil {
+						return err
+					}
+					if !needed {
+						continue
+					}
+					for i := range doc {
+						if doc[i].Key == field {
+							doc[i].Value = plaintext
+						}
+					}
+					changed[key] = doc
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for key, doc := range changed {
+				if err := kvPut(tx, bucket, key, doc); err != nil {
+					return err
+				}
+			}
+			total += int64(len(changed))
+		}
+		return nil
+	})
+	return total, err
+}
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"context"
+	"crypto/rand"
+	"draft-notification/encryption"
+	"draft-notification/models"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// useTestKeyring ghi keyring gồm keys ra file rồi dùng nó cho tới hết test
+func useTestKeyring(t *testing.T, keys map[string]string, primary string) {
+	t.Helper()
+	content := "primary: " + primary + "\nkeys:\n"
+	for id, key := range keys {
+		content += "  - id: " + id + "\n    key: " + key + "\n"
+	}
+	path := filepath.Join(t.TempDir(), "keyring.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := encryption.LoadKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryption.Use(keyring)
+	t.Cleanup(func() { encryption.Use(nil) })
+}
+
+func randomKey(t *testing.T) string {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func TestKVReEncrypt(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	repos := newKV(store)
+
+	// Connection được tạo khi chưa bật mã hoá nên webhookSecret đang là plaintext
+	connection := models.Connection{
+		Id:                   primitive.NewObjectID(),
+		OrganizationId:       primitive.NewObjectID(),
+		Status:               "active",
+		WebviewServerId:      primitive.NewObjectID(),
+		UserDeliveryServerId: primitive.NewObjectID(),
+		WebhookSecret:        "webhook-secret",
+		CreatedAt:            time.Now().UTC(),
+	}
+	if err := repos.Connections.Create(ctx, connection); err != nil {
+		t.Fatal(err)
+	}
+	storedKeyId := func() string {
+		t.Helper()
+		var raw bson.Raw
+		store.view(func(tx kvTx) error {
+			raw = tx.get(connectionCollectionName, connection.Id.Hex())
+			return nil
+		})
+		value := raw.Lookup("webhookSecret")
+		if value.Type == bson.TypeString {
+			return ""
+		}
+		return value.Document().Lookup("k").StringValue()
+	}
+	assertSecret := func() {
+		t.Helper()
+		found, err := repos.Connections.FindById(ctx, connection.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.WebhookSecret != connection.WebhookSecret {
+			t.Fatalf("webhook secret = %q", found.WebhookSecret)
+		}
+	}
+
+	keys := map[string]string{"k1": randomKey(t), "k2": randomKey(t)}
+	steps := []struct {
+		name    string
+		keys    map[string]string
+		primary string
+		total   int64
+		keyId   string
+	}{
+		{"encrypt plaintext", map[string]string{"k1": keys["k1"]}, "k1", 1, "k1"},
+		{"nothing to do", map[string]string{"k1": keys["k1"]}, "k1", 0, "k1"},
+		{"rotate primary", keys, "k2", 1, "k2"},
+		{"old key removed", map[string]string{"k2": keys["k2"]}, "k2", 0, "k2"},
+	}
+
+	for _, step := range steps {
+		useTestKeyring(t, step.keys, step.primary)
+		total, err := repos.ReEncrypt(ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if total != step.total {
+			t.Fatalf("%s: re-encrypted %d, want %d", step.name, total, step.total)
+		}
+		if keyId := storedKeyId(); keyId != step.keyId {
+			t.Fatalf("%s: stored with key %q, want %q", step.name, keyId, step.keyId)
+		}
+		assertSecret()
+	}
+}
@@ -0,0 +1,235 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// jobQueueBucketName là index của các job pending và leased theo thời điểm đến hạn (runAt hoặc
+// leaseExpiresAt), để Lease không phải đọc lại mọi job. Job done vẫn ở bucket jobs để tra cứu
+// trạng thái như Mongo, chỉ bị bỏ khỏi index.
+const jobQueueBucketName = "jobs-queue"
+
+// jobFailedBucketName giữ id các job failed để Stats đếm mà không phải duyệt bucket jobs
+const jobFailedBucketName = "jobs-failed"
+
+type kvJobRepo struct {
+	store kvStore
+}
+
+func (r *kvJobRepo) Enqueue(ctx context.Context, job models.Job) error {
+	return r.store.update(func(tx kvTx) error {
+		return kvPutJob(tx, nil, job)
+	})
+}
+
+func (r *kvJobRepo) FindById(ctx context.Context, id primitive.ObjectID) (job models.Job, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		job, err = kvGet[models.Job](tx, jobCollectionName, id.Hex())
+		return err
+	})
+	return job, err
+}
+
+// Lease lấy job đến hạn sớm nhất (hoặc job có lease đã hết hạn) và giữ nó trong leaseDuration
+func (r *kvJobRepo) Lease(ctx context.Context, leaseDuration time.Duration, maxAttempts int) (leased *models.Job, err error) {
+	err = r.store.update(func(tx kvTx) error {
+		now := time.Now().UTC()
+		var due []string
+		err := tx.forEach(jobQueueBucketName, func(key string, value []byte) error {
+			if key > jobQueueKey(now, "") {
+				return errStopForEach
+			}
+			due = append(due, string(value))
+			return nil
+		})
+		if err != nil && err != errStopForEach {
+			return err
+		}
+
+		for _, id := range due {
+			job, err := kvGet[models.Job](tx, jobCollectionName, id)
+			if err != nil {
+				return err
+			}
+			old := job
+			if job.Status == models.JobStatusLeased {
+				if !job.LeaseExpiresAt.Before(now) {
+					continue
+				}
+				// Lease hết hạn được tính là một lần thử, lần thử cuối thì job chuyển sang failed
+				job.Attempts++
+				job.LastError = errLeaseExpired
+				if job.Attempts >= maxAttempts {
+					job.Status = models.JobStatusFailed
+					job.LeaseExpiresAt = time.Time{}
+					job.UpdatedAt = now
+					if err := kvPutJob(tx, &old, job); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+
+			job.Status = models.JobStatusLeased
+			job.LeaseId = primitive.NewObjectID()
+			job.LeasedAt = now
+			job.LeaseExpiresAt = now.Add(leaseDuration)
+			job.UpdatedAt = now
+			leased = &job
+			return kvPutJob(tx, &old, job)
+		}
+		return nil
+	})
+	return leased, err
+}
+
+func (r *kvJobRepo) Ack(ctx context.Context, leased models.Job) error {
+	return r.modifyLeased(leased, func(job *models.Job) {
+		job.Status = models.JobStatusDone
+	})
+}
+
+func (r *kvJobRepo) Release(ctx context.Context, leased models.Job) error {
+	return r.modifyLeased(leased, func(job *models.Job) {
+		job.Status = models.JobStatusPending
+		job.RunAt = time.Now().UTC()
+	})
+}
+
+func (r *kvJobRepo) Fail(ctx context.Context, failed models.Job, cause error, retryAt time.Time, final bool) error {
+	return r.modifyLeased(failed, func(job *models.Job) {
+		job.Status = models.JobStatusPending
+		if final {
+			job.Status = models.JobStatusFailed
+		}
+		job.Attempts = failed.Attempts + 1
+		job.LastError = cause.Error()
+		job.RunAt = retryAt.UTC()
+	})
+}
+
+// Stats chỉ đọc các job trong index jobs-queue và đếm key của jobs-failed, job done tích luỹ
+// trong bucket jobs không làm probe /readyz hay scrape metrics chậm dần
+func (r *kvJobRepo) Stats(ctx context.Context) (stats JobStats, err error) {
+	stats.Counts = map[string]int64{
+		models.JobStatusPending: 0,
+		models.JobStatusLeased:  0,
+		models.JobStatusFailed:  0,
+	}
+	err = r.store.view(func(tx kvTx) error {
+		err := tx.forEach(jobQueueBucketName, func(key string, value []byte) error {
+			// Chỉ decode các field cần thiết, không giải mã nội dung notification
+			var job struct {
+				Status   string    `bson:"status"`
+				RunAt    time.Time `bson:"runAt"`
+				LeasedAt time.Time `bson:"leasedAt"`
+			}
+			raw := tx.get(jobCollectionName, string(value))
+			if raw == nil {
+				return nil
+			}
+			if err := bson.Unmarshal(raw, &job); err != nil {
+				return err
+			}
+
+			switch job.Status {
+			case models.JobStatusPending:
+				stats.Counts[job.Status]++
+				if stats.OldestRunAt.IsZero() || job.RunAt.Before(stats.OldestRunAt) {
+					stats.OldestRunAt = job.RunAt
+				}
+			case models.JobStatusLeased:
+				stats.Counts[job.Status]++
+				if stats.OldestLeasedAt.IsZero() || job.LeasedAt.Before(stats.OldestLeasedAt) {
+					stats.OldestLeasedAt = job.LeasedAt
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return tx.forEach(jobFailedBucketName, func(key string, value []byte) error {
+			stats.Counts[models.JobStatusFailed]++
+			return nil
+		})
+	})
+	return stats, err
+}
+
+// modifyLeased chỉ cập nhật job khi nó vẫn đang được giữ bởi đúng lần lease của leased
+func (r *kvJobRepo) modifyLeased(leased models.Job, fn func(job *models.Job)) error {
+	return r.store.update(func(tx kvTx) error {
+		job, err := kvGet[models.Job](tx, jobCollectionName, leased.Id.Hex())
+		if err == ErrNotFound || (err == nil && (job.Status != models.JobStatusLeased || job.LeaseId != leased.LeaseId)) {
+			return ErrLeaseLost
+		}
+		if err != nil {
+			return err
+		}
+
+		old := job
+		fn(&job)
+		job.LeaseExpiresAt = time.Time{}
+		job.UpdatedAt = time.Now().UTC()
+		return kvPutJob(tx, &old, job)
+	})
+}
+
+// kvPutJob ghi job và cập nhật các index jobs-queue, jobs-failed, old là giá trị đang lưu
+// (nil nếu job mới)
+func kvPutJob(tx kvTx, old *models.Job, job models.Job) error {
+	if old != nil {
+		if bucket, key, ok := jobIndexEntry(*old); ok {
+			if err := tx.delete(bucket, key); err != nil {
+				return err
+			}
+		}
+	}
+	if bucket, key, ok := jobIndexEntry(job); ok {
+		if err := tx.put(bucket, key, []byte(job.Id.Hex())); err != nil {
+			return err
+		}
+	}
+	return kvPut(tx, jobCollectionName, job.Id.Hex(), job)
+}
+
+// jobIndexEntry trả về bucket index và key của job pending, leased hoặc failed
+func jobIndexEntry(job models.Job) (bucket, key string, ok bool) {
+	switch job.Status {
+	case models.JobStatusPending:
+		return jobQueueBucketName, jobQueueKey(job.RunAt, job.Id.Hex()), true
+	case models.JobStatusLeased:
+		return jobQueueBucketName, jobQueueKey(job.LeaseExpiresAt, job.Id.Hex()), true
+	case models.JobStatusFailed:
+		return jobFailedBucketName, job.Id.Hex(), true
+	}
+	return "", "", false
+}
+
+// jobQueueKey sắp xếp theo thời gian khi so sánh chuỗi. Thời gian chỉ lấy tới millisecond
+// như khi lưu dạng BSON để key tính từ job đọc lại khớp với key lúc ghi.
+func jobQueueKey(at time.Time, id string) string {
+	return at.UTC().Format("20060102150405.000") + "/" + id
+}
+
+// kvIndexJobQueue tạo lại các index jobs-queue và jobs-failed từ bucket jobs
+func kvIndexJobQueue(store kvStore) error {
+	return store.update(func(tx kvTx) error {
+		jobs, err := kvFind[models.Job](tx, jobCollectionName, nil)
+		if err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			if err := kvPutJob(tx, nil, job); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func enqueueJob(t *testing.T, repos Repositories, runAt time.Time) models.Job {
+	t.Helper()
+	job := models.Job{
+		Id:        primitive.NewObjectID(),
+		Message:   "hello",
+		Status:    models.JobStatusPending,
+		RunAt:     runAt,
+		CreatedAt: runAt,
+		UpdatedAt: runAt,
+	}
+	if err := repos.Jobs.Enqueue(context.Background(), job); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	return job
+}
+
+func TestKVJobLeaseOrder(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemory()
+	now := time.Now()
+	later := enqueueJob(t, repos, now.Add(-time.Second))
+	earlier := enqueueJob(t, repos, now.Add(-time.Minute))
+	enqueueJob(t, repos, now.Add(time.Hour))
+
+	for _, want := range []models.Job{earlier, later} {
+		job, err := repos.Jobs.Lease(ctx, time.Minute, 5)
+		if err != nil || job == nil {
+			t.Fatalf("Lease = %v, %v", job, err)
+		}
+		if job.Id != want.Id || job.Status != models.JobStatusLeased || job.LeaseId.IsZero() {
+			t.Fatalf("Lease = %+v, want job %s leased", job, want.Id.Hex())
+		}
+	}
+
+	job, err := repos.Jobs.Lease(ctx, time.Minute, 5)
+	if err != nil || job != nil {
+		t.Fatalf("Lease with no due job = %v, %v", job, err)
+	}
+}
+
+func TestKVJobAckKeepsDoneJob(t *testing.T) {
+	ctx := context.Background()
+	bolt, err := NewBolt(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatalf("NewBolt: %v", err)
+	}
+	defer bolt.Close(ctx)
+	if err := bolt.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	for name, repos := range map[string]Repositories{"memory": NewMemory(), "bolt": bolt} {
+		t.Run(name, func(t *testing.T) {
+			enqueueJob(t, repos, time.Now().Add(-time.Second))
+
+			job, err := repos.Jobs.Lease(ctx, time.Minute, 5)
+			if err != nil || job == nil {
+				t.Fatalf("Lease = %v, %v", job, err)
+			}
+			if err := repos.Jobs.Ack(ctx, *job); err != nil {
+				t.Fatalf("Ack: %v", err)
+			}
+			// Job done vẫn đọc được như với Mongo, chỉ không còn được lease lại
+			done, err := repos.Jobs.FindById(ctx, job.Id)
+			if err != nil || done.Status != models.JobStatusDone {
+				t.Fatalf("FindById after Ack = %+v, %v, want status done", done, err)
+			}
+			if next, err := repos.Jobs.Lease(ctx, time.Minute, 5); err != nil || next != nil {
+				t.Fatalf("Lease after Ack = %v, %v, want no job", next, err)
+			}
+			if err := repos.Jobs.Ack(ctx, *job); !errors.Is(err, ErrLeaseLost) {
+				t.Fatalf("second Ack = %v, want ErrLeaseLost", err)
+			}
+		})
+	}
+}
+
+func TestKVJobExpiredLease(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemory()
+	enqueueJob(t, repos, time.Now().Add(-time.Second))
+
+	first, err := repos.Jobs.Lease(ctx, -time.Second, 2)
+	if err != nil || first == nil {
+		t.Fatalf("Lease = %v, %v", first, err)
+	}
+
+	// Lease đã hết hạn nên job được lấy lại và tính là một lần thử
+	second, err := repos.Jobs.Lease(ctx, -time.Second, 2)
+	if err != nil || second == nil {
+		t.Fatalf("second Lease = %v, %v", second, err)
+	}
+	if second.Attempts != 1 || second.LeaseId == first.LeaseId {
+		t.Fatalf("second Lease = %+v, want attempts 1 and a new lease id", second)
+	}
+
+	// Worker đầu trả kết quả muộn không được ghi đè lần lease sau
+	if err := repos.Jobs.Ack(ctx, *first); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("late Ack = %v, want ErrLeaseLost", err)
+	}
+	if err := repos.Jobs.Fail(ctx, *first, errors.New("late"), time.Now(), false); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("late Fail = %v, want ErrLeaseLost", err)
+	}
+
+	// Hết lần thử thì job chuyển sang failed thay vì được lease lại
+	third, err := repos.Jobs.Lease(ctx, time.Minute, 2)
+	if err != nil || third != nil {
+		t.Fatalf("third Lease = %v, %v, want no job", third, err)
+	}
+	job, err := repos.Jobs.FindById(ctx, first.Id)
+	if err != nil {
+		t.Fatalf("FindById: %v", err)
+	}
+	if job.Status != models.JobStatusFailed || job.Attempts != 2 || job.LastError != errLeaseExpired {
+		t.Fatalf("job = %+v, want failed after 2 attempts", job)
+	}
+}
+
+func TestKVJobFailRetries(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemory()
+	enqueueJob(t, repos, time.Now().Add(-time.Second))
+
+	job, err := repos.Jobs.Lease(ctx, time.Minute, 5)
+	if err != nil || job == nil {
+		t.Fatalf("Lease = %v, %v", job, err)
+	}
+	if err := repos.Jobs.Fail(ctx, *job, errors.New("boom"), time.Now().Add(time.Hour), false); err != nil {
+		t.Fatalf("Fail: %v", err)
+	}
+	if next, err := repos.Jobs.Lease(ctx, time.Minute, 5); err != nil || next != nil {
+		t.Fatalf("Lease before retryAt = %v, %v", next, err)
+	}
+
+	stats, err := repos.Jobs.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.Counts[models.JobStatusPending] != 1 || stats.Counts[models.JobStatusLeased] != 0 {
+		t.Fatalf("Stats = %+v", stats.Counts)
+	}
+}
+
+func TestKVJobStats(t *testing.T) {
+	ctx := context.Background()
+	bolt, err := NewBolt(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatalf("NewBolt: %v", err)
+	}
+	defer bolt.Close(ctx)
+	if err := bolt.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	for name, repos := range map[string]Repositories{"memory": NewMemory(), "bolt": bolt} {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now().UTC().Truncate(time.Millisecond)
+			enqueueJob(t, repos, now.Add(-time.Hour))
+			enqueueJob(t, repos, now.Add(-2*time.Hour))
+			oldest := enqueueJob(t, repos, now.Add(-3*time.Hour))
+
+			// Job done không được đếm, job failed được đếm nhưng không tính vào tuổi pending
+			done, err := repos.Jobs.Lease(ctx, time.Minute, 5)
+			if err != nil || done == nil || done.Id != oldest.Id {
+				t.Fatalf("Lease = %v, %v", done, err)
+			}
+			if err := repos.Jobs.Ack(ctx, *done); err != nil {
+				t.Fatalf("Ack: %v", err)
+			}
+			failed, err := repos.Jobs.Lease(ctx, time.Minute, 5)
+			if err != nil || failed == nil {
+				t.Fatalf("Lease = %v, %v", failed, err)
+			}
+			if err := repos.Jobs.Fail(ctx, *failed, errors.New("boom"), now, true); err != nil {
+				t.Fatalf("Fail: %v", err)
+			}
+			leased, err := repos.Jobs.Lease(ctx, time.Minute, 5)
+			if err != nil || leased == nil {
+				t.Fatalf("Lease = %v, %v", leased, err)
+			}
+			pending := enqueueJob(t, repos, now.Add(time.Minute))
+
+			stats, err := repos.Jobs.Stats(ctx)
+			if err != nil {
+				t.Fatalf("Stats: %v", err)
+			}
+			want := map[string]int64{
+				models.JobStatusPending: 1,
+				models.JobStatusLeased:  1,
+				models.JobStatusFailed:  1,
+			}
+			for status, count := range want {
+				if stats.Counts[status] != count {
+					t.Errorf("Counts[%s] = %d, want %d", status, stats.Counts[status], count)
+				}
+			}
+			if !stats.OldestRunAt.Equal(pending.RunAt) {
+				t.Errorf("OldestRunAt = %v, want %v", stats.OldestRunAt, pending.RunAt)
+			}
+			if !stats.OldestLeasedAt.Equal(leased.LeasedAt.Truncate(time.Millisecond)) {
+				t.Errorf("OldestLeasedAt = %v, want %v", stats.OldestLeasedAt, leased.LeasedAt)
+			}
+		})
+	}
+}
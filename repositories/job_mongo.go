@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoJobRepo struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoJobRepo) Enqueue(ctx context.Context, job models.Job) error {
+	_, err := r.collection.InsertOne(ctx, job)
+	return err
+}
+
+func (r *mongoJobRepo) FindById(ctx context.Context, id primitive.ObjectID) (models.Job, error) {
+	var job models.Job
+	err := findOne(ctx, r.collection, bson.M{"_id": id}, &job)
+	return job, err
+}
+
+// Lease lấy job đến hạn cũ nhất (hoặc job có lease đã hết hạn) và giữ nó trong leaseDuration
+func (r *mongoJobRepo) Lease(ctx context.Context, leaseDuration time.Duration, maxAttempts int) (*models.Job, error) {
+	now := time.Now().UTC()
+	expired := bson.M{"status": models.JobStatusLeased, "leaseExpiresAt": bson.M{"$lt": now}}
+
+	// Job có lease hết hạn ở lần thử cuối không được lease lại
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"$and": bson.A{expired, bson.M{"attempts": bson.M{"$gte": maxAttempts - 1}}}},
+		bson.M{
+			"$set": bson.M{"status": models.JobStatusFailed, "lastError": errLeaseExpired, "leaseExpiresAt": time.Time{}, "updatedAt": now},
+			"$inc": bson.M{"attempts": 1},
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": models.JobStatusPending, "runAt": bson.M{"$lte": now}},
+		expired,
+	}}
+	// Update dạng pipeline để chỉ tăng attempts khi lấy lại job có lease hết hạn
+	wasLeased := bson.M{"$eq": bson.A{"$status", models.JobStatusLeased}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"attempts":       bson.M{"$cond": bson.A{wasLeased, bson.M{"$add": bson.A{"$attempts", 1}}, "$attempts"}},
+		"lastError":      bson.M{"$cond": bson.A{wasLeased, errLeaseExpired, "$lastError"}},
+		"status":         models.JobStatusLeased,
+		"leaseId":        primitive.NewObjectID(),
+		"leasedAt":       now,
+		"leaseExpiresAt": now.Add(leaseDuration),
+		"updatedAt":      now,
+	}}}}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "runAt", Value: 1}}).SetReturnDocument(options.After)
+
+	var job models.Job
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *mongoJobRepo) Ack(ctx context.Context, job models.Job) error {
+	return r.setLeased(ctx, job, bson.M{"status": models.JobStatusDone})
+}
+
+func (r *mongoJobRepo) Release(ctx context.Context, job models.Job) error {
+	return r.setLeased(ctx, job, bson.M{"status": models.JobStatusPending, "runAt": time.Now().UTC()})
+}
+
+func (r *mongoJobRepo) Fail(ctx context.Context, job models.Job, cause error, retryAt time.Time, final bool) error {
+	status := models.JobStatusPending
+	if final {
+		status = models.JobStatusFailed
+	}
+	return r.setLeased(ctx, job, bson.M{
+		"status":    status,
+		"attempts":  job.Attempts + 1,
+		"lastError": cause.Error(),
+		"runAt":     retryAt.UTC(),
+	})
+}
+
+// Các thao tác sau lease chỉ áp dụng cho đúng lần lease đã lấy job, tránh ghi đè
+// job đã bị worker khác lấy lại sau khi lease hết hạn
+func (r *mongoJobRepo) setLeased(ctx context.Context, job models.Job, fields bson.M) error {
+	fields["leaseExpiresAt"] = time.Time{}
+	fields["updatedAt"] = time.Now().UTC()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": job.Id, "status": models.JobStatusLeased, "leaseId": job.LeaseId},
+		bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (r *mongoJobRepo) Stats(ctx context.Context) (JobStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": bson.M{"$in": bson.A{models.JobStatusPending, models.JobStatusLeased, models.JobStatusFailed}}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":            "$status",
+			"count":          bson.M{"$sum": 1},
+			"oldestRunAt":    bson.M{"$min": "$runAt"},
+			"oldestLeasedAt": bson.M{"$min": "$leasedAt"},
+		}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return JobStats{}, err
+	}
+
+	var groups []struct {
+		Status      string    `bson:"_id"`
+		Count       int64     `bson:"count"`
+		OldestRun   time.Time `bson:"oldestRunAt"`
+		OldestLease time.Time `bson:"oldestLeasedAt"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return JobStats{}, err
+	}
+
+	stats := JobStats{Counts: map[string]int64{
+		models.JobStatusPending: 0,
+		models.JobStatusLeased:  0,
+		models.JobStatusFailed:  0,
+	}}
+	for _, group := range groups {
+		stats.Counts[group.Status] = group.Count
+		switch group.Status {
+		case models.JobStatusPending:
+			stats.OldestRunAt = group.OldestRun
+		case models.JobStatusLeased:
+			stats.OldestLeasedAt = group.OldestLease
+		}
+	}
+	return stats, nil
+}
+
+// errLeaseExpired là lastError của lần thử mà worker không trả kết quả trước khi lease hết hạn
+const errLeaseExpired = "lease expired"
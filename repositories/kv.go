@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// kvStore là storage key-value chia theo bucket, dùng cho các backend không cần MongoDB.
+// Document được lưu dạng BSON giống hệt khi lưu vào Mongo.
+type kvStore interface {
+	view(fn func(tx kvTx) error) error
+	// update chạy fn trong một transaction, mọi thay đổi bị huỷ nếu fn trả về lỗi
+	update(fn func(tx kvTx) error) error
+	close() error
+}
+
+type kvTx interface {
+	// get trả về nil nếu key không tồn tại
+	get(bucket, key string) []byte
+	put(bucket, key string, value []byte) error
+	delete(bucket, key string) error
+	// forEach duyệt bucket theo thứ tự key tăng dần
+	forEach(bucket string, fn func(key string, value []byte) error) error
+}
+
+func kvGet[T any](tx kvTx, bucket, key string) (T, error) {
+	var doc T
+	raw := tx.get(bucket, key)
+	if raw == nil {
+		return doc, ErrNotFound
+	}
+	err := bson.Unmarshal(raw, &doc)
+	return doc, err
+}
+
+func kvPut(tx kvTx, bucket, key string, doc interface{}) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return tx.put(bucket, key, raw)
+}
+
+// kvFind trả về các document trong bucket thoả match (match nil thì lấy tất cả)
+func kvFind[T any](tx kvTx, bucket string, match func(T) bool) ([]T, error) {
+	list := []T{}
+	err := tx.forEach(bucket, func(key string, raw []byte) error {
+		var doc T
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			return err
+		}
+		if match == nil || match(doc) {
+			list = append(list, doc)
+		}
+		return nil
+	})
+	return list, err
+}
+
+// kvPage cắt một trang từ danh sách theo limit/page giống skip/limit của Mongo
+func kvPage[T any](list []T, limit, page int) []T {
+	start := page * limit
+	if start >= len(list) {
+		return []T{}
+	}
+	end := start + limit
+	if limit <= 0 || end > len(list) {
+		end = len(list)
+	}
+	return list[start:end]
+}
+
+// keywordMatcher so khớp tên theo regex không phân biệt hoa thường, giống $regex với $options "i"
+func keywordMatcher(keyword string) (func(string) bool, error) {
+	if keyword == "" {
+		return func(string) bool { return true }, nil
+	}
+	re, err := regexp.Compile("(?i)" + keyword)
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var errReadOnlyTx = errors.New("write in read-only transaction")
+
+// errStopForEach được fn của forEach trả về để dừng duyệt sớm
+var errStopForEach = errors.New("stop iteration")
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltStore lưu dữ liệu vào một file bbolt, dùng cho triển khai một node không có MongoDB.
+// Mỗi collection là một bucket, key là ObjectID dạng hex.
+type boltStore struct {
+	db *bolt.DB
+}
+
+func openBoltStore(path string) (*boltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &boltStore{db: db}, nil
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (s *boltStore) view(fn func(tx kvTx) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+func (s *boltStore) update(fn func(tx kvTx) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+func (s *boltStore) close() error {
+	return s.db.Close()
+}
+
+// createBuckets tạo trước các bucket cho mọi collection
+func (s *boltStore) createBuckets(names []string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range names {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltStore) deleteBucket(name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(name)); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		return nil
+	})
+}
+
+func (tx *boltTx) get(bucket, key string) []byte {
+	b := tx.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+	// Giá trị của bbolt chỉ hợp lệ trong transaction nên cần sao chép
+	value := b.Get([]byte(key))
+	if value == nil {
+		return nil
+	}
+	return append([]byte(nil), value...)
+}
+
+func (tx *boltTx) put(bucket, key string, value []byte) error {
+	b, err := tx.tx.CreateBucketIfNotExists([]byte(bucket))
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), value)
+}
+
+func (tx *boltTx) delete(bucket, key string) error {
+	b := tx.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+	return b.Delete([]byte(key))
+}
+
+func (tx *boltTx) forEach(bucket string, fn func(key string, value []byte) error) error {
+	b := tx.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+	return b.ForEach(func(key, value []byte) error {
+		return fn(string(key), value)
+	})
+}
@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"sync"
+)
+
+// memoryStore giữ toàn bộ dữ liệu trong bộ nhớ, mất khi process dừng.
+// Thay đổi trong transaction được ghi riêng theo key và chỉ áp dụng khi commit.
+type memoryStore struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string][]byte
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{buckets: map[string]map[string][]byte{}}
+}
+
+type memoryTx struct {
+	store    *memoryStore
+	writable bool
+	// dirty là các key đã ghi trong transaction theo bucket, giá trị nil là key đã bị xoá
+	dirty map[string]map[string][]byte
+}
+
+func (s *memoryStore) view(fn func(tx kvTx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return fn(&memoryTx{store: s})
+}
+
+func (s *memoryStore) update(fn func(tx kvTx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &memoryTx{store: s, writable: true, dirty: map[string]map[string][]byte{}}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	for name, changes := range tx.dirty {
+		bucket := s.buckets[name]
+		if bucket == nil {
+			bucket = map[string][]byte{}
+			s.buckets[name] = bucket
+		}
+		for key, value := range changes {
+			if value == nil {
+				delete(bucket, key)
+			} else {
+				bucket[key] = value
+			}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) close() error {
+	return nil
+}
+
+func (tx *memoryTx) get(bucket, key string) []byte {
+	if value, ok := tx.dirty[bucket][key]; ok {
+		return value
+	}
+	return tx.store.buckets[bucket][key]
+}
+
+func (tx *memoryTx) put(bucket, key string, value []byte) error {
+	if !tx.writable {
+		return errReadOnlyTx
+	}
+	tx.changes(bucket)[key] = append([]byte{}, value...)
+	return nil
+}
+
+func (tx *memoryTx) delete(bucket, key string) error {
+	if !tx.writable {
+		return errReadOnlyTx
+	}
+	tx.changes(bucket)[key] = nil
+	return nil
+}
+
+func (tx *memoryTx) changes(bucket string) map[string][]byte {
+	changes, ok := tx.dirty[bucket]
+	if !ok {
+		changes = map[string][]byte{}
+		tx.dirty[bucket] = changes
+	}
+	return changes
+}
+
+func (tx *memoryTx) forEach(bucket string, fn func(key string, value []byte) error) error {
+	values := tx.store.buckets[bucket]
+	if changes := tx.dirty[bucket]; len(changes) > 0 {
+		merged := make(map[string][]byte, len(values)+len(changes))
+		for key, value := range values {
+			merged[key] = value
+		}
+		for key, value := range changes {
+			if value == nil {
+				delete(merged, key)
+			} else {
+				merged[key] = value
+			}
+		}
+		values = merged
+	}
+
+	for _, key := range sortedKeys(values) {
+		if err := fn(key, values[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newKV tạo các repository trên một kvStore
+func newKV(store kvStore) Repositories {
+	return Repositories{
+		WebviewServers:      &kvWebviewServerRepo{store: store},
+		UserDeliveryServers: &kvUserDeliveryServerRepo{store: store},
+		Connections:         &kvConnectionRepo{store: store},
+		Jobs:                &kvJobRepo{store: store},
+		Admins:              &kvAdminRepo{store: store},
+		AdminSessions:       &kvAdminSessionRepo{store: store},
+		Organizations:       &kvOrganizationRepo{store: store},
+		ConnectionConsents:  &kvConnectionConsentRepo{store: store},
+		ApiKeys:             &kvApiKeyRepo{store: store},
+		AuditEvents:         &kvAuditEventRepo{store: store},
+		assignOrganization: func(ctx context.Context, organizationId primitive.ObjectID) (int64, error) {
+			return kvAssignOrganization(store, organizationId)
+		},
+		reEncrypt: func(ctx context.Context) (int64, error) {
+			return kvReEncrypt(store)
+		},
+		close: func(ctx context.Context) error {
+			return store.close()
+		},
+	}
+}
+
+// NewBolt mở (hoặc tạo) file bbolt tại path và tạo các repository trên đó.
+// File bị khoá bởi process đang mở nên các role cần chạy chung bằng lệnh all.
+func NewBolt(path string) (Repositories, error) {
+	store, err := openBoltStore(path)
+	if err != nil {
+		return Repositories{}, err
+	}
+
+	repos := newKV(store)
+	migrations := kvMigrationLog{store: store}
+	repos.migrate = func(ctx context.Context) error {
+		return migrateUp(ctx, store, boltMigrations, migrations)
+	}
+	repos.migrateDown = func(ctx context.Context, to int) error {
+		return migrateDown(ctx, store, boltMigrations, migrations, to)
+	}
+	repos.migrations = func(ctx context.Context) ([]MigrationStatus, error) {
+		return migrationStatus(ctx, boltMigrations, migrations)
+	}
+	repos.checkMigrated = func(ctx context.Context) error {
+		return checkMigrations(ctx, boltMigrations, migrations)
+	}
+	return repos, nil
+}
+
+// NewMemory tạo các repository lưu trong bộ nhớ, dùng cho unit test và phát triển local
+func NewMemory() Repositories {
+	return newKV(newMemoryStore())
+}
+
+// kvAssignOrganization gán organizationId cho các document chưa có organizationId.
+// Document được đọc dạng bson.D để giữ nguyên các field không thuộc model hiện tại.
+func kvAssignOrganization(store kvStore, organizationId primitive.ObjectID) (total int64, err error) {
+	err = store.update(func(tx kvTx) error {
+		for _, bucket := range tenantCollectionNames {
+			orphans := map[string]bson.D{}
+			err := tx.forEach(bucket, func(key string, raw []byte) error {
+				var doc bson.D
+				if err := bson.Unmarshal(raw, &doc); err != nil {
+					return err
+				}
+				for _, field := range doc {
+					if field.Key == "organizationId" {
+						return nil
+					}
+				}
+				orphans[key] = append(doc, bson.E{Key: "organizationId", Value: organizationId})
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for key, doc := range orphans {
+				if err := kvPut(tx, bucket, key, doc); err != nil {
+					return err
+				}
+			}
+			total += int64(len(orphans))
+		}
+		return nil
+	})
+	return total, err
+}
+
+// kvHashLegacyApiKeys thay các API key plaintext lưu trước đây bằng prefix và hash
+func kvHashLegacyApiKeys(store kvStore) error {
+	return store.update(func(tx kvTx) error {
+		legacy := map[string]bson.M{}
+		err := tx.forEach(connectionCollectionName, func(key string, raw []byte) error {
+			var doc bson.M
+			if err := bson.Unmarshal(raw, &doc); err != nil {
+				return err
+			}
+			if hashLegacyApiKeys(doc) {
+				legacy[key] = doc
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for key, doc := range legacy {
+			if err := kvPut(tx, connectionCollectionName, key, doc); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Collection (bucket với bolt) lưu các migration đã chạy
+const migrationCollectionName = "migrations"
+
+var ErrIrreversibleMigration = errors.New("migration cannot be reverted")
+
+// MigrationStatus là trạng thái một migration, AppliedAt bằng zero nếu chưa chạy
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// migration là một bước thay đổi schema của storage T. Các bước phải idempotent vì
+// database tạo trước khi có bảng migrations sẽ chạy lại từ đầu.
+type migration[T any] struct {
+	version int
+	name    string
+	up      func(ctx context.Context, target T) error
+	// nil nếu migration không thể hoàn tác
+	down func(ctx context.Context, target T) error
+}
+
+// migrationLog ghi lại các migration đã chạy trên một storage
+type migrationLog interface {
+	applied(ctx context.Context) (map[int]time.Time, error)
+	record(ctx context.Context, version int, name string, appliedAt time.Time) error
+	remove(ctx context.Context, version int) error
+}
+
+// migrateUp chạy lần lượt các migration chưa được áp dụng
+func migrateUp[T any](ctx context.Context, target T, steps []migration[T], log migrationLog) error {
+	applied, err := log.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range steps {
+		if _, ok := applied[step.version]; ok {
+			continue
+		}
+		if err := step.up(ctx, target); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", step.version, step.name, err)
+		}
+		if err := log.record(ctx, step.version, step.name, time.Now().UTC()); err != nil {
+			return err
+		}
+		slog.Info("Applied migration", "version", step.version, "name", step.name)
+	}
+	return nil
+}
+
+// migrateDown hoàn tác các migration đã áp dụng có version lớn hơn to, từ mới nhất về cũ nhất
+func migrateDown[T any](ctx context.Context, target T, steps []migration[T], log migrationLog, to int) error {
+	applied, err := log.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+		if step.version <= to {
+			break
+		}
+		if _, ok := applied[step.version]; !ok {
+			continue
+		}
+		if step.down == nil {
+			return fmt.Errorf("migration %d (%s): %w", step.version, step.name, ErrIrreversibleMigration)
+		}
+		if err := step.down(ctx, target); err != nil {
+			return fmt.Errorf("revert migration %d (%s): %w", step.version, step.name, err)
+		}
+		if err := log.remove(ctx, step.version); err != nil {
+			return err
+		}
+		slog.Info("Reverted migration", "version", step.version, "name", step.name)
+	}
+	return nil
+}
+
+// migrationStatus trả về trạng thái mọi migration theo thứ tự version
+func migrationStatus[T any](ctx context.Context, steps []migration[T], log migrationLog) ([]MigrationStatus, error) {
+	applied, err := log.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(steps))
+	for _, step := range steps {
+		statuses = append(statuses, MigrationStatus{Version: step.version, Name: step.name, AppliedAt: applied[step.version]})
+	}
+	return statuses, nil
+}
+
+// checkMigrations trả về lỗi nếu còn migration chưa được áp dụng
+func checkMigrations[T any](ctx context.Context, steps []migration[T], log migrationLog) error {
+	applied, err := log.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range steps {
+		if _, ok := applied[step.version]; !ok {
+			return fmt.Errorf("migration %d (%s) is not applied, run migrate", step.version, step.name)
+		}
+	}
+	return nil
+}
+
+// camelCaseFieldNames đổi tên field lowercase mà mongo-driver tự sinh khi model chưa có
+// bson tag sang tên camelCase trong tag hiện tại. Field có tên không đổi (name, status, ...)
+// không cần liệt kê.
+var camelCaseFieldNames = map[string]string{
+	"actorid":                          "actorId",
+	"actorusername":                    "actorUsername",
+	"adminid":                          "adminId",
+	"allowedips":                       "allowedIps",
+	"connectionid":                     "connectionId",
+	"createdat":                        "createdAt",
+	"createdby":                        "createdBy",
+	"entityid":                         "entityId",
+	"entitytype":                       "entityType",
+	"expiresat":                        "expiresAt",
+	"granteeorganizationid":            "granteeOrganizationId",
+	"lasterror":                        "lastError",
+	"leaseexpiresat":                   "leaseExpiresAt",
+	"leasedat":                         "leasedAt",
+	"leaseid":                          "leaseId",
+	"organizationid":                   "organizationId",
+	"ownerid":                          "ownerId",
+	"ownertype":                        "ownerType",
+	"parentid":                         "parentId",
+	"passwordhash":                     "passwordHash",
+	"refreshtokenhash":                 "refreshTokenHash",
+	"requestid":                        "requestId",
+	"revokedat":                        "revokedAt",
+	"runat":                            "runAt",
+	"sourceip":                         "sourceIp",
+	"tracecontext":                     "traceContext",
+	"updatedat":                        "updatedAt",
+	"userdeliveryserverapikeyhash":     "userDeliveryServerApiKeyHash",
+	"userdeliveryserverapikeyprefix":   "userDeliveryServerApiKeyPrefix",
+	"userdeliveryserverapikeyrotation": "userDeliveryServerApiKeyRotation",
+	"userdeliveryserverid":             "userDeliveryServerId",
+	"userdeliveryserverwebhookurl":     "userDeliveryServerWebHookUrl",
+	"webhooksecret":                    "webhookSecret",
+	"webhookverifiedat":                "webhookVerifiedAt",
+	"webhookverifiedurl":               "webhookVerifiedUrl",
+	"webviewserverapikeyhash":          "webviewServerApiKeyHash",
+	"webviewserverapikeyprefix":        "webviewServerApiKeyPrefix",
+	"webviewserverapikeyrotation":      "webviewServerApiKeyRotation",
+	"webviewserverid":                  "webviewServerId",
+}
+
+// camelCaseSubdocuments là các field (tên cũ) chứa subdocument, field bên trong được đổi
+// tên theo camelCaseSubdocumentFields
+var camelCaseSubdocuments = []string{"webviewserverapikeyrotation", "userdeliveryserverapikeyrotation"}
+
+var camelCaseSubdocumentFields = map[string]string{
+	"previouskeyprefix":    "previousKeyPrefix",
+	"previouskeyhash":      "previousKeyHash",
+	"previouskeyexpiresat": "previousKeyExpiresAt",
+	"rotatedby":            "rotatedBy",
+	"rotatedat":            "rotatedAt",
+}
+
+// fieldRenames trả về map tên cũ -> tên mới, hoặc ngược lại khi reverse
+func fieldRenames(names map[string]string, reverse bool) map[string]string {
+	if !reverse {
+		return names
+	}
+	reversed := make(map[string]string, len(names))
+	for from, to := range names {
+		reversed[to] = from
+	}
+	return reversed
+}
+
+// camelCaseName đổi từng phần của path ("a.b") hoặc tên index ("a_b") sang tên mới
+func camelCaseName(name string, sep string) string {
+	parts := strings.Split(name, sep)
+	for i, part := range parts {
+		if renamed, ok := camelCaseFieldNames[part]; ok {
+			parts[i] = renamed
+		} else if renamed, ok := camelCaseSubdocumentFields[part]; ok {
+			parts[i] = renamed
+		}
+	}
+	return strings.Join(parts, sep)
+}
@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// boltMigrations là lịch sử schema của file bolt. Các ràng buộc unique được kiểm tra
+// trong transaction ghi nên không cần bước tạo index như MongoDB.
+var boltMigrations = []migration[*boltStore]{
+	{
+		version: 1,
+		name:    "create buckets",
+		up: func(ctx context.Context, store *boltStore) error {
+			return store.createBuckets(collectionNames)
+		},
+	},
+	{
+		version: 2,
+		name:    "hash legacy api keys",
+		up: func(ctx context.Context, store *boltStore) error {
+			return kvHashLegacyApiKeys(store)
+		},
+	},
+	{
+		version: 3,
+		name:    "camelCase field names",
+		up: func(ctx context.Context, store *boltStore) error {
+			return kvRenameFields(store, false)
+		},
+		down: func(ctx context.Context, store *boltStore) error {
+			return kvRenameFields(store, true)
+		},
+	},
+	{
+		version: 4,
+		name:    "job queue index",
+		up: func(ctx context.Context, store *boltStore) error {
+			return kvIndexJobQueue(store)
+		},
+		down: func(ctx context.Context, store *boltStore) error {
+			return store.deleteBucket(jobQueueBucketName)
+		},
+	},
+	{
+		version: 5,
+		name:    "failed job index",
+		up: func(ctx context.Context, store *boltStore) error {
+			return kvIndexJobQueue(store)
+		},
+		down: func(ctx context.Context, store *boltStore) error {
+			return store.deleteBucket(jobFailedBucketName)
+		},
+	},
+}
+
+// kvRenameFields ghi lại mọi document với tên field mới, giữ nguyên thứ tự field
+func kvRenameFields(store kvStore, reverse bool) error {
+	names := fieldRenames(camelCaseFieldNames, reverse)
+	subdocumentFields := fieldRenames(camelCaseSubdocumentFields, reverse)
+	subdocuments := make(map[string]bool, len(camelCaseSubdocuments))
+	for _, parent := range camelCaseSubdocuments {
+		subdocuments[parent] = true
+		subdocuments[camelCaseFieldNames[parent]] = true
+	}
+
+	return store.update(func(tx kvTx) error {
+		for _, bucket := range collectionNames {
+			docs := map[string]bson.D{}
+			err := tx.forEach(bucket, func(key string, raw []byte) error {
+				var doc bson.D
+				if err := bson.Unmarshal(raw, &doc); err != nil {
+					return err
+				}
+				docs[key] = doc
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for key, doc := range docs {
+				for _, elem := range doc {
+					if sub, ok := elem.Value.(bson.D); ok && subdocuments[elem.Key] {
+						renameKeys(sub, subdocumentFields)
+					}
+				}
+				renameKeys(doc, names)
+				if err := kvPut(tx, bucket, key, doc); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func renameKeys(doc bson.D, names map[string]string) {
+	for i, elem := range doc {
+		if renamed, ok := names[elem.Key]; ok {
+			doc[i].Key = renamed
+		}
+	}
+}
+
+// kvMigrationLog lưu các migration đã chạy trong bucket migrations, key là version
+type kvMigrationLog struct {
+	store kvStore
+}
+
+func (l kvMigrationLog) applied(ctx context.Context) (applied map[int]time.Time, err error) {
+	err = l.store.view(func(tx kvTx) error {
+		records, err := kvFind[migrationRecord](tx, migrationCollectionName, nil)
+		if err != nil {
+			return err
+		}
+		applied = make(map[int]time.Time, len(records))
+		for _, record := range records {
+			applied[record.Version] = record.AppliedAt
+		}
+		return nil
+	})
+	return applied, err
+}
+
+func (l kvMigrationLog) record(ctx context.Context, version int, name string, appliedAt time.Time) error {
+	return l.store.update(func(tx kvTx) error {
+		return kvPut(tx, migrationCollectionName, migrationKey(version), migrationRecord{Version: version, Name: name, AppliedAt: appliedAt})
+	})
+}
+
+func (l kvMigrationLog) remove(ctx context.Context, version int) error {
+	return l.store.update(func(tx kvTx) error {
+		return tx.delete(migrationCollectionName, migrationKey(version))
+	})
+}
+
+func migrationKey(version int) string {
+	return fmt.Sprintf("%06d", version)
+}
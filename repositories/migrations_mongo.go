@@ -0,0 +1,267 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoMigrations là lịch sử schema của MongoDB, chỉ thêm bước mới vào cuối
+var mongoMigrations = []migration[*mongo.Database]{
+	{
+		version: 1,
+		name:    "create collections",
+		up:      ensureCollections,
+	},
+	{
+		version: 2,
+		name:    "hash legacy api keys",
+		up: func(ctx context.Context, db *mongo.Database) error {
+			return (&mongoConnectionRepo{collection: db.Collection(connectionCollectionName)}).hashLegacyApiKeys(ctx)
+		},
+	},
+	indexMigration(3, "unique server names per organization", serverNameIndexes),
+	indexMigration(4, "unique connection pair", connectionPairIndexes),
+	indexMigration(5, "unique admin username, organization name and consent", uniqueNameIndexes),
+	indexMigration(6, "query indexes", queryIndexes),
+	{
+		version: 7,
+		name:    "camelCase field names",
+		up: func(ctx context.Context, db *mongo.Database) error {
+			return renameMongoFields(ctx, db, false)
+		},
+		down: func(ctx context.Context, db *mongo.Database) error {
+			return renameMongoFields(ctx, db, true)
+		},
+	},
+	camelCaseIndexMigration(8, "camelCase indexes", concatIndexes(serverNameIndexes, connectionPairIndexes, uniqueNameIndexes, queryIndexes)),
+	{
+		version: 9,
+		name:    "json schema validation",
+		up: func(ctx context.Context, db *mongo.Database) error {
+			for _, name := range collectionNames {
+				command := bson.D{
+					{Key: "collMod", Value: name},
+					{Key: "validator", Value: bson.M{"$jsonSchema": collectionSchemas[name]}},
+					{Key: "validationLevel", Value: "moderate"},
+				}
+				if err := db.RunCommand(ctx, command).Err(); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		down: func(ctx context.Context, db *mongo.Database) error {
+			for _, name := range collectionNames {
+				command := bson.D{{Key: "collMod", Value: name}, {Key: "validator", Value: bson.M{}}}
+				if err := db.RunCommand(ctx, command).Err(); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	indexMigration(10, "list sort indexes", listSortIndexes),
+}
+
+// Index cho thứ tự mặc định của danh sách server và connection, _id là khoá phụ của cursor
+var listSortIndexes = []indexSpec{
+	{webviewServerCollectionName, "organizationId_createdAt_id", bson.D{{Key: "organizationId", Value: 1}, {Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}, false},
+	{userDeliveryServerCollectionName, "organizationId_createdAt_id", bson.D{{Key: "organizationId", Value: 1}, {Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}, false},
+	{connectionCollectionName, "userDeliveryServerId_createdAt_id", bson.D{{Key: "userDeliveryServerId", Value: 1}, {Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}, false},
+}
+
+// Các index tạo trước khi đổi sang camelCase, giữ nguyên tên field cũ vì migration 3-6
+// chạy trên dữ liệu chưa đổi tên. Migration 8 thay chúng bằng bản camelCase.
+var (
+	serverNameIndexes = []indexSpec{
+		{webviewServerCollectionName, "organizationid_name_unique", bson.D{{Key: "organizationid", Value: 1}, {Key: "name", Value: 1}}, true},
+		{userDeliveryServerCollectionName, "organizationid_name_unique", bson.D{{Key: "organizationid", Value: 1}, {Key: "name", Value: 1}}, true},
+	}
+	connectionPairIndexes = []indexSpec{
+		{connectionCollectionName, "webviewserverid_userdeliveryserverid_unique", bson.D{{Key: "webviewserverid", Value: 1}, {Key: "userdeliveryserverid", Value: 1}}, true},
+	}
+	uniqueNameIndexes = []indexSpec{
+		{adminCollectionName, "username_unique", bson.D{{Key: "username", Value: 1}}, true},
+		{organizationCollectionName, "name_unique", bson.D{{Key: "name", Value: 1}}, true},
+		{connectionConsentCollectionName, "webviewserverid_granteeorganizationid_unique", bson.D{{Key: "webviewserverid", Value: 1}, {Key: "granteeorganizationid", Value: 1}}, true},
+	}
+	queryIndexes = []indexSpec{
+		{jobCollectionName, "status_runat", bson.D{{Key: "status", Value: 1}, {Key: "runat", Value: 1}}, false},
+		{jobCollectionName, "status_leaseexpiresat", bson.D{{Key: "status", Value: 1}, {Key: "leaseexpiresat", Value: 1}}, false},
+		{apiKeyCollectionName, "prefix", bson.D{{Key: "prefix", Value: 1}}, false},
+		{apiKeyCollectionName, "ownertype_ownerid", bson.D{{Key: "ownertype", Value: 1}, {Key: "ownerid", Value: 1}}, false},
+		{connectionCollectionName, "userdeliveryserverid", bson.D{{Key: "userdeliveryserverid", Value: 1}}, false},
+		{connectionCollectionName, "webviewserverapikeyprefix", bson.D{{Key: "webviewserverapikeyprefix", Value: 1}}, false},
+		{connectionCollectionName, "userdeliveryserverapikeyprefix", bson.D{{Key: "userdeliveryserverapikeyprefix", Value: 1}}, false},
+		{connectionCollectionName, "webviewserverapikeyrotation_previouskeyprefix", bson.D{{Key: "webviewserverapikeyrotation.previouskeyprefix", Value: 1}}, false},
+		{connectionCollectionName, "userdeliveryserverapikeyrotation_previouskeyprefix", bson.D{{Key: "userdeliveryserverapikeyrotation.previouskeyprefix", Value: 1}}, false},
+		{auditEventCollectionName, "organizationid_createdat", bson.D{{Key: "organizationid", Value: 1}, {Key: "createdat", Value: -1}}, false},
+	}
+)
+
+func concatIndexes(groups ...[]indexSpec) []indexSpec {
+	var indexes []indexSpec
+	for _, group := range groups {
+		indexes = append(indexes, group...)
+	}
+	return indexes
+}
+
+type indexSpec struct {
+	collection string
+	name       string
+	keys       bson.D
+	unique     bool
+}
+
+// indexMigration tạo các index khi up và xoá chúng khi down
+func indexMigration(version int, name string, indexes []indexSpec) migration[*mongo.Database] {
+	return migration[*mongo.Database]{
+		version: version,
+		name:    name,
+		up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db, indexes)
+		},
+		down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexes(ctx, db, indexes)
+		},
+	}
+}
+
+// camelCaseIndexMigration thay các index trên field cũ bằng index cùng cấu trúc trên field camelCase
+func camelCaseIndexMigration(version int, name string, indexes []indexSpec) migration[*mongo.Database] {
+	renamed := make([]indexSpec, len(indexes))
+	for i, index := range indexes {
+		keys := make(bson.D, len(index.keys))
+		for j, key := range index.keys {
+			keys[j] = bson.E{Key: camelCaseName(key.Key, "."), Value: key.Value}
+		}
+		renamed[i] = indexSpec{index.collection, camelCaseName(index.name, "_"), keys, index.unique}
+	}
+
+	return migration[*mongo.Database]{
+		version: version,
+		name:    name,
+		up: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropIndexes(ctx, db, indexes); err != nil {
+				return err
+			}
+			return createIndexes(ctx, db, renamed)
+		},
+		down: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropIndexes(ctx, db, renamed); err != nil {
+				return err
+			}
+			return createIndexes(ctx, db, indexes)
+		},
+	}
+}
+
+// renameMongoFields đổi tên field của mọi document bằng $rename. Subdocument được xử lý khi
+// field cha còn mang tên cũ: trước khi đổi tên field cấp trên khi up, sau đó khi down.
+func renameMongoFields(ctx context.Context, db *mongo.Database, reverse bool) error {
+	for _, name := range collectionNames {
+		collection := db.Collection(name)
+		if !reverse {
+			if err := renameMongoSubdocumentFields(ctx, collection, false); err != nil {
+				return err
+			}
+		}
+		if _, err := collection.UpdateMany(ctx, bson.M{}, bson.M{"$rename": fieldRenames(camelCaseFieldNames, reverse)}); err != nil {
+			return err
+		}
+		if reverse {
+			if err := renameMongoSubdocumentFields(ctx, collection, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func renameMongoSubdocumentFields(ctx context.Context, collection *mongo.Collection, reverse bool) error {
+	for _, parent := range camelCaseSubdocuments {
+		rename := bson.M{}
+		for from, to := range fieldRenames(camelCaseSubdocumentFields, reverse) {
+			rename[parent+"."+from] = parent + "." + to
+		}
+		// $rename lỗi nếu field cha là null nên chỉ cập nhật document có subdocument
+		_, err := collection.UpdateMany(ctx, bson.M{parent: bson.M{"$type": "object"}}, bson.M{"$rename": rename})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createIndexes tạo index, index unique sẽ thất bại nếu dữ liệu hiện có đang bị trùng
+func createIndexes(ctx context.Context, db *mongo.Database, indexes []indexSpec) error {
+	for _, index := range indexes {
+		model := mongo.IndexModel{Keys: index.keys, Options: options.Index().SetName(index.name).SetUnique(index.unique)}
+		if _, err := db.Collection(index.collection).Indexes().CreateOne(ctx, model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dropIndexes(ctx context.Context, db *mongo.Database, indexes []indexSpec) error {
+	for _, index := range indexes {
+		_, err := db.Collection(index.collection).Indexes().DropOne(ctx, index.name)
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Code == indexNotFoundCode {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Mã lỗi của MongoDB khi index không tồn tại
+const indexNotFoundCode = 27
+
+// mongoMigrationLog lưu mỗi migration đã chạy là một document với _id là version
+type mongoMigrationLog struct {
+	collection *mongo.Collection
+}
+
+type migrationRecord struct {
+	Version   int       `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"appliedAt"`
+}
+
+func (l mongoMigrationLog) applied(ctx context.Context) (map[int]time.Time, error) {
+	cursor, err := l.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	var records []migrationRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]time.Time, len(records))
+	for _, record := range records {
+		applied[record.Version] = record.AppliedAt
+	}
+	return applied, nil
+}
+
+func (l mongoMigrationLog) record(ctx context.Context, version int, name string, appliedAt time.Time) error {
+	_, err := l.collection.InsertOne(ctx, migrationRecord{Version: version, Name: name, AppliedAt: appliedAt})
+	return err
+}
+
+func (l mongoMigrationLog) remove(ctx context.Context, version int) error {
+	_, err := l.collection.DeleteOne(ctx, bson.M{"_id": version})
+	return err
+}
@@ -0,0 +1,294 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"draft-notification/models"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestBoltCheckMigrated(t *testing.T) {
+	ctx := context.Background()
+	repos, err := NewBolt(filepath.Join(t.TempDir(), "migrations.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repos.Close(ctx)
+
+	// File mới chưa có index unique nên các role phục vụ request không được khởi động
+	if err := repos.CheckMigrated(ctx); err == nil {
+		t.Fatal("CheckMigrated on a new file = nil, want pending migrations")
+	}
+	if err := repos.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if err := repos.CheckMigrated(ctx); err != nil {
+		t.Fatalf("CheckMigrated after Migrate = %v", err)
+	}
+}
+
+// legacyConnection là connection được lưu trước khi model có bson tag, tên field lowercase
+func legacyConnection(id primitive.ObjectID) bson.D {
+	now := primitive.NewDateTimeFromTime(time.Now().UTC().Truncate(time.Millisecond))
+	return bson.D{
+		{Key: "_id", Value: id},
+		{Key: "organizationid", Value: primitive.NewObjectID()},
+		{Key: "status", Value: "active"},
+		{Key: "createdat", Value: now},
+		{Key: "webviewserverid", Value: primitive.NewObjectID()},
+		{Key: "userdeliveryserverid", Value: primitive.NewObjectID()},
+		{Key: "webviewserverapikeyprefix", Value: "abcd1234"},
+		{Key: "webviewserverapikeyrotation", Value: bson.D{
+			{Key: "previouskeyprefix", Value: "efgh5678"},
+			{Key: "previouskeyexpiresat", Value: now},
+		}},
+		{Key: "userdeliveryserverapikeyrotation", Value: nil},
+	}
+}
+
+func TestCamelCaseFieldNames(t *testing.T) {
+	for _, names := range []map[string]string{camelCaseFieldNames, camelCaseSubdocumentFields} {
+		reversed := fieldRenames(names, true)
+		if len(reversed) != len(names) {
+			t.Fatalf("renames are not reversible, %d names map to %d", len(names), len(reversed))
+		}
+		for from, to := range names {
+			if reversed[to] != from {
+				t.Errorf("reverse of %s = %s, want %s", to, reversed[to], from)
+			}
+		}
+	}
+}
+
+func TestCamelCaseName(t *testing.T) {
+	tests := []struct {
+		name string
+		sep  string
+		want string
+	}{
+		{"organizationid_name_unique", "_", "organizationId_name_unique"},
+		{"webviewserverid_userdeliveryserverid_unique", "_", "webviewServerId_userDeliveryServerId_unique"},
+		{"webviewserverapikeyrotation.previouskeyprefix", ".", "webviewServerApiKeyRotation.previousKeyPrefix"},
+		{"status", ".", "status"},
+	}
+	for _, tt := range tests {
+		if got := camelCaseName(tt.name, tt.sep); got != tt.want {
+			t.Errorf("camelCaseName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// Migration 3 của bolt là migration 7 của MongoDB cho kv store
+func TestKVRenameFields(t *testing.T) {
+	store := newMemoryStore()
+	id := primitive.NewObjectID()
+	legacy, err := bson.Marshal(legacyConnection(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.update(func(tx kvTx) error {
+		return tx.put(connectionCollectionName, id.Hex(), legacy)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := kvRenameFields(store, false); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	var connection models.Connection
+	err = store.view(func(tx kvTx) error {
+		connection, err = kvGet[models.Connection](tx, connectionCollectionName, id.Hex())
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if connection.WebviewServerId.IsZero() || connection.UserDeliveryServerId.IsZero() || connection.OrganizationId.IsZero() ||
+		connection.WebviewServerApiKeyPrefix != "abcd1234" || connection.CreatedAt.IsZero() {
+		t.Fatalf("renamed connection = %+v", connection)
+	}
+	if rotation := connection.WebviewServerApiKeyRotation; rotation == nil || rotation.PreviousKeyPrefix != "efgh5678" || rotation.PreviousKeyExpiresAt.IsZero() {
+		t.Fatalf("renamed rotation = %+v", rotation)
+	}
+
+	if err := kvRenameFields(store, true); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	var reverted []byte
+	store.view(func(tx kvTx) error {
+		reverted = tx.get(connectionCollectionName, id.Hex())
+		return nil
+	})
+	if !bytes.Equal(reverted, legacy) {
+		t.Fatalf("down = %s, want %s", bson.Raw(reverted), bson.Raw(legacy))
+	}
+}
+
+func TestMongoCamelCaseMigrations(t *testing.T) {
+	ctx := context.Background()
+	repos, db := openTestMongoDatabase(t)
+	collection := db.Collection(connectionCollectionName)
+
+	indexNames := func() map[string]bool {
+		t.Helper()
+		specs, err := collection.Indexes().ListSpecifications(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := map[string]bool{}
+		for _, spec := range specs {
+			names[spec.Name] = true
+		}
+		return names
+	}
+	raw := func(id primitive.ObjectID) bson.Raw {
+		t.Helper()
+		doc, err := collection.FindOne(ctx, bson.M{"_id": id}).Raw()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return doc
+	}
+
+	// Quay về trước migration 7, ghi document kiểu cũ rồi chạy lại migration 7 và 8
+	if err := repos.MigrateDown(ctx, 6); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if names := indexNames(); !names["webviewserverid_userdeliveryserverid_unique"] || names["webviewServerId_userDeliveryServerId_unique"] {
+		t.Fatalf("indexes after down = %v", names)
+	}
+
+	id := primitive.NewObjectID()
+	legacy := legacyConnection(id)
+	if _, err := collection.InsertOne(ctx, legacy); err != nil {
+		t.Fatal(err)
+	}
+	legacyRaw := raw(id)
+
+	if err := repos.Migrate(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if names := indexNames(); names["webviewserverid_userdeliveryserverid_unique"] || !names["webviewServerId_userDeliveryServerId_unique"] {
+		t.Fatalf("indexes after up = %v", names)
+	}
+	connection, err := repos.Connections.FindById(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if connection.WebviewServerId.IsZero() || connection.WebviewServerApiKeyPrefix != "abcd1234" ||
+		connection.WebviewServerApiKeyRotation == nil || connection.WebviewServerApiKeyRotation.PreviousKeyPrefix != "efgh5678" {
+		t.Fatalf("renamed connection = %+v", connection)
+	}
+
+	if err := repos.MigrateDown(ctx, 6); err != nil {
+		t.Fatalf("second down: %v", err)
+	}
+	for _, elem := range legacy {
+		if _, err := raw(id).LookupErr(elem.Key); err != nil {
+			t.Errorf("field %s missing after down: %s (was %s)", elem.Key, raw(id), legacyRaw)
+		}
+	}
+	if _, err := raw(id).LookupErr("webviewserverapikeyrotation", "previouskeyprefix"); err != nil {
+		t.Errorf("subdocument field not reverted: %s", raw(id))
+	}
+}
+
+func TestCollectionSchemasRequiredFields(t *testing.T) {
+	for _, name := range collectionNames {
+		schema, ok := collectionSchemas[name]
+		if !ok {
+			t.Errorf("collection %s has no schema", name)
+			continue
+		}
+		properties := schema["properties"].(bson.M)
+		for _, field := range schema["required"].([]string) {
+			if _, ok := properties[field]; !ok {
+				t.Errorf("%s requires %s but does not declare it", name, field)
+			}
+		}
+		// organizationId phải bắt buộc ở mọi collection có field này
+		if _, ok := properties["organizationId"]; ok && !containsString(schema["required"].([]string), "organizationId") {
+			t.Errorf("%s declares organizationId but does not require it", name)
+		}
+	}
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// documentValidationFailure là mã lỗi MongoDB khi document không khớp validator
+const documentValidationFailure = 121
+
+func TestMongoSchemaValidation(t *testing.T) {
+	ctx := context.Background()
+	_, db := openTestMongoDatabase(t)
+	now := time.Now().UTC()
+
+	admin := func(skip string) bson.M {
+		doc := bson.M{
+			"organizationId": primitive.NewObjectID(),
+			"username":       "admin-" + primitive.NewObjectID().Hex(),
+			"role":           "admin",
+			"passwordHash":   "hash",
+			"createdAt":      now,
+		}
+		delete(doc, skip)
+		return doc
+	}
+	connection := func(skip string) bson.M {
+		doc := bson.M{
+			"organizationId":       primitive.NewObjectID(),
+			"status":               "active",
+			"webviewServerId":      primitive.NewObjectID(),
+			"userDeliveryServerId": primitive.NewObjectID(),
+			"createdAt":            now,
+		}
+		delete(doc, skip)
+		return doc
+	}
+
+	tests := []struct {
+		name       string
+		collection string
+		doc        bson.M
+		rejected   bool
+	}{
+		{"valid admin", adminCollectionName, admin(""), false},
+		{"admin without organizationId", adminCollectionName, admin("organizationId"), true},
+		{"admin without passwordHash", adminCollectionName, admin("passwordHash"), true},
+		{"valid connection", connectionCollectionName, connection(""), false},
+		{"connection without organizationId", connectionCollectionName, connection("organizationId"), true},
+		{"connection without status", connectionCollectionName, connection("status"), true},
+		{"connection with unknown status", connectionCollectionName, func() bson.M { doc := connection(""); doc["status"] = "paused"; return doc }(), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.Collection(tt.collection).InsertOne(ctx, tt.doc)
+			if !tt.rejected {
+				if err != nil {
+					t.Fatalf("InsertOne = %v, want success", err)
+				}
+				return
+			}
+			var writeErr mongo.WriteException
+			if !errors.As(err, &writeErr) || !writeErr.HasErrorCode(documentValidationFailure) {
+				t.Fatalf("InsertOne = %v, want document validation failure", err)
+			}
+		})
+	}
+}
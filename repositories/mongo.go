@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/configs"
+	"errors"
+	"log/slog"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+const (
+	webviewServerCollectionName      = "webview-server"
+	userDeliveryServerCollectionName = "user-delivery-server"
+	connectionCollectionName         = "connection"
+	jobCollectionName                = "jobs"
+	adminCollectionName              = "admin"
+	adminSessionCollectionName       = "admin-session"
+	organizationCollectionName       = "organization"
+	connectionConsentCollectionName  = "connection-consent"
+	apiKeyCollectionName             = "api-key"
+	auditEventCollectionName         = "audit-event"
+)
+
+var collectionNames = []string{
+	webviewServerCollectionName,
+	userDeliveryServerCollectionName,
+	connectionCollectionName,
+	jobCollectionName,
+	adminCollectionName,
+	adminSessionCollectionName,
+	organizationCollectionName,
+	connectionConsentCollectionName,
+	apiKeyCollectionName,
+	auditEventCollectionName,
+}
+
+// tenantCollectionNames là các collection mà mỗi document thuộc về một organization
+var tenantCollectionNames = []string{
+	webviewServerCollectionName,
+	userDeliveryServerCollectionName,
+	connectionCollectionName,
+	adminCollectionName,
+}
+
+// NewMongo tạo các repository dùng MongoDB
+func NewMongo(db *mongo.Database) Repositories {
+	connections := &mongoConnectionRepo{collection: configs.GetCollection(db, connectionCollectionName)}
+	migrations := mongoMigrationLog{collection: configs.GetCollection(db, migrationCollectionName)}
+
+	return Repositories{
+		WebviewServers:      &mongoWebviewServerRepo{collection: configs.GetCollection(db, webviewServerCollectionName)},
+		UserDeliveryServers: &mongoUserDeliveryServerRepo{collection: configs.GetCollection(db, userDeliveryServerCollectionName)},
+		Connections:         connections,
+		Jobs:                &mongoJobRepo{collection: configs.GetCollection(db, jobCollectionName)},
+		Admins:              &mongoAdminRepo{collection: configs.GetCollection(db, adminCollectionName)},
+		AdminSessions:       &mongoAdminSessionRepo{collection: configs.GetCollection(db, adminSessionCollectionName)},
+		Organizations:       &mongoOrganizationRepo{collection: configs.GetCollection(db, organizationCollectionName)},
+		ConnectionConsents:  &mongoConnectionConsentRepo{collection: configs.GetCollection(db, connectionConsentCollectionName)},
+		ApiKeys:             &mongoApiKeyRepo{collection: configs.GetCollection(db, apiKeyCollectionName)},
+		AuditEvents:         &mongoAuditEventRepo{collection: configs.GetCollection(db, auditEventCollectionName)},
+		migrate: func(ctx context.Context) error {
+			return migrateUp(ctx, db, mongoMigrations, migrations)
+		},
+		migrateDown: func(ctx context.Context, to int) error {
+			return migrateDown(ctx, db, mongoMigrations, migrations, to)
+		},
+		migrations: func(ctx context.Context) ([]MigrationStatus, error) {
+			return migrationStatus(ctx, mongoMigrations, migrations)
+		},
+		checkMigrated: func(ctx context.Context) error {
+			return checkMigrations(ctx, mongoMigrations, migrations)
+		},
+		ping: func(ctx context.Context) error {
+			return db.Client().Ping(ctx, readpref.Primary())
+		},
+		assignOrganization: func(ctx context.Context, organizationId primitive.ObjectID) (int64, error) {
+			return assignOrganization(ctx, db, organizationId)
+		},
+		reEncrypt: func(ctx context.Context) (int64, error) {
+			return reEncrypt(ctx, db)
+		},
+		close: func(ctx context.Context) error {
+			return db.Client().Disconnect(ctx)
+		},
+	}
+}
+
+// ensureCollections tạo các collection còn thiếu trong database
+func ensureCollections(ctx context.Context, db *mongo.Database) error {
+	existing, err := db.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+
+	exists := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		exists[name] = true
+	}
+
+	for _, name := range collectionNames {
+		if exists[name] {
+			continue
+		}
+		if err := db.CreateCollection(ctx, name); err != nil {
+			return err
+		}
+		slog.Info("Created collection", "collection", name)
+	}
+
+	return nil
+}
+
+// assignOrganization gán organizationId cho các document chưa có organizationId
+func assignOrganization(ctx context.Context, db *mongo.Database, organizationId primitive.ObjectID) (int64, error) {
+	var total int64
+	for _, name := range tenantCollectionNames {
+		result, err := configs.GetCollection(db, name).UpdateMany(ctx,
+			bson.M{"organizationId": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"organizationId": organizationId}})
+		if err != nil {
+			return total, err
+		}
+		total += result.ModifiedCount
+	}
+	return total, nil
+}
+
+func pageOptions(limit, page int) *options.FindOptions {
+	return options.Find().SetLimit(int64(limit)).SetSkip(int64(page * limit))
+}
+
+// serverFilter tạo filter cho danh sách server theo keyword, status và khoảng createdAt
+func serverFilter(filter ListFilter) bson.M {
+	query := bson.M{"organizationId": filter.OrganizationId}
+	if filter.Keyword != "" {
+		query["name"] = bson.M{"$regex": filter.Keyword, "$options": "i"}
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	createdRange(query, filter.CreatedFrom, filter.CreatedTo)
+	return query
+}
+
+// findOne decode một document, chuyển mongo.ErrNoDocuments thành ErrNotFound
+func findOne(ctx context.Context, collection *mongo.Collection, filter interface{}, out interface{}) error {
+	err := collection.FindOne(ctx, filter).Decode(out)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// insertOne thêm document, vi phạm unique index (kể cả do request đồng thời) trả về ErrDuplicate
+func insertOne(ctx context.Context, collection *mongo.Collection, doc interface{}) error {
+	_, err := collection.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// findSortedList trả về một trang document theo sort và cursor cùng tổng số document khớp filter,
+// total không tính điều kiện cursor
+func findSortedList[T any](ctx context.Context, collection *mongo.Collection, filter bson.M, sort Sort, after *Cursor, limit, page int) ([]T, int64, error) {
+	results, err := collection.Find(ctx, afterCursor(filter, sort, after), sortedPageOptions(sort, after, limit, page))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer results.Close(ctx)
+
+	list := []T{}
+	if err := results.All(ctx, &list); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return list, total, nil
+}
+
+// updateAndFind cập nhật các field của một document rồi đọc lại document đó
+func updateAndFind(ctx context.Context, collection *mongo.Collection, filter bson.M, set bson.M, out interface{}) error {
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return findOne(ctx, collection, filter, out)
+}
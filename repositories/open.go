@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"draft-notification/configs"
+	"draft-notification/metrics"
+	"fmt"
+)
+
+// Open tạo các repository theo storage driver trong config
+func Open(cfg configs.Config) (Repositories, error) {
+	switch cfg.Storage.Driver {
+	case configs.StorageMongo:
+		client, err := configs.ConnectDB(cfg.Mongo, metrics.MongoPoolMonitor())
+		if err != nil {
+			return Repositories{}, err
+		}
+		return NewMongo(configs.GetDatabase(client, cfg.Mongo)), nil
+	case configs.StorageBolt:
+		return NewBolt(cfg.Storage.BoltPath)
+	case configs.StorageMemory:
+		return NewMemory(), nil
+	default:
+		return Repositories{}, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type kvOrganizationRepo struct {
+	store kvStore
+}
+
+func (r *kvOrganizationRepo) Create(ctx context.Context, organization models.Organization) error {
+	return r.store.update(func(tx kvTx) error {
+		found, err := kvFind(tx, organizationCollectionName, func(existing models.Organization) bool {
+			return existing.Name == organization.Name
+		})
+		if err != nil {
+			return err
+		}
+		if len(found) > 0 {
+			return ErrDuplicate
+		}
+		return kvPut(tx, organizationCollectionName, organization.Id.Hex(), organization)
+	})
+}
+
+func (r *kvOrganizationRepo) FindById(ctx context.Context, id primitive.ObjectID) (organization models.Organization, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		organization, err = kvGet[models.Organization](tx, organizationCollectionName, id.Hex())
+		return err
+	})
+	return organization, err
+}
+
+func (r *kvOrganizationRepo) FindByName(ctx context.Context, name string) (organization models.Organization, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		found, err := kvFind(tx, organizationCollectionName, func(existing models.Organization) bool {
+			return existing.Name == name
+		})
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			return ErrNotFound
+		}
+		organization = found[0]
+		return nil
+	})
+	return organization, err
+}
+
+type kvConnectionConsentRepo struct {
+	store kvStore
+}
+
+func (r *kvConnectionConsentRepo) Create(ctx context.Context, consent models.ConnectionConsent) error {
+	return r.store.update(func(tx kvTx) error {
+		if exists, err := r.exists(tx, consent.WebviewServerId, consent.GranteeOrganizationId); err != nil {
+			return err
+		} else if exists {
+			return ErrDuplicate
+		}
+		return kvPut(tx, connectionConsentCollectionName, consent.Id.Hex(), consent)
+	})
+}
+
+func (r *kvConnectionConsentRepo) FindById(ctx context.Context, id primitive.ObjectID) (consent models.ConnectionConsent, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		consent, err = kvGet[models.ConnectionConsent](tx, connectionConsentCollectionName, id.Hex())
+		return err
+	})
+	return consent, err
+}
+
+func (r *kvConnectionConsentRepo) Exists(ctx context.Context, webviewServerId, granteeOrganizationId primitive.ObjectID) (exists bool, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		exists, err = r.exists(tx, webviewServerId, granteeOrganizationId)
+		return err
+	})
+	return exists, err
+}
+
+func (r *kvConnectionConsentRepo) exists(tx kvTx, webviewServerId, granteeOrganizationId primitive.ObjectID) (bool, error) {
+	found, err := kvFind(tx, connectionConsentCollectionName, func(consent models.ConnectionConsent) bool {
+		return consent.WebviewServerId == webviewServerId && consent.GranteeOrganizationId == granteeOrganizationId
+	})
+	return len(found) > 0, err
+}
+
+func (r *kvConnectionConsentRepo) ListByWebviewServer(ctx context.Context, webviewServerId primitive.ObjectID) (list []models.ConnectionConsent, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		list, err = kvFind(tx, connectionConsentCollectionName, func(consent models.ConnectionConsent) bool {
+			return consent.WebviewServerId == webviewServerId
+		})
+		return err
+	})
+	return list, err
+}
+
+func (r *kvConnectionConsentRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.store.update(func(tx kvTx) error {
+		if tx.get(connectionConsentCollectionName, id.Hex()) == nil {
+			return ErrNotFound
+		}
+		return tx.delete(connectionConsentCollectionName, id.Hex())
+	})
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoOrganizationRepo struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoOrganizationRepo) Create(ctx context.Context, organization models.Organization) error {
+	return insertOne(ctx, r.collection, organization)
+}
+
+func (r *mongoOrganizationRepo) FindById(ctx context.Context, id primitive.ObjectID) (models.Organization, error) {
+	var organization models.Organization
+	err := findOne(ctx, r.collection, bson.M{"_id": id}, &organization)
+	return organization, err
+}
+
+func (r *mongoOrganizationRepo) FindByName(ctx context.Context, name string) (models.Organization, error) {
+	var organization models.Organization
+	err := findOne(ctx, r.collection, bson.M{"name": name}, &organization)
+	return organization, err
+}
+
+type mongoConnectionConsentRepo struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoConnectionConsentRepo) Create(ctx context.Context, consent models.ConnectionConsent) error {
+	return insertOne(ctx, r.collection, consent)
+}
+
+func (r *mongoConnectionConsentRepo) FindById(ctx context.Context, id primitive.ObjectID) (models.ConnectionConsent, error) {
+	var consent models.ConnectionConsent
+	err := findOne(ctx, r.collection, bson.M{"_id": id}, &consent)
+	return consent, err
+}
+
+func (r *mongoConnectionConsentRepo) Exists(ctx context.Context, webviewServerId, granteeOrganizationId primitive.ObjectID) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"webviewServerId": webviewServerId,
+		"granteeOrganizationId": granteeOrganizationId})
+	return count > 0, err
+}
+
+func (r *mongoConnectionConsentRepo) ListByWebviewServer(ctx context.Context, webviewServerId primitive.ObjectID) ([]models.ConnectionConsent, error) {
+	results, err := r.collection.Find(ctx, bson.M{"webviewServerId": webviewServerId})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close(ctx)
+
+	list := []models.ConnectionConsent{}
+	err = results.All(ctx, &list)
+	return list, err
+}
+
+func (r *mongoConnectionConsentRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
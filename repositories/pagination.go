@@ -0,0 +1,185 @@
+package repositories
+
+import (
+	"draft-notification/models"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Các field có thể dùng để sort danh sách server và connection, trùng tên field trong storage
+const (
+	SortName      = "name"
+	SortStatus    = "status"
+	SortCreatedAt = "createdAt"
+	SortUpdatedAt = "updatedAt"
+)
+
+// Sort là thứ tự của danh sách, _id luôn là khoá phụ để thứ tự ổn định giữa các trang
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// DefaultSort giữ thứ tự tạo như trước khi có sort
+var DefaultSort = Sort{Field: SortCreatedAt}
+
+// Cursor là vị trí của phần tử cuối trang trước, trang sau bắt đầu ngay sau nó theo Sort.
+// Value là string với name/status, time.Time với createdAt/updatedAt.
+type Cursor struct {
+	Value interface{}
+	Id    primitive.ObjectID
+}
+
+// SortFields là giá trị các field có thể sort của một document, dùng để tạo cursor và sort trên KV
+type SortFields struct {
+	Id        primitive.ObjectID
+	Name      string
+	Status    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (f SortFields) value(field string) interface{} {
+	switch field {
+	case SortName:
+		return f.Name
+	case SortStatus:
+		return f.Status
+	case SortUpdatedAt:
+		return f.UpdatedAt
+	}
+	return f.CreatedAt
+}
+
+// Cursor trả về cursor trỏ tới document theo field đang sort
+func (f SortFields) Cursor(sort Sort) Cursor {
+	return Cursor{Value: f.value(sort.Field), Id: f.Id}
+}
+
+// WebviewServerSortFields, UserDeliveryServerSortFields và ConnectionSortFields lấy SortFields của từng loại document
+func WebviewServerSortFields(server models.WebviewServer) SortFields {
+	return SortFields{Id: server.Id, Name: server.Name, Status: server.Status, CreatedAt: server.CreatedAt, UpdatedAt: server.UpdatedAt}
+}
+
+func UserDeliveryServerSortFields(server models.UserDeliveryServer) SortFields {
+	return SortFields{Id: server.Id, Name: server.Name, Status: server.Status, CreatedAt: server.CreatedAt, UpdatedAt: server.UpdatedAt}
+}
+
+func ConnectionSortFields(connection models.Connection) SortFields {
+	return SortFields{Id: connection.Id, Status: connection.Status, CreatedAt: connection.CreatedAt, UpdatedAt: connection.UpdatedAt}
+}
+
+// compareSort so sánh document với cursor theo sort, kết quả dương nghĩa là document nằm sau cursor
+func compareSort(f SortFields, cursor Cursor, sort Sort) int {
+	result := compareValues(f.value(sort.Field), cursor.Value)
+	if result == 0 {
+		result = strings.Compare(f.Id.Hex(), cursor.Id.Hex())
+	}
+	if sort.Desc {
+		return -result
+	}
+	return result
+}
+
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		b, _ := b.(string)
+		return strings.Compare(a, b)
+	case time.Time:
+		b, _ := b.(time.Time)
+		return a.Compare(b)
+	}
+	return 0
+}
+
+func sortOrDefault(sort Sort) Sort {
+	if sort.Field == "" {
+		return DefaultSort
+	}
+	return sort
+}
+
+// createdRange thêm điều kiện createdAt trong [from, to) vào query, giá trị zero là không giới hạn
+func createdRange(query bson.M, from, to time.Time) {
+	createdAt := bson.M{}
+	if !from.IsZero() {
+		createdAt["$gte"] = from
+	}
+	if !to.IsZero() {
+		createdAt["$lt"] = to
+	}
+	if len(createdAt) > 0 {
+		query["createdAt"] = createdAt
+	}
+}
+
+// inCreatedRange là createdRange cho KV
+func inCreatedRange(createdAt, from, to time.Time) bool {
+	return (from.IsZero() || !createdAt.Before(from)) && (to.IsZero() || createdAt.Before(to))
+}
+
+// sortDocument sort theo sort với _id làm khoá phụ
+func sortDocument(sort Sort) bson.D {
+	sort = sortOrDefault(sort)
+	direction := 1
+	if sort.Desc {
+		direction = -1
+	}
+	return bson.D{{Key: sort.Field, Value: direction}, {Key: "_id", Value: direction}}
+}
+
+// sortedPageOptions sort theo sort và _id, bỏ skip khi phân trang bằng cursor
+func sortedPageOptions(sort Sort, after *Cursor, limit, page int) *options.FindOptions {
+	opts := options.Find().SetSort(sortDocument(sort)).SetLimit(int64(limit))
+	if after == nil {
+		opts.SetSkip(int64(page * limit))
+	}
+	return opts
+}
+
+// afterCursor trả về query chỉ lấy các document nằm sau cursor theo sort
+func afterCursor(query bson.M, sort Sort, after *Cursor) bson.M {
+	if after == nil {
+		return query
+	}
+	return bson.M{"$and": bson.A{query, cursorCondition(sort, *after)}}
+}
+
+// cursorCondition là điều kiện "nằm sau cursor" theo sort
+func cursorCondition(sort Sort, after Cursor) bson.M {
+	sort = sortOrDefault(sort)
+	operator := "$gt"
+	if sort.Desc {
+		operator = "$lt"
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{sort.Field: bson.M{operator: after.Value}},
+		bson.M{sort.Field: after.Value, "_id": bson.M{operator: after.Id}},
+	}}
+}
+
+// kvSortedPage sort danh sách giống sortedPageOptions rồi cắt trang theo cursor hoặc limit/page
+func kvSortedPage[T any](list []T, fields func(T) SortFields, sort Sort, after *Cursor, limit, page int) []T {
+	sort = sortOrDefault(sort)
+	slices.SortFunc(list, func(a, b T) int {
+		return compareSort(fields(a), fields(b).Cursor(sort), sort)
+	})
+	if after == nil {
+		return kvPage(list, limit, page)
+	}
+
+	start := len(list)
+	for i, item := range list {
+		if compareSort(fields(item), *after, sort) > 0 {
+			start = i
+			break
+		}
+	}
+	return kvPage(list[start:], limit, 0)
+}
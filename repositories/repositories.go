@@ -0,0 +1,281 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/apperrors"
+	"draft-notification/encryption"
+	"draft-notification/models"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Lỗi chung của storage, controller thay bằng lỗi có code riêng của từng entity khi cần
+var (
+	ErrNotFound  = apperrors.NotFound("not_found")
+	ErrDuplicate = apperrors.Conflict("duplicate")
+)
+
+// ErrLeaseLost là lỗi khi cập nhật job mà lease của worker đã hết hạn và job đã được lease lại
+var ErrLeaseLost = errors.New("job lease lost")
+
+// ListFilter dùng cho danh sách webview server và user delivery server. CreatedFrom/CreatedTo là
+// khoảng CreatedAt (CreatedTo không bao gồm); khi After khác nil danh sách bắt đầu sau cursor và Page bị bỏ qua
+type ListFilter struct {
+	OrganizationId primitive.ObjectID
+	Keyword        string
+	Status         string
+	CreatedFrom    time.Time
+	CreatedTo      time.Time
+	Sort           Sort
+	After          *Cursor
+	Limit          int
+	Page           int
+}
+
+// ConnectionFilter giống ListFilter, connection không có name nên không sort theo name
+type ConnectionFilter struct {
+	UserDeliveryServerId primitive.ObjectID
+	WebviewServerId      primitive.ObjectID
+	Status               string
+	CreatedFrom          time.Time
+	CreatedTo            time.Time
+	Sort                 Sort
+	After                *Cursor
+	Limit                int
+	Page                 int
+}
+
+// AuditEventFilter lọc audit event, From/To là khoảng CreatedAt (To không bao gồm)
+type AuditEventFilter struct {
+	OrganizationId primitive.ObjectID
+	ActorId        primitive.ObjectID
+	EntityType     string
+	EntityId       primitive.ObjectID
+	Action         string
+	RequestId      string
+	From           time.Time
+	To             time.Time
+	Limit          int
+	Page           int
+}
+
+type AdminFilter struct {
+	OrganizationId primitive.ObjectID
+	Keyword        string
+	Role           string
+	Limit          int
+	Page           int
+}
+
+// WebviewServerRepo lưu các webview server, tên là duy nhất trong một organization
+type WebviewServerRepo interface {
+	Create(ctx context.Context, server models.WebviewServer) error
+	FindById(ctx context.Context, id primitive.ObjectID) (models.WebviewServer, error)
+	List(ctx context.Context, filter ListFilter) ([]models.WebviewServer, int64, error)
+	UpdateName(ctx context.Context, id primitive.ObjectID, name string) (models.WebviewServer, error)
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) (models.WebviewServer, error)
+}
+
+// UserDeliveryServerRepo lưu các user delivery server, tên là duy nhất trong một organization
+type UserDeliveryServerRepo interface {
+	Create(ctx context.Context, server models.UserDeliveryServer) error
+	FindById(ctx context.Context, id primitive.ObjectID) (models.UserDeliveryServer, error)
+	List(ctx context.Context, filter ListFilter) ([]models.UserDeliveryServer, int64, error)
+	UpdateName(ctx context.Context, id primitive.ObjectID, name string) (models.UserDeliveryServer, error)
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) (models.UserDeliveryServer, error)
+}
+
+// ConnectionRepo lưu các connection, mỗi cặp webview server / user delivery server là duy nhất
+type ConnectionRepo interface {
+	Create(ctx context.Context, connection models.Connection) error
+	FindById(ctx context.Context, id primitive.ObjectID) (models.Connection, error)
+	List(ctx context.Context, filter ConnectionFilter) ([]models.Connection, int64, error)
+	// ListWithServers giống List nhưng kèm tên webview server và user delivery server của từng connection
+	ListWithServers(ctx context.Context, filter ConnectionFilter) ([]models.ConnectionWithServers, int64, error)
+	// UpdateWebhookUrl đổi webhook URL, verifiedAt khác 0 nghĩa là URL mới đã qua bước xác minh
+	UpdateWebhookUrl(ctx context.Context, id primitive.ObjectID, webhookUrl string, verifiedAt time.Time) (models.Connection, error)
+	UpdateWebhookSecret(ctx context.Context, id primitive.ObjectID, secret string) (models.Connection, error)
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) (models.Connection, error)
+	// RotateApiKey thay prefix/hash API key của một phía (models.ApiKeySide...) và lưu thông tin rotation.
+	// Chỉ thay khi hash đang lưu vẫn là rotation.PreviousKeyHash, trả về ErrNotFound nếu không khớp
+	// để hai lần rotate đồng thời không làm mất key vừa cấp của nhau
+	RotateApiKey(ctx context.Context, id primitive.ObjectID, side string, prefix, hash string, rotation models.ApiKeyRotation) (models.Connection, error)
+	// FindByApiKeyPrefix trả về các connection có key hiện tại hoặc key cũ còn hạn tại now mang prefix,
+	// người gọi phải so sánh hash để xác định key nào khớp
+	FindByApiKeyPrefix(ctx context.Context, prefix string, now time.Time) ([]models.Connection, error)
+	// DeactivateBy... chuyển các connection đang active sang inactive và trả về trạng thái trước đó của chúng
+	DeactivateByWebviewServer(ctx context.Context, webviewServerId primitive.ObjectID) ([]models.Connection, error)
+	DeactivateByUserDeliveryServer(ctx context.Context, userDeliveryServerId primitive.ObjectID) ([]models.Connection, error)
+}
+
+// AuditEventRepo lưu audit event, chỉ thêm mới, không sửa hay xoá
+type AuditEventRepo interface {
+	Create(ctx context.Context, event models.AuditEvent) error
+	// List trả về event mới nhất trước
+	List(ctx context.Context, filter AuditEventFilter) ([]models.AuditEvent, int64, error)
+	// Export gọi fn cho mọi event khớp filter theo thứ tự thời gian, bỏ qua Limit/Page
+	Export(ctx context.Context, filter AuditEventFilter, fn func(models.AuditEvent) error) error
+}
+
+// ApiKeyRepo lưu các scoped API key, chỉ lưu prefix và hash của key
+type ApiKeyRepo interface {
+	Create(ctx context.Context, apiKey models.ApiKey) error
+	FindById(ctx context.Context, id primitive.ObjectID) (models.ApiKey, error)
+	FindByPrefix(ctx context.Context, prefix string) ([]models.ApiKey, error)
+	ListByOwner(ctx context.Context, ownerType string, ownerId primitive.ObjectID) ([]models.ApiKey, error)
+	Revoke(ctx context.Context, id primitive.ObjectID) (models.ApiKey, error)
+}
+
+// JobRepo là hàng đợi job, worker lấy job bằng cách lease có thời hạn
+type JobRepo interface {
+	Enqueue(ctx context.Context, job models.Job) error
+	FindById(ctx context.Context, id primitive.ObjectID) (models.Job, error)
+	// Lease trả về nil nếu không có job nào đến hạn. Lấy lại job có lease đã hết hạn (worker trước
+	// bị crash hoặc treo) được tính là một lần thử, job đã hết maxAttempts lần thử thì chuyển sang failed.
+	Lease(ctx context.Context, leaseDuration time.Duration, maxAttempts int) (*models.Job, error)
+	// Ack, Release và Fail nhận job trả về từ Lease, trả về ErrLeaseLost nếu job đã bị lease lại
+	Ack(ctx context.Context, job models.Job) error
+	Release(ctx context.Context, job models.Job) error
+	Fail(ctx context.Context, job models.Job, cause error, retryAt time.Time, final bool) error
+	// Stats đếm job chưa xong theo status, dùng cho metrics
+	Stats(ctx context.Context) (JobStats, error)
+}
+
+// JobStats là trạng thái hàng đợi, chỉ tính các job pending, leased và failed
+type JobStats struct {
+	Counts map[string]int64
+	// Thời điểm lease của job leased lâu nhất, zero nếu không có
+	OldestLeasedAt time.Time
+	// RunAt sớm nhất của các job pending, zero nếu không có
+	OldestRunAt time.Time
+}
+
+// OrganizationRepo lưu các organization, tên là duy nhất
+type OrganizationRepo interface {
+	Create(ctx context.Context, organization models.Organization) error
+	FindById(ctx context.Context, id primitive.ObjectID) (models.Organization, error)
+	FindByName(ctx context.Context, name string) (models.Organization, error)
+}
+
+// ConnectionConsentRepo lưu các consent cho phép organization khác kết nối tới webview server,
+// mỗi cặp webview server / organization được cấp là duy nhất
+type ConnectionConsentRepo interface {
+	Create(ctx context.Context, consent models.ConnectionConsent) error
+	FindById(ctx context.Context, id primitive.ObjectID) (models.ConnectionConsent, error)
+	Exists(ctx context.Context, webviewServerId, granteeOrganizationId primitive.ObjectID) (bool, error)
+	ListByWebviewServer(ctx context.Context, webviewServerId primitive.ObjectID) ([]models.ConnectionConsent, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+// AdminRepo lưu tài khoản admin, username là duy nhất
+type AdminRepo interface {
+	Create(ctx context.Context, admin models.Admin) error
+	FindById(ctx context.Context, id primitive.ObjectID) (models.Admin, error)
+	FindByUsername(ctx context.Context, username string) (models.Admin, error)
+	List(ctx context.Context, filter AdminFilter) ([]models.Admin, int64, error)
+	UpdateRole(ctx context.Context, id primitive.ObjectID, role string) (models.Admin, error)
+}
+
+// AdminSessionRepo lưu các phiên đăng nhập cùng hash của refresh token hiện tại
+type AdminSessionRepo interface {
+	Create(ctx context.Context, session models.AdminSession) error
+	FindById(ctx context.Context, id primitive.ObjectID) (models.AdminSession, error)
+	// UpdateRefreshToken chỉ thay hash khi hash đang lưu vẫn là previousHash, trả về ErrNotFound nếu không khớp
+	// để hai lần refresh đồng thời bằng cùng một token chỉ có một lần thành công
+	UpdateRefreshToken(ctx context.Context, id primitive.ObjectID, previousHash, refreshTokenHash string, expiresAt time.Time) error
+	Revoke(ctx context.Context, id primitive.ObjectID) error
+}
+
+// Repositories gom tất cả repository của một backend
+type Repositories struct {
+	WebviewServers      WebviewServerRepo
+	UserDeliveryServers UserDeliveryServerRepo
+	Connections         ConnectionRepo
+	Jobs                JobRepo
+	Admins              AdminRepo
+	AdminSessions       AdminSessionRepo
+	Organizations       OrganizationRepo
+	ConnectionConsents  ConnectionConsentRepo
+	ApiKeys             ApiKeyRepo
+	AuditEvents         AuditEventRepo
+
+	migrate            func(ctx context.Context) error
+	migrateDown        func(ctx context.Context, to int) error
+	migrations         func(ctx context.Context) ([]MigrationStatus, error)
+	checkMigrated      func(ctx context.Context) error
+	ping               func(ctx context.Context) error
+	assignOrganization func(ctx context.Context, organizationId primitive.ObjectID) (int64, error)
+	reEncrypt          func(ctx context.Context) (int64, error)
+	close              func(ctx context.Context) error
+}
+
+// Migrate chạy các migration chưa được áp dụng (collection, index...) của backend
+func (r Repositories) Migrate(ctx context.Context) error {
+	if r.migrate == nil {
+		return nil
+	}
+	return r.migrate(ctx)
+}
+
+// MigrateDown hoàn tác các migration có version lớn hơn to
+func (r Repositories) MigrateDown(ctx context.Context, to int) error {
+	if r.migrateDown == nil {
+		return nil
+	}
+	return r.migrateDown(ctx, to)
+}
+
+// Migrations trả về trạng thái các migration của backend, rỗng nếu backend không cần migration
+func (r Repositories) Migrations(ctx context.Context) ([]MigrationStatus, error) {
+	if r.migrations == nil {
+		return nil, nil
+	}
+	return r.migrations(ctx)
+}
+
+// CheckMigrated trả về lỗi nếu storage chưa được chuẩn bị bằng lệnh migrate
+func (r Repositories) CheckMigrated(ctx context.Context) error {
+	if r.checkMigrated == nil {
+		return nil
+	}
+	return r.checkMigrated(ctx)
+}
+
+// Ping kiểm tra storage còn kết nối được
+func (r Repositories) Ping(ctx context.Context) error {
+	if r.ping == nil {
+		return nil
+	}
+	return r.ping(ctx)
+}
+
+// AssignOrganization gán các server, connection và admin tạo trước khi có organization
+// (chưa có organizationid) cho organizationId, trả về số document đã cập nhật
+func (r Repositories) AssignOrganization(ctx context.Context, organizationId primitive.ObjectID) (int64, error) {
+	if r.assignOrganization == nil {
+		return 0, nil
+	}
+	return r.assignOrganization(ctx, organizationId)
+}
+
+// ReEncrypt mã hoá lại các field nhạy cảm đang là plaintext hoặc dùng khoá cũ bằng khoá
+// primary của keyring, trả về số document đã cập nhật
+func (r Repositories) ReEncrypt(ctx context.Context) (int64, error) {
+	if !encryption.Enabled() {
+		return 0, encryption.ErrNoKeyring
+	}
+	if r.reEncrypt == nil {
+		return 0, nil
+	}
+	return r.reEncrypt(ctx)
+}
+
+// Close giải phóng kết nối tới storage
+func (r Repositories) Close(ctx context.Context) error {
+	if r.close == nil {
+		return nil
+	}
+	return r.close(ctx)
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testMongoURIEnv bật test và benchmark trên MongoDB, mỗi lần chạy dùng một database tạm và xoá nó khi xong
+const testMongoURIEnv = "NOTIFICATION_TEST_MONGO_URI"
+
+// openTestMongo mở một database tạm đã chạy hết migration, bỏ qua test nếu chưa cấu hình MongoDB
+func openTestMongo(tb testing.TB) Repositories {
+	tb.Helper()
+	repos, _ := openTestMongoDatabase(tb)
+	return repos
+}
+
+func openTestMongoDatabase(tb testing.TB) (Repositories, *mongo.Database) {
+	tb.Helper()
+	uri := os.Getenv(testMongoURIEnv)
+	if uri == "" {
+		tb.Skip(testMongoURIEnv + " is not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	db := client.Database("notification_test_" + primitive.NewObjectID().Hex())
+	tb.Cleanup(func() {
+		if err := db.Drop(ctx); err != nil {
+			tb.Error(err)
+		}
+		client.Disconnect(ctx)
+	})
+
+	repos := NewMongo(db)
+	if err := repos.Migrate(ctx); err != nil {
+		tb.Fatal(err)
+	}
+	return repos, db
+}
@@ -0,0 +1,154 @@
+package repositories
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// Kiểu BSON của các field, khớp với cách mongo-driver marshal model
+var (
+	objectIdType  = bson.M{"bsonType": "objectId"}
+	stringType    = bson.M{"bsonType": "string"}
+	dateType      = bson.M{"bsonType": "date"}
+	intType       = bson.M{"bsonType": bson.A{"int", "long"}}
+	stringsType   = bson.M{"bsonType": bson.A{"array", "null"}, "items": stringType}
+	optionalMap   = bson.M{"bsonType": bson.A{"object", "null"}}
+	encryptedType = bson.M{"bsonType": bson.A{"string", "object"}} // object là envelope đã mã hoá
+)
+
+var apiKeyRotationSchema = bson.M{
+	"bsonType": bson.A{"object", "null"},
+	"properties": bson.M{
+		"previousKeyPrefix":    stringType,
+		"previousKeyHash":      stringType,
+		"previousKeyExpiresAt": dateType,
+		"rotatedBy":            objectIdType,
+		"rotatedAt":            dateType,
+	},
+}
+
+// collectionSchemas là $jsonSchema của từng collection, dùng làm validator ở migration 9.
+// Bắt buộc mọi field luôn được gán khi tạo document, kể cả organizationId của mọi collection thuộc một tổ chức;
+// validationLevel là moderate nên document cũ đã thiếu field vẫn cập nhật được.
+var collectionSchemas = map[string]bson.M{
+	webviewServerCollectionName:      serverSchema,
+	userDeliveryServerCollectionName: serverSchema,
+	connectionCollectionName: objectSchema(
+		[]string{"organizationId", "status", "webviewServerId", "userDeliveryServerId", "createdAt"},
+		bson.M{
+			"organizationId":                   objectIdType,
+			"status":                           bson.M{"enum": bson.A{"active", "inactive"}},
+			"createdAt":                        dateType,
+			"updatedAt":                        dateType,
+			"webviewServerApiKeyPrefix":        stringType,
+			"webviewServerApiKeyHash":          stringType,
+			"userDeliveryServerApiKeyPrefix":   stringType,
+			"userDeliveryServerApiKeyHash":     stringType,
+			"webviewServerId":                  objectIdType,
+			"userDeliveryServerId":             objectIdType,
+			"userDeliveryServerWebHookUrl":     stringType,
+			"webviewServerApiKeyRotation":      apiKeyRotationSchema,
+			"userDeliveryServerApiKeyRotation": apiKeyRotationSchema,
+			"webhookSecret":                    encryptedType,
+			"webhookVerifiedUrl":               stringType,
+			"webhookVerifiedAt":                dateType,
+		}),
+	jobCollectionName: objectSchema(
+		[]string{"connectionId", "message", "status", "attempts", "runAt", "createdAt"},
+		bson.M{
+			"connectionId":   objectIdType,
+			"message":        encryptedType,
+			"status":         bson.M{"enum": bson.A{"pending", "leased", "done", "failed"}},
+			"attempts":       intType,
+			"lastError":      stringType,
+			"runAt":          dateType,
+			"leasedAt":       dateType,
+			"leaseExpiresAt": dateType,
+			"createdAt":      dateType,
+			"updatedAt":      dateType,
+			"traceContext":   optionalMap,
+		}),
+	adminCollectionName: objectSchema(
+		[]string{"organizationId", "username", "role", "passwordHash", "createdAt"},
+		bson.M{
+			"organizationId": objectIdType,
+			"username":       stringType,
+			"role":           stringType,
+			"passwordHash":   stringType,
+			"createdAt":      dateType,
+			"updatedAt":      dateType,
+		}),
+	adminSessionCollectionName: objectSchema(
+		[]string{"adminId", "refreshTokenHash", "expiresAt", "createdAt"},
+		bson.M{
+			"adminId":          objectIdType,
+			"refreshTokenHash": stringType,
+			"expiresAt":        dateType,
+			"revokedAt":        dateType,
+			"createdAt":        dateType,
+			"updatedAt":        dateType,
+		}),
+	organizationCollectionName: objectSchema(
+		[]string{"name", "createdAt"},
+		bson.M{
+			"name":      stringType,
+			"createdAt": dateType,
+			"updatedAt": dateType,
+		}),
+	connectionConsentCollectionName: objectSchema(
+		[]string{"organizationId", "webviewServerId", "granteeOrganizationId", "createdAt"},
+		bson.M{
+			"organizationId":        objectIdType,
+			"webviewServerId":       objectIdType,
+			"granteeOrganizationId": objectIdType,
+			"createdBy":             objectIdType,
+			"createdAt":             dateType,
+		}),
+	apiKeyCollectionName: objectSchema(
+		[]string{"organizationId", "ownerType", "ownerId", "prefix", "hash", "createdAt"},
+		bson.M{
+			"organizationId": objectIdType,
+			"name":           stringType,
+			"ownerType":      stringType,
+			"ownerId":        objectIdType,
+			"side":           stringType,
+			"scopes":         stringsType,
+			"allowedIps":     stringsType,
+			"prefix":         stringType,
+			"hash":           stringType,
+			"expiresAt":      dateType,
+			"revokedAt":      dateType,
+			"createdBy":      objectIdType,
+			"createdAt":      dateType,
+			"updatedAt":      dateType,
+		}),
+	auditEventCollectionName: objectSchema(
+		[]string{"organizationId", "action", "entityType", "entityId", "createdAt"},
+		bson.M{
+			"organizationId": objectIdType,
+			"actorId":        objectIdType,
+			"actorUsername":  stringType,
+			"action":         stringType,
+			"entityType":     stringType,
+			"entityId":       objectIdType,
+			"changes": bson.M{
+				"bsonType": bson.A{"array", "null"},
+				"items":    objectSchema([]string{"field"}, bson.M{"field": stringType}),
+			},
+			"parentId":  objectIdType,
+			"requestId": stringType,
+			"sourceIp":  stringType,
+			"createdAt": dateType,
+		}),
+}
+
+var serverSchema = objectSchema(
+	[]string{"organizationId", "name", "status", "createdAt"},
+	bson.M{
+		"organizationId": objectIdType,
+		"name":           stringType,
+		"status":         bson.M{"enum": bson.A{"active", "inactive"}},
+		"createdAt":      dateType,
+		"updatedAt":      dateType,
+	})
+
+func objectSchema(required []string, properties bson.M) bson.M {
+	return bson.M{"bsonType": "object", "required": required, "properties": properties}
+}
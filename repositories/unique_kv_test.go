@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/apperrors"
+	"draft-notification/models"
+	"errors"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// serverRepo là phần chung của WebviewServerRepo và UserDeliveryServerRepo dùng trong test tên duy nhất
+type serverRepo struct {
+	create     func(id, organizationId primitive.ObjectID, name string) error
+	updateName func(id primitive.ObjectID, name string) error
+}
+
+func TestKVServerNameUniqueness(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemory()
+	tests := map[string]serverRepo{
+		"webview server": {
+			create: func(id, organizationId primitive.ObjectID, name string) error {
+				return repos.WebviewServers.Create(ctx, models.WebviewServer{Id: id, OrganizationId: organizationId, Name: name})
+			},
+			updateName: func(id primitive.ObjectID, name string) error {
+				_, err := repos.WebviewServers.UpdateName(ctx, id, name)
+				return err
+			},
+		},
+		"user delivery server": {
+			create: func(id, organizationId primitive.ObjectID, name string) error {
+				return repos.UserDeliveryServers.Create(ctx, models.UserDeliveryServer{Id: id, OrganizationId: organizationId, Name: name})
+			},
+			updateName: func(id primitive.ObjectID, name string) error {
+				_, err := repos.UserDeliveryServers.UpdateName(ctx, id, name)
+				return err
+			},
+		},
+	}
+
+	for name, repo := range tests {
+		t.Run(name, func(t *testing.T) {
+			organizationId, otherOrganizationId := primitive.NewObjectID(), primitive.NewObjectID()
+			first, second := primitive.NewObjectID(), primitive.NewObjectID()
+
+			steps := []struct {
+				name string
+				err  error
+				run  func() error
+			}{
+				{"create", nil, func() error { return repo.create(first, organizationId, "a") }},
+				{"create same name", ErrDuplicate, func() error { return repo.create(primitive.NewObjectID(), organizationId, "a") }},
+				{"create same name in another organization", nil, func() error { return repo.create(primitive.NewObjectID(), otherOrganizationId, "a") }},
+				{"create other name", nil, func() error { return repo.create(second, organizationId, "b") }},
+				{"rename to a taken name", ErrDuplicate, func() error { return repo.updateName(second, "a") }},
+				{"rename to its own name", nil, func() error { return repo.updateName(first, "a") }},
+				{"rename to a free name", nil, func() error { return repo.updateName(second, "c") }},
+				{"create the released name", nil, func() error { return repo.create(primitive.NewObjectID(), organizationId, "b") }},
+			}
+			for _, step := range steps {
+				if err := step.run(); !errors.Is(err, step.err) {
+					t.Fatalf("%s = %v, want %v", step.name, err, step.err)
+				}
+			}
+		})
+	}
+}
+
+func TestKVConnectionPairUniqueness(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemory()
+	webview, otherWebview := primitive.NewObjectID(), primitive.NewObjectID()
+	delivery, otherDelivery := primitive.NewObjectID(), primitive.NewObjectID()
+
+	tests := []struct {
+		name                 string
+		webviewServerId      primitive.ObjectID
+		userDeliveryServerId primitive.ObjectID
+		err                  error
+	}{
+		{"new pair", webview, delivery, nil},
+		{"same pair", webview, delivery, ErrDuplicate},
+		{"same webview server", webview, otherDelivery, nil},
+		{"same user delivery server", otherWebview, delivery, nil},
+	}
+	for _, tt := range tests {
+		err := repos.Connections.Create(ctx, models.Connection{
+			Id:                   primitive.NewObjectID(),
+			WebviewServerId:      tt.webviewServerId,
+			UserDeliveryServerId: tt.userDeliveryServerId,
+		})
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%s: Create = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	// Controller trả lỗi trùng lặp của storage về cho client dưới dạng 409
+	var appErr *apperrors.Error
+	if !errors.As(ErrDuplicate, &appErr) || appErr.Status() != http.StatusConflict {
+		t.Fatalf("ErrDuplicate = %v, want a 409 conflict", ErrDuplicate)
+	}
+}
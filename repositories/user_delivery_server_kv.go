@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type kvUserDeliveryServerRepo struct {
+	store kvStore
+}
+
+func (r *kvUserDeliveryServerRepo) Create(ctx context.Context, server models.UserDeliveryServer) error {
+	return r.store.update(func(tx kvTx) error {
+		if exists, err := r.existsByName(tx, server.Id, server.OrganizationId, server.Name); err != nil {
+			return err
+		} else if exists {
+			return ErrDuplicate
+		}
+		return kvPut(tx, userDeliveryServerCollectionName, server.Id.Hex(), server)
+	})
+}
+
+func (r *kvUserDeliveryServerRepo) FindById(ctx context.Context, id primitive.ObjectID) (server models.UserDeliveryServer, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		server, err = kvGet[models.UserDeliveryServer](tx, userDeliveryServerCollectionName, id.Hex())
+		return err
+	})
+	return server, err
+}
+
+// existsByName tìm server khác selfId có cùng tên trong organization, đổi tên sang chính tên hiện tại không bị coi là trùng
+func (r *kvUserDeliveryServerRepo) existsByName(tx kvTx, selfId, organizationId primitive.ObjectID, name string) (bool, error) {
+	found, err := kvFind(tx, userDeliveryServerCollectionName, func(server models.UserDeliveryServer) bool {
+		return server.Id != selfId && server.OrganizationId == organizationId && server.Name == name
+	})
+	return len(found) > 0, err
+}
+
+func (r *kvUserDeliveryServerRepo) List(ctx context.Context, filter ListFilter) (list []models.UserDeliveryServer, total int64, err error) {
+	matchName, err := keywordMatcher(filter.Keyword)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.store.view(func(tx kvTx) error {
+		list, err = kvFind(tx, userDeliveryServerCollectionName, func(server models.UserDeliveryServer) bool {
+			return server.OrganizationId == filter.OrganizationId &&
+				matchName(server.Name) && (filter.Status == "" || server.Status == filter.Status) &&
+				inCreatedRange(server.CreatedAt, filter.CreatedFrom, filter.CreatedTo)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return kvSortedPage(list, UserDeliveryServerSortFields, filter.Sort, filter.After, filter.Limit, filter.Page), int64(len(list)), nil
+}
+
+func (r *kvUserDeliveryServerRepo) UpdateName(ctx context.Context, id primitive.ObjectID, name string) (models.UserDeliveryServer, error) {
+	return r.modify(id, func(tx kvTx, server *models.UserDeliveryServer) error {
+		if exists, err := r.existsByName(tx, server.Id, server.OrganizationId, name); err != nil {
+			return err
+		} else if exists {
+			return ErrDuplicate
+		}
+		server.Name = name
+		return nil
+	})
+}
+
+func (r *kvUserDeliveryServerRepo) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) (models.UserDeliveryServer, error) {
+	return r.modify(id, func(tx kvTx, server *models.UserDeliveryServer) error {
+		server.Status = status
+		return nil
+	})
+}
+
+// modify đọc, sửa và ghi lại một user delivery server trong cùng transaction
+func (r *kvUserDeliveryServerRepo) modify(id primitive.ObjectID, fn func(tx kvTx, server *models.UserDeliveryServer) error) (server models.UserDeliveryServer, err error) {
+	err = r.store.update(func(tx kvTx) error {
+		server, err = kvGet[models.UserDeliveryServer](tx, userDeliveryServerCollectionName, id.Hex())
+		if err != nil {
+			return err
+		}
+		if err := fn(tx, &server); err != nil {
+			return err
+		}
+		server.UpdatedAt = time.Now().UTC()
+		return kvPut(tx, userDeliveryServerCollectionName, id.Hex(), server)
+	})
+	return server, err
+}
@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoUserDeliveryServerRepo struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoUserDeliveryServerRepo) Create(ctx context.Context, server models.UserDeliveryServer) error {
+	return insertOne(ctx, r.collection, server)
+}
+
+func (r *mongoUserDeliveryServerRepo) FindById(ctx context.Context, id primitive.ObjectID) (models.UserDeliveryServer, error) {
+	var server models.UserDeliveryServer
+	err := findOne(ctx, r.collection, bson.M{"_id": id}, &server)
+	return server, err
+}
+
+func (r *mongoUserDeliveryServerRepo) List(ctx context.Context, filter ListFilter) ([]models.UserDeliveryServer, int64, error) {
+	return findSortedList[models.UserDeliveryServer](ctx, r.collection, serverFilter(filter), filter.Sort, filter.After, filter.Limit, filter.Page)
+}
+
+func (r *mongoUserDeliveryServerRepo) UpdateName(ctx context.Context, id primitive.ObjectID, name string) (models.UserDeliveryServer, error) {
+	var server models.UserDeliveryServer
+	err := updateAndFind(ctx, r.collection, bson.M{"_id": id}, bson.M{"name": name, "updatedAt": time.Now().UTC()}, &server)
+	return server, err
+}
+
+func (r *mongoUserDeliveryServerRepo) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) (models.UserDeliveryServer, error) {
+	var server models.UserDeliveryServer
+	err := updateAndFind(ctx, r.collection, bson.M{"_id": id}, bson.M{"status": status, "updatedAt": time.Now().UTC()}, &server)
+	return server, err
+}
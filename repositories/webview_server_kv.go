@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type kvWebviewServerRepo struct {
+	store kvStore
+}
+
+func (r *kvWebviewServerRepo) Create(ctx context.Context, server models.WebviewServer) error {
+	return r.store.update(func(tx kvTx) error {
+		if exists, err := r.existsByName(tx, server.Id, server.OrganizationId, server.Name); err != nil {
+			return err
+		} else if exists {
+			return ErrDuplicate
+		}
+		return kvPut(tx, webviewServerCollectionName, server.Id.Hex(), server)
+	})
+}
+
+func (r *kvWebviewServerRepo) FindById(ctx context.Context, id primitive.ObjectID) (server models.WebviewServer, err error) {
+	err = r.store.view(func(tx kvTx) error {
+		server, err = kvGet[models.WebviewServer](tx, webviewServerCollectionName, id.Hex())
+		return err
+	})
+	return server, err
+}
+
+// existsByName tìm server khác selfId có cùng tên trong organization, đổi tên sang chính tên hiện tại không bị coi là trùng
+func (r *kvWebviewServerRepo) existsByName(tx kvTx, selfId, organizationId primitive.ObjectID, name string) (bool, error) {
+	found, err := kvFind(tx, webviewServerCollectionName, func(server models.WebviewServer) bool {
+		return server.Id != selfId && server.OrganizationId == organizationId && server.Name == name
+	})
+	return len(found) > 0, err
+}
+
+func (r *kvWebviewServerRepo) List(ctx context.Context, filter ListFilter) (list []models.WebviewServer, total int64, err error) {
+	matchName, err := keywordMatcher(filter.Keyword)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.store.view(func(tx kvTx) error {
+		list, err = kvFind(tx, webviewServerCollectionName, func(server models.WebviewServer) bool {
+			return server.OrganizationId == filter.OrganizationId &&
+				matchName(server.Name) && (filter.Status == "" || server.Status == filter.Status) &&
+				inCreatedRange(server.CreatedAt, filter.CreatedFrom, filter.CreatedTo)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return kvSortedPage(list, WebviewServerSortFields, filter.Sort, filter.After, filter.Limit, filter.Page), int64(len(list)), nil
+}
+
+func (r *kvWebviewServerRepo) UpdateName(ctx context.Context, id primitive.ObjectID, name string) (models.WebviewServer, error) {
+	return r.modify(id, func(tx kvTx, server *models.WebviewServer) error {
+		if exists, err := r.existsByName(tx, server.Id, server.OrganizationId, name); err != nil {
+			return err
+		} else if exists {
+			return ErrDuplicate
+		}
+		server.Name = name
+		return nil
+	})
+}
+
+func (r *kvWebviewServerRepo) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) (models.WebviewServer, error) {
+	return r.modify(id, func(tx kvTx, server *models.WebviewServer) error {
+		server.Status = status
+		return nil
+	})
+}
+
+// modify đọc, sửa và ghi lại một webview server trong cùng transaction
+func (r *kvWebviewServerRepo) modify(id primitive.ObjectID, fn func(tx kvTx, server *models.WebviewServer) error) (server models.WebviewServer, err error) {
+	err = r.store.update(func(tx kvTx) error {
+		server, err = kvGet[models.WebviewServer](tx, webviewServerCollectionName, id.Hex())
+		if err != nil {
+			return err
+		}
+		if err := fn(tx, &server); err != nil {
+			return err
+		}
+		server.UpdatedAt = time.Now().UTC()
+		return kvPut(tx, webviewServerCollectionName, id.Hex(), server)
+	})
+	return server, err
+}
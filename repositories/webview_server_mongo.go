@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"draft-notification/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoWebviewServerRepo struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoWebviewServerRepo) Create(ctx context.Context, server models.WebviewServer) error {
+	return insertOne(ctx, r.collection, server)
+}
+
+func (r *mongoWebviewServerRepo) FindById(ctx context.Context, id primitive.ObjectID) (models.WebviewServer, error) {
+	var server models.WebviewServer
+	err := findOne(ctx, r.collection, bson.M{"_id": id}, &server)
+	return server, err
+}
+
+func (r *mongoWebviewServerRepo) List(ctx context.Context, filter ListFilter) ([]models.WebviewServer, int64, error) {
+	return findSortedList[models.WebviewServer](ctx, r.collection, serverFilter(filter), filter.Sort, filter.After, filter.Limit, filter.Page)
+}
+
+func (r *mongoWebviewServerRepo) UpdateName(ctx context.Context, id primitive.ObjectID, name string) (models.WebviewServer, error) {
+	var server models.WebviewServer
+	err := updateAndFind(ctx, r.collection, bson.M{"_id": id}, bson.M{"name": name, "updatedAt": time.Now().UTC()}, &server)
+	return server, err
+}
+
+func (r *mongoWebviewServerRepo) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) (models.WebviewServer, error) {
+	var server models.WebviewServer
+	err := updateAndFind(ctx, r.collection, bson.M{"_id": id}, bson.M{"status": status, "updatedAt": time.Now().UTC()}, &server)
+	return server, err
+}
@@ -0,0 +1,10 @@
+package responses
+
+import (
+	"draft-notification/models"
+)
+
+type GetAllAdminResponse struct {
+	List       []models.Admin `json:"list"`
+	Pagination Pagination     `json:"pagination"`
+}
@@ -0,0 +1,8 @@
+package responses
+
+import "draft-notification/models"
+
+type GetAllAuditEventResponse struct {
+	List       []models.AuditEvent `json:"list"`
+	Pagination Pagination          `json:"pagination"`
+}
@@ -2,9 +2,35 @@ package responses
 
 import (
 	"draft-notification/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type GetAllConnectionResponse struct {
-	List       []models.ConnectionResponse `json:"list"`
-	Pagination Pagination                  `json:"pagination"`
+	List []models.ConnectionResponse `json:"list"`
+	// Pagination có ở chế độ page, Cursor có ở chế độ cursor
+	Pagination *Pagination       `json:"pagination,omitempty"`
+	Cursor     *CursorPagination `json:"cursor,omitempty"`
+}
+
+// UpdatedWebhookUrlResponse trả về connection sau khi đổi webhook URL kèm kết quả xác minh
+type UpdatedWebhookUrlResponse struct {
+	models.Connection
+	WebhookVerified          bool   `json:"webhookVerified"`
+	WebhookVerificationError string `json:"webhookVerificationError,omitempty"`
+}
+
+// RotateWebhookSecretResponse chứa webhook secret mới, chỉ hiển thị một lần
+type RotateWebhookSecretResponse struct {
+	ConnectionId  primitive.ObjectID `json:"connectionId"`
+	WebhookSecret string             `json:"webhookSecret"`
+}
+
+// RotateApiKeyResponse chứa API key mới, key cũ còn hợp lệ tới PreviousKeyExpiresAt
+type RotateApiKeyResponse struct {
+	ConnectionId         primitive.ObjectID `json:"connectionId"`
+	Side                 string             `json:"side"`
+	ApiKey               string             `json:"apiKey"`
+	PreviousKeyExpiresAt time.Time          `json:"previousKeyExpiresAt"`
 }
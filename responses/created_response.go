@@ -0,0 +1,24 @@
+package responses
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Giữ nguyên format của mongo.InsertOneResult mà API trả về trước đây
+type CreatedResponse struct {
+	InsertedID primitive.ObjectID `json:"InsertedID"`
+}
+
+// CreatedConnectionResponse trả về API key và webhook secret đầy đủ của connection, chỉ hiển thị một lần khi tạo.
+// Webhook chưa được xác minh: sau khi cấu hình secret ở webhook, client gọi
+// POST /connections/:id/verify-webhook rồi mới active được connection.
+type CreatedConnectionResponse struct {
+	InsertedID               primitive.ObjectID `json:"InsertedID"`
+	WebviewServerApiKey      string             `json:"webviewServerApiKey"`
+	UserDeliveryServerApiKey string             `json:"userDeliveryServerApiKey"`
+	WebhookSecret            string             `json:"webhookSecret"`
+}
+
+// CreatedApiKeyResponse trả về scoped API key đầy đủ, chỉ hiển thị một lần khi tạo
+type CreatedApiKeyResponse struct {
+	InsertedID primitive.ObjectID `json:"InsertedID"`
+	ApiKey     string             `json:"apiKey"`
+}
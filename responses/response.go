@@ -1,11 +1,15 @@
 package responses
 
+import "draft-notification/apperrors"
+
 type Response struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data"`
-	// Data    *echo.Map `json:"data"`
-
+	// Mã ổn định dạng machine-readable của message, ví dụ "missing_permission" hoặc "success"
+	Reason string `json:"reason,omitempty"`
+	// Chi tiết lỗi của từng field khi validation thất bại
+	Errors []apperrors.FieldError `json:"errors,omitempty"`
 }
 
 type Pagination struct {
@@ -13,3 +17,10 @@ type Pagination struct {
 	Page  int `json:"page"`
 	Total int `json:"total"`
 }
+
+// CursorPagination là phân trang theo cursor, NextCursor rỗng khi đã tới trang cuối
+type CursorPagination struct {
+	Limit      int    `json:"limit"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
@@ -5,6 +5,8 @@ import (
 )
 
 type GetAllUserDeliveryServerResponse struct {
-	List       []models.UserDeliveryServer `json:"list"`
-	Pagination Pagination                  `json:"pagination"`
+	List []models.UserDeliveryServer `json:"list"`
+	// Pagination có ở chế độ page, Cursor có ở chế độ cursor
+	Pagination *Pagination       `json:"pagination,omitempty"`
+	Cursor     *CursorPagination `json:"cursor,omitempty"`
 }
@@ -5,6 +5,8 @@ import (
 )
 
 type GetAllWebviewServerResponse struct {
-	List       []models.WebviewServer `json:"list"`
-	Pagination Pagination             `json:"pagination"`
+	List []models.WebviewServer `json:"list"`
+	// Pagination có ở chế độ page, Cursor có ở chế độ cursor
+	Pagination *Pagination       `json:"pagination,omitempty"`
+	Cursor     *CursorPagination `json:"cursor,omitempty"`
 }
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"draft-notification/auth"
+	"draft-notification/controllers"
+	"draft-notification/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func AdminRoute(e *echo.Echo, ctl *controllers.AdminController) {
+	e.POST("/admins", ctl.CreateAdmin, middlewares.RequirePermission(auth.PermAdminsManage))
+	e.GET("/admins", ctl.GetAllAdmins, middlewares.RequirePermission(auth.PermAdminsManage))
+	e.PATCH("/admins/:id/change-role", ctl.ChangeRoleAdmin, middlewares.RequirePermission(auth.PermAdminsManage))
+}
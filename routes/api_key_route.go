@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"draft-notification/auth"
+	"draft-notification/controllers"
+	"draft-notification/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func ApiKeyRoute(e *echo.Echo, ctl *controllers.ApiKeyController) {
+	e.POST("/webview-server/:id/api-keys", ctl.CreateWebviewServerApiKey, middlewares.RequirePermission(auth.PermKeysManage))
+	e.GET("/webview-server/:id/api-keys", ctl.GetAllWebviewServerApiKeys, middlewares.RequirePermission(auth.PermServersRead))
+	e.POST("/connections/:id/api-keys", ctl.CreateConnectionApiKey, middlewares.RequirePermission(auth.PermKeysManage))
+	e.GET("/connections/:id/api-keys", ctl.GetAllConnectionApiKeys, middlewares.RequirePermission(auth.PermConnectionsRead))
+	e.PATCH("/api-keys/:id/revoke", ctl.RevokeApiKey, middlewares.RequirePermission(auth.PermKeysManage))
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"draft-notification/auth"
+	"draft-notification/controllers"
+	"draft-notification/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func AuditEventRoute(e *echo.Echo, ctl *controllers.AuditEventController) {
+	e.GET("/audit-events", ctl.GetAllAuditEvents, middlewares.RequirePermission(auth.PermAuditRead))
+	e.GET("/audit-events/export", ctl.ExportAuditEvents, middlewares.RequirePermission(auth.PermAuditRead))
+}
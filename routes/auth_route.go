@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"draft-notification/controllers"
+
+	"github.com/labstack/echo/v4"
+)
+
+func AuthRoute(e *echo.Echo, ctl *controllers.AuthController) {
+	e.POST("/auth/login", ctl.Login)
+	e.POST("/auth/refresh", ctl.Refresh)
+	e.POST("/auth/logout", ctl.Logout)
+	e.GET("/auth/me", ctl.Me)
+}
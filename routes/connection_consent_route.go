@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"draft-notification/auth"
+	"draft-notification/controllers"
+	"draft-notification/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func ConnectionConsentRoute(e *echo.Echo, ctl *controllers.ConnectionConsentController) {
+	e.POST("/webview-server/:id/consents", ctl.CreateConnectionConsent, middlewares.RequirePermission(auth.PermConsentsManage))
+	e.GET("/webview-server/:id/consents", ctl.GetAllConnectionConsents, middlewares.RequirePermission(auth.PermServersRead))
+	e.DELETE("/webview-server/:id/consents/:consentId", ctl.DeleteConnectionConsent, middlewares.RequirePermission(auth.PermConsentsManage))
+}
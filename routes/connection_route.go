@@ -1,14 +1,21 @@
 package routes
 
 import (
+	"draft-notification/auth"
 	"draft-notification/controllers"
+	"draft-notification/middlewares"
 
 	"github.com/labstack/echo/v4"
 )
 
-func ConnectionRoute(e *echo.Echo) {
-	e.POST("/user-delivery-server/:userDeliveryServerId/connection", controllers.CreateConnection)
-	e.GET("/user-delivery-server/:userDeliveryServerId/connections", controllers.GetAllConnections)
-	e.PATCH("/connections/:id/update-web-hook-url", controllers.UpdateConnectionWebhookUrl)
-	e.PATCH("/connections/:id/change-status", controllers.ChangeStatusConnection)
+func ConnectionRoute(e *echo.Echo, ctl *controllers.ConnectionController) {
+	// Tạo connection trả về API key và webhook secret dạng plaintext nên chỉ owner được gọi, giống các route rotate
+	e.POST("/user-delivery-server/:userDeliveryServerId/connection", ctl.CreateConnection, middlewares.RequirePermission(auth.PermKeysRotate))
+	e.GET("/user-delivery-server/:userDeliveryServerId/connections", ctl.GetAllConnections, middlewares.RequirePermission(auth.PermConnectionsRead))
+	e.PATCH("/connections/:id/update-web-hook-url", ctl.UpdateConnectionWebhookUrl, middlewares.RequirePermission(auth.PermConnectionsWrite))
+	e.POST("/connections/:id/verify-webhook", ctl.VerifyConnectionWebhook, middlewares.RequirePermission(auth.PermConnectionsWrite))
+	e.POST("/connections/:id/rotate-webhook-secret", ctl.RotateWebhookSecret, middlewares.RequirePermission(auth.PermKeysRotate))
+	e.PATCH("/connections/:id/change-status", ctl.ChangeStatusConnection, middlewares.RequirePermission(auth.PermConnectionsStatus))
+	e.POST("/connections/:id/rotate-webview-server-key", ctl.RotateWebviewServerApiKey, middlewares.RequirePermission(auth.PermKeysRotate))
+	e.POST("/connections/:id/rotate-user-delivery-server-key", ctl.RotateUserDeliveryServerApiKey, middlewares.RequirePermission(auth.PermKeysRotate))
 }
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"draft-notification/health"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HealthRoute cho liveness và readiness probe, không cần access token
+func HealthRoute(e *echo.Echo, checkTimeout time.Duration) {
+	e.GET("/healthz", echo.WrapHandler(health.LiveHandler()))
+	e.GET("/readyz", echo.WrapHandler(health.ReadyHandler(checkTimeout)))
+}
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"draft-notification/metrics"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MetricsRoute export Prometheus metrics, không cần access token
+func MetricsRoute(e *echo.Echo) {
+	e.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"draft-notification/auth"
+	"draft-notification/controllers"
+	"draft-notification/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+// NotificationRoute đăng ký các route dùng API key, nằm dưới prefix /api
+func NotificationRoute(e *echo.Echo, ctl *controllers.NotificationController, authenticator *auth.ApiKeyAuthenticator) {
+	api := e.Group("/api", middlewares.ValidateApiKey(authenticator))
+	api.POST("/notifications", ctl.SendNotification, middlewares.RequireScope(auth.ScopeNotificationsSend))
+	api.GET("/notifications/:id", ctl.GetNotification, middlewares.RequireScope(auth.ScopeNotificationsRead))
+}
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"draft-notification/controllers"
+
+	"github.com/labstack/echo/v4"
+)
+
+func OrganizationRoute(e *echo.Echo, ctl *controllers.OrganizationController) {
+	e.GET("/organization", ctl.GetCurrentOrganization)
+}
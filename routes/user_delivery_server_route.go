@@ -1,15 +1,17 @@
 package routes
 
 import (
+	"draft-notification/auth"
 	"draft-notification/controllers"
+	"draft-notification/middlewares"
 
 	"github.com/labstack/echo/v4"
 )
 
-func UserDeliveryServerRoute(e *echo.Echo) {
-	e.POST("/user-delivery-server", controllers.CreateUserDeliveryServer)
-	e.GET("/user-delivery-server", controllers.GetAllUserDeliveryServers)
-	e.GET("/user-delivery-server/:id", controllers.GetUserDeliveryServerDetail)
-	e.PUT("/user-delivery-server/:id", controllers.UpdateUserDeliveryServer)
-	e.PATCH("/user-delivery-server/:id/change-status", controllers.ChangeStatusUserDeliveryServer)
+func UserDeliveryServerRoute(e *echo.Echo, ctl *controllers.UserDeliveryServerController) {
+	e.POST("/user-delivery-server", ctl.CreateUserDeliveryServer, middlewares.RequirePermission(auth.PermServersWrite))
+	e.GET("/user-delivery-server", ctl.GetAllUserDeliveryServers, middlewares.RequirePermission(auth.PermServersRead))
+	e.GET("/user-delivery-server/:id", ctl.GetUserDeliveryServerDetail, middlewares.RequirePermission(auth.PermServersRead))
+	e.PUT("/user-delivery-server/:id", ctl.UpdateUserDeliveryServer, middlewares.RequirePermission(auth.PermServersWrite))
+	e.PATCH("/user-delivery-server/:id/change-status", ctl.ChangeStatusUserDeliveryServer, middlewares.RequirePermission(auth.PermServersStatus))
 }
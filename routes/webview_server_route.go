@@ -1,15 +1,17 @@
 package routes
 
 import (
+	"draft-notification/auth"
 	"draft-notification/controllers"
+	"draft-notification/middlewares"
 
 	"github.com/labstack/echo/v4"
 )
 
-func WebviewServerRoute(e *echo.Echo) {
-	e.POST("/webview-server", controllers.CreateWebviewServer)
-	e.GET("/webview-server", controllers.GetAllWebviewServers)
-	e.GET("/webview-server/:id", controllers.GetWebviewServerDetail)
-	e.PUT("/webview-server/:id", controllers.UpdateWebviewServer)
-	e.PATCH("/webview-server/:id/change-status", controllers.ChangeStatusWebviewServer)
+func WebviewServerRoute(e *echo.Echo, ctl *controllers.WebviewServerController) {
+	e.POST("/webview-server", ctl.CreateWebviewServer, middlewares.RequirePermission(auth.PermServersWrite))
+	e.GET("/webview-server", ctl.GetAllWebviewServers, middlewares.RequirePermission(auth.PermServersRead))
+	e.GET("/webview-server/:id", ctl.GetWebviewServerDetail, middlewares.RequirePermission(auth.PermServersRead))
+	e.PUT("/webview-server/:id", ctl.UpdateWebviewServer, middlewares.RequirePermission(auth.PermServersWrite))
+	e.PATCH("/webview-server/:id/change-status", ctl.ChangeStatusWebviewServer, middlewares.RequirePermission(auth.PermServersStatus))
 }
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+const maxRedirects = 3
+
+// Header gửi kèm mọi request tới webhook
+const (
+	EventHeader     = "X-Webhook-Event"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	EventNotification    = "notification"
+	EventUrlVerification = "url_verification"
+)
+
+// newHTTPClient tạo http.Client chỉ gọi được các địa chỉ mà policy cho phép,
+// địa chỉ được kiểm tra lại lúc dial và khi redirect
+func newHTTPClient(policy Policy, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: policy.Control,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Không đi qua proxy của môi trường, nếu không Control chỉ kiểm tra được địa chỉ proxy
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("too many redirects")
+			}
+			return policy.CheckURL(req.Context(), req.URL.String())
+		},
+	}
+}
+
+// Sign trả về HMAC-SHA256 (hex) của data với secret của connection
+func Sign(secret string, data []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
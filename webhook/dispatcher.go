@@ -0,0 +1,130 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"draft-notification/logging"
+	"draft-notification/metrics"
+	"draft-notification/models"
+	"draft-notification/repositories"
+	"draft-notification/tracing"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Payload là body JSON gửi tới webhook của user delivery server
+type Payload struct {
+	Id           primitive.ObjectID `json:"id"`
+	ConnectionId primitive.ObjectID `json:"connectionId"`
+	Message      string             `json:"message"`
+	CreatedAt    time.Time          `json:"createdAt"`
+}
+
+// Dispatcher gửi job tới webhook của connection, dùng làm queue.Handler
+type Dispatcher struct {
+	connections repositories.ConnectionRepo
+	policy      Policy
+	client      *http.Client
+}
+
+func NewDispatcher(connections repositories.ConnectionRepo, policy Policy, timeout time.Duration) *Dispatcher {
+	return &Dispatcher{connections: connections, policy: policy, client: newHTTPClient(policy, timeout)}
+}
+
+// Deliver gửi job tới webhook URL hiện tại của connection, lỗi sẽ được worker retry
+func (d *Dispatcher) Deliver(ctx context.Context, job models.Job) error {
+	if job.ConnectionId.IsZero() {
+		return errors.New("job has no connection")
+	}
+
+	connection, err := d.connections.FindById(ctx, job.ConnectionId)
+	if err != nil {
+		return fmt.Errorf("find connection %s: %w", job.ConnectionId.Hex(), err)
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "webhook deliver", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("connection.id", connection.Id.Hex()),
+		attribute.String("user_delivery_server.id", connection.UserDeliveryServerId.Hex()),
+	))
+	defer span.End()
+
+	start := time.Now()
+	err = d.deliver(ctx, job, connection)
+
+	result := metrics.DeliverySuccess
+	if err != nil {
+		result = metrics.DeliveryFailure
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	metrics.ObserveDelivery(connection.Id.Hex(), connection.UserDeliveryServerId.Hex(), result, time.Since(start))
+	return err
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, job models.Job, connection models.Connection) error {
+	if connection.Status != "active" {
+		return fmt.Errorf("connection %s is not active", connection.Id.Hex())
+	}
+	if connection.UserDeliveryServerWebHookUrl == "" {
+		return fmt.Errorf("connection %s has no webhook url", connection.Id.Hex())
+	}
+
+	// URL đã được kiểm tra lúc lưu nhưng policy hoặc DNS có thể đã thay đổi
+	if err := d.policy.CheckURL(ctx, connection.UserDeliveryServerWebHookUrl); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(Payload{
+		Id:           job.Id,
+		ConnectionId: job.ConnectionId,
+		Message:      string(job.Message),
+		CreatedAt:    job.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, connection.UserDeliveryServerWebHookUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "draft-notification-webhook")
+	req.Header.Set(EventHeader, EventNotification)
+	// traceparent để user delivery server nối tiếp trace của notification
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	// Chữ ký trên "timestamp.body" để user delivery server kiểm tra nguồn gửi và chống replay
+	if connection.WebhookSecret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, "sha256="+Sign(string(connection.WebhookSecret), []byte(timestamp+"."+string(body))))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	logging.FromContext(ctx).InfoContext(ctx, "Webhook đã phản hồi",
+		"user_delivery_server_id", connection.UserDeliveryServerId.Hex(), "status", resp.StatusCode)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ErrURLNotAllowed được trả về khi webhook URL vi phạm policy
+var ErrURLNotAllowed = errors.New("webhook url not allowed")
+
+// Các dải địa chỉ không nằm trong IsPrivate/IsLoopback/... nhưng vẫn không được gọi tới
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// Policy giới hạn các URL mà server được phép gọi webhook tới, chống SSRF
+type Policy struct {
+	HTTPSOnly bool
+	// Không rỗng thì chỉ các host khớp mới được phép. "example.com" khớp chính xác,
+	// "*.example.com" khớp mọi subdomain
+	AllowedHosts []string
+	DeniedHosts  []string
+	// Rỗng thì cho phép mọi port
+	AllowedPorts []int
+	// Cho phép gọi địa chỉ private/loopback/link-local, chỉ dùng khi phát triển local
+	AllowPrivateNetworks bool
+	Resolver             *net.Resolver
+}
+
+// CheckURL kiểm tra URL lúc lưu và trước mỗi lần gửi: scheme, host, port và
+// mọi địa chỉ IP mà host resolve ra
+func (p Policy) CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.ParseRequestURI(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: invalid url", ErrURLNotAllowed)
+	}
+
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if p.HTTPSOnly {
+			return fmt.Errorf("%w: only https is allowed", ErrURLNotAllowed)
+		}
+	default:
+		return fmt.Errorf("%w: scheme %q is not allowed", ErrURLNotAllowed, u.Scheme)
+	}
+
+	if u.User != nil {
+		return fmt.Errorf("%w: credentials in url are not allowed", ErrURLNotAllowed)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrURLNotAllowed)
+	}
+	if err := p.checkHost(host); err != nil {
+		return err
+	}
+
+	port := 443
+	if u.Scheme == "http" {
+		port = 80
+	}
+	if u.Port() != "" {
+		port, err = strconv.Atoi(u.Port())
+		if err != nil {
+			return fmt.Errorf("%w: invalid port", ErrURLNotAllowed)
+		}
+	}
+	if err := p.checkPort(port); err != nil {
+		return err
+	}
+
+	if p.AllowPrivateNetworks {
+		return nil
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return checkAddr(addr)
+	}
+
+	addrs, err := p.resolver().LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("%w: cannot resolve host %s", ErrURLNotAllowed, host)
+	}
+	for _, addr := range addrs {
+		if err := checkAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Control dùng cho net.Dialer, kiểm tra lại địa chỉ thật sự được dial để chống DNS rebinding
+func (p Policy) Control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: invalid address %s", ErrURLNotAllowed, address)
+	}
+	if err := p.checkPort(int(addrPort.Port())); err != nil {
+		return err
+	}
+	if p.AllowPrivateNetworks {
+		return nil
+	}
+	return checkAddr(addrPort.Addr())
+}
+
+func (p Policy) checkHost(host string) error {
+	for _, pattern := range p.DeniedHosts {
+		if matchHost(pattern, host) {
+			return fmt.Errorf("%w: host %s is denied", ErrURLNotAllowed, host)
+		}
+	}
+	if len(p.AllowedHosts) == 0 {
+		return nil
+	}
+	for _, pattern := range p.AllowedHosts {
+		if matchHost(pattern, host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: host %s is not in the allowlist", ErrURLNotAllowed, host)
+}
+
+func (p Policy) checkPort(port int) error {
+	if len(p.AllowedPorts) == 0 {
+		return nil
+	}
+	for _, allowed := range p.AllowedPorts {
+		if port == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: port %d is not allowed", ErrURLNotAllowed, port)
+}
+
+func (p Policy) resolver() *net.Resolver {
+	if p.Resolver != nil {
+		return p.Resolver
+	}
+	return net.DefaultResolver
+}
+
+func matchHost(pattern, host string) bool {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == pattern
+}
+
+func checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return fmt.Errorf("%w: address %s is not public", ErrURLNotAllowed, addr)
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("%w: address %s is not public", ErrURLNotAllowed, addr)
+		}
+	}
+	return nil
+}
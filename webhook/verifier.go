@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"draft-notification/helpers"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrVerificationFailed được trả về khi webhook không trả lời đúng challenge
+var ErrVerificationFailed = errors.New("webhook verification failed")
+
+type challengeRequest struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+}
+
+// Webhook phải trả về challenge nhận được và HMAC-SHA256 (hex) của challenge với secret của connection
+type challengeResponse struct {
+	Challenge string `json:"challenge"`
+	Signature string `json:"signature"`
+}
+
+// Verifier xác minh webhook URL bằng challenge trước khi connection được active
+type Verifier struct {
+	policy Policy
+	client *http.Client
+}
+
+func NewVerifier(policy Policy, timeout time.Duration) *Verifier {
+	return &Verifier{policy: policy, client: newHTTPClient(policy, timeout)}
+}
+
+// CheckURL kiểm tra webhook URL theo policy chống SSRF
+func (v *Verifier) CheckURL(ctx context.Context, rawURL string) error {
+	return v.policy.CheckURL(ctx, rawURL)
+}
+
+// Verify gửi challenge tới webhook URL và kiểm tra chữ ký trong response
+func (v *Verifier) Verify(ctx context.Context, rawURL, secret string) error {
+	if secret == "" {
+		return fmt.Errorf("%w: connection has no webhook secret, rotate it first", ErrVerificationFailed)
+	}
+	if err := v.policy.CheckURL(ctx, rawURL); err != nil {
+		return err
+	}
+
+	challenge, err := helpers.GenerateAPIKey(16)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(challengeRequest{Type: EventUrlVerification, Challenge: challenge})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "draft-notification-webhook")
+	req.Header.Set(EventHeader, EventUrlVerification)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: webhook responded with status %d", ErrVerificationFailed, resp.StatusCode)
+	}
+
+	var answer challengeResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&answer); err != nil {
+		return fmt.Errorf("%w: invalid response body", ErrVerificationFailed)
+	}
+
+	if answer.Challenge != challenge {
+		return fmt.Errorf("%w: challenge mismatch", ErrVerificationFailed)
+	}
+	if !hmac.Equal([]byte(answer.Signature), []byte(Sign(secret, []byte(challenge)))) {
+		return fmt.Errorf("%w: invalid signature", ErrVerificationFailed)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	const secret = "webhook-secret"
+
+	tests := []struct {
+		name     string
+		respond  func(w http.ResponseWriter, challenge string)
+		noSecret bool
+		want     string
+	}{
+		{
+			name: "signed challenge",
+			respond: func(w http.ResponseWriter, challenge string) {
+				json.NewEncoder(w).Encode(challengeResponse{Challenge: challenge, Signature: Sign(secret, []byte(challenge))})
+			},
+		},
+		{
+			name: "signed with another secret",
+			respond: func(w http.ResponseWriter, challenge string) {
+				json.NewEncoder(w).Encode(challengeResponse{Challenge: challenge, Signature: Sign("other-secret", []byte(challenge))})
+			},
+			want: "invalid signature",
+		},
+		{
+			name: "wrong challenge echoed",
+			respond: func(w http.ResponseWriter, challenge string) {
+				json.NewEncoder(w).Encode(challengeResponse{Challenge: "other", Signature: Sign(secret, []byte("other"))})
+			},
+			want: "challenge mismatch",
+		},
+		{
+			name: "error status",
+			respond: func(w http.ResponseWriter, challenge string) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			want: "status 500",
+		},
+		{
+			name: "not json",
+			respond: func(w http.ResponseWriter, challenge string) {
+				w.Write([]byte("ok"))
+			},
+			want: "invalid response body",
+		},
+		{
+			name:     "no secret",
+			respond:  func(w http.ResponseWriter, challenge string) {},
+			noSecret: true,
+			want:     "no webhook secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var request challengeRequest
+				if r.Header.Get(EventHeader) != EventUrlVerification || json.NewDecoder(r.Body).Decode(&request) != nil ||
+					request.Type != EventUrlVerification || request.Challenge == "" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				tt.respond(w, request.Challenge)
+			}))
+			defer server.Close()
+
+			useSecret := secret
+			if tt.noSecret {
+				useSecret = ""
+			}
+			verifier := NewVerifier(Policy{AllowPrivateNetworks: true}, time.Second)
+			err := verifier.Verify(context.Background(), server.URL, useSecret)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Verify = %v, want success", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrVerificationFailed) || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Verify = %v, want ErrVerificationFailed with %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("verifier called a URL rejected by the policy")
+	}))
+	defer server.Close()
+
+	verifier := NewVerifier(Policy{}, time.Second)
+	if err := verifier.Verify(context.Background(), server.URL, "secret"); !errors.Is(err, ErrURLNotAllowed) {
+		t.Fatalf("Verify loopback URL = %v, want ErrURLNotAllowed", err)
+	}
+}